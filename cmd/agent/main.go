@@ -14,14 +14,18 @@ import (
 
 func main() {
 	config.PrintBuildInfo()
-	cfg, err := config.GetAgentConfig()
+	cfg, err := config.NewAgentConfig(os.Args[0], os.Args[1:])
 	if err != nil {
 		log.Fatalf("stopped agent: %s", err)
 	}
+	if cfg.CheckConfig {
+		log.Println("configuration is valid")
+		return
+	}
 
 	pollTick := time.Tick(cfg.PollInterval.Duration)
 	reportTick := time.Tick(cfg.ReportInterval.Duration)
-	metricWorkers, err := worker.NewMetricWorkers()
+	metricWorkers, err := worker.NewMetricWorkers(cfg)
 	if err != nil {
 		log.Fatalf("stopped agent: %s", err)
 	}
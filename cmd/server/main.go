@@ -2,6 +2,7 @@ package main
 
 import (
 	"log"
+	"os"
 
 	"github.com/SpaceSlow/execenv/internal/config"
 	"github.com/SpaceSlow/execenv/internal/server"
@@ -9,7 +10,15 @@ import (
 
 func main() {
 	config.PrintBuildInfo()
-	if srv, err := server.NewServer(); err != nil || srv.Run() != nil {
+	cfg, err := config.NewServerConfig(os.Args[0], os.Args[1:])
+	if err != nil {
+		log.Fatalf("Error occured: %s.\r\nExiting...", err)
+	}
+	if cfg.CheckConfig {
+		log.Println("configuration is valid")
+		return
+	}
+	if srv, err := server.NewServer(cfg); err != nil || srv.Run() != nil {
 		log.Fatalf("Error occured: %s.\r\nExiting...", err)
 	}
 }
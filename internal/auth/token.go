@@ -0,0 +1,169 @@
+// Package auth реализует аутентификацию запросов по API-токенам (Authorization: Bearer <токен>).
+//
+// В конфигурации хранятся только SHA-256 хэши токенов (HashToken), каждому токену назначаются области доступа:
+// read — чтение метрик, write — запись метрик, admin — любые операции.
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/SpaceSlow/execenv/internal/logger"
+)
+
+var (
+	_ flag.Value = (*Tokens)(nil)
+
+	ErrIncorrectToken = errors.New("need token in a form <name>:<sha256 hex of token>:<scope>[+<scope>...], scopes: read, write, admin")
+	// ErrNoToken в запросе не указан токен.
+	ErrNoToken = errors.New("no bearer token")
+	// ErrInvalidToken токен не найден среди настроенных.
+	ErrInvalidToken = errors.New("invalid bearer token")
+	// ErrForbidden у токена нет необходимой области доступа.
+	ErrForbidden = errors.New("token has no required scope")
+)
+
+const bearerPrefix = "Bearer "
+
+// Scope область доступа токена.
+type Scope string
+
+const (
+	ScopeRead  Scope = "read"
+	ScopeWrite Scope = "write"
+	ScopeAdmin Scope = "admin"
+)
+
+func (s Scope) isValid() bool {
+	return s == ScopeRead || s == ScopeWrite || s == ScopeAdmin
+}
+
+// Token настроенный API-токен.
+type Token struct {
+	Name string
+	// Hash SHA-256 хэш токена в шестнадцатеричном виде
+	Hash   string
+	Scopes []Scope
+}
+
+// HashToken возвращает хэш токена для конфигурации.
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+func (t *Token) UnmarshalText(text []byte) error {
+	parts := strings.Split(string(text), ":")
+	if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+		return ErrIncorrectToken
+	}
+	if hash, err := hex.DecodeString(parts[1]); err != nil || len(hash) != sha256.Size {
+		return ErrIncorrectToken
+	}
+
+	scopes := make([]Scope, 0)
+	for _, scope := range strings.Split(parts[2], "+") {
+		if !Scope(scope).isValid() {
+			return ErrIncorrectToken
+		}
+		scopes = append(scopes, Scope(scope))
+	}
+
+	t.Name = parts[0]
+	t.Hash = strings.ToLower(parts[1])
+	t.Scopes = scopes
+	return nil
+}
+
+func (t Token) String() string {
+	scopes := make([]string, 0, len(t.Scopes))
+	for _, scope := range t.Scopes {
+		scopes = append(scopes, string(scope))
+	}
+	return t.Name + ":" + t.Hash + ":" + strings.Join(scopes, "+")
+}
+
+// Allows сообщает, разрешена ли токену операция с областью доступа scope. Токену admin разрешены все операции.
+func (t Token) Allows(scope Scope) bool {
+	return slices.Contains(t.Scopes, ScopeAdmin) || slices.Contains(t.Scopes, scope)
+}
+
+// Tokens набор настроенных токенов. Пустой набор отключает аутентификацию.
+type Tokens []Token
+
+func (t *Tokens) String() string {
+	if t == nil {
+		return ""
+	}
+	tokens := make([]string, 0, len(*t))
+	for _, token := range *t {
+		tokens = append(tokens, token.String())
+	}
+	return strings.Join(tokens, ",")
+}
+
+// Set добавляет токен, позволяя указывать флаг несколько раз.
+func (t *Tokens) Set(s string) error {
+	var token Token
+	if err := token.UnmarshalText([]byte(s)); err != nil {
+		return err
+	}
+	*t = append(*t, token)
+	return nil
+}
+
+// Enabled сообщает, включена ли аутентификация.
+func (t Tokens) Enabled() bool {
+	return len(t) > 0
+}
+
+// Authorize находит токен по значению заголовка Authorization и проверяет наличие у него области доступа scope.
+func (t Tokens) Authorize(authorization string, scope Scope) (Token, error) {
+	value, ok := strings.CutPrefix(authorization, bearerPrefix)
+	if !ok || value == "" {
+		return Token{}, ErrNoToken
+	}
+
+	hash := []byte(HashToken(value))
+	for _, token := range t {
+		if subtle.ConstantTimeCompare(hash, []byte(token.Hash)) != 1 {
+			continue
+		}
+		if !token.Allows(scope) {
+			return token, ErrForbidden
+		}
+		return token, nil
+	}
+	return Token{}, ErrInvalidToken
+}
+
+// BearerAuthorization возвращает значение заголовка Authorization для токена token.
+func BearerAuthorization(token string) string {
+	return bearerPrefix + token
+}
+
+type tokenKey struct{}
+
+// WithToken возвращает контекст запроса, выполненного с токеном token.
+func WithToken(ctx context.Context, token Token) context.Context {
+	return context.WithValue(ctx, tokenKey{}, token)
+}
+
+// TokenFromContext возвращает токен, с которым выполнен запрос.
+func TokenFromContext(ctx context.Context) (Token, bool) {
+	token, ok := ctx.Value(tokenKey{}).(Token)
+	return token, ok
+}
+
+// Audit записывает в журнал аудита операцию, выполненную с токеном token.
+func Audit(token Token, fields ...zap.Field) {
+	logger.Log.Info("audit", append([]zap.Field{zap.String("token", token.Name)}, fields...)...)
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const secretHash = "2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b" // sha256("secret")
+
+func TestHashToken(t *testing.T) {
+	assert.Equal(t, secretHash, HashToken("secret"))
+}
+
+func TestToken_UnmarshalText(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    Token
+		wantErr error
+	}{
+		{
+			name: "one scope",
+			text: "agent:" + secretHash + ":write",
+			want: Token{Name: "agent", Hash: secretHash, Scopes: []Scope{ScopeWrite}},
+		},
+		{
+			name: "several scopes",
+			text: "grafana:" + secretHash + ":read+write",
+			want: Token{Name: "grafana", Hash: secretHash, Scopes: []Scope{ScopeRead, ScopeWrite}},
+		},
+		{name: "unknown scope", text: "agent:" + secretHash + ":delete", wantErr: ErrIncorrectToken},
+		{name: "no scopes", text: "agent:" + secretHash + ":", wantErr: ErrIncorrectToken},
+		{name: "no name", text: ":" + secretHash + ":read", wantErr: ErrIncorrectToken},
+		{name: "plain token instead of hash", text: "agent:secret:read", wantErr: ErrIncorrectToken},
+		{name: "no parts", text: "agent", wantErr: ErrIncorrectToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var token Token
+			err := token.UnmarshalText([]byte(tt.text))
+			require.ErrorIs(t, err, tt.wantErr)
+			if tt.wantErr == nil {
+				assert.Equal(t, tt.want, token)
+				assert.Equal(t, tt.text, token.String())
+			}
+		})
+	}
+}
+
+func TestTokens_Authorize(t *testing.T) {
+	tokens := Tokens{
+		{Name: "reader", Hash: HashToken("read-secret"), Scopes: []Scope{ScopeRead}},
+		{Name: "root", Hash: HashToken("admin-secret"), Scopes: []Scope{ScopeAdmin}},
+	}
+
+	tests := []struct {
+		wantErr       error
+		name          string
+		authorization string
+		scope         Scope
+		wantToken     string
+	}{
+		{name: "allowed scope", authorization: "Bearer read-secret", scope: ScopeRead, wantToken: "reader"},
+		{name: "admin allows everything", authorization: "Bearer admin-secret", scope: ScopeWrite, wantToken: "root"},
+		{name: "missing scope", authorization: "Bearer read-secret", scope: ScopeWrite, wantToken: "reader", wantErr: ErrForbidden},
+		{name: "unknown token", authorization: "Bearer other", scope: ScopeRead, wantErr: ErrInvalidToken},
+		{name: "no header", authorization: "", scope: ScopeRead, wantErr: ErrNoToken},
+		{name: "not bearer", authorization: "Basic cmVhZC1zZWNyZXQ=", scope: ScopeRead, wantErr: ErrNoToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := tokens.Authorize(tt.authorization, tt.scope)
+			require.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.wantToken, token.Name)
+		})
+	}
+}
+
+func TestTokenFromContext(t *testing.T) {
+	_, ok := TokenFromContext(context.Background())
+	assert.False(t, ok)
+
+	token, ok := TokenFromContext(WithToken(context.Background(), Token{Name: "agent"}))
+	assert.True(t, ok)
+	assert.Equal(t, "agent", token.Name)
+}
@@ -9,17 +9,16 @@ type Client struct {
 	sender Sender
 }
 
-func NewClient() (*Client, error) {
-	cfg, err := config.GetAgentConfig()
-	if err != nil {
-		return nil, err
-	}
-
-	var sender Sender
+// NewClient создаёт клиент, отправляющий метрики на сервер в соответствии с конфигурацией агента cfg.
+func NewClient(cfg *config.AgentConfig) (*Client, error) {
+	var (
+		sender Sender
+		err    error
+	)
 	if cfg.UsedGRPCAgent {
-		sender, err = newGrpcSender()
+		sender, err = newGrpcSender(cfg)
 	} else {
-		sender, err = newHTTPSender()
+		sender, err = newHTTPSender(cfg)
 	}
 	if err != nil {
 		return nil, err
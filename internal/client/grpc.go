@@ -5,9 +5,11 @@ import (
 	"log"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
 
+	"github.com/SpaceSlow/execenv/internal/auth"
 	"github.com/SpaceSlow/execenv/internal/config"
 	"github.com/SpaceSlow/execenv/internal/metrics"
 	pb "github.com/SpaceSlow/execenv/internal/proto"
@@ -17,27 +19,33 @@ import (
 var _ Sender = (*grpcSender)(nil)
 
 type grpcSender struct {
-	addr string
+	cfg   *config.AgentConfig
+	creds credentials.TransportCredentials
+	addr  string
 }
 
-func newGrpcSender() (*grpcSender, error) {
-	cfg, err := config.GetAgentConfig()
+func newGrpcSender(cfg *config.AgentConfig) (*grpcSender, error) {
+	tlsConfig, err := cfg.TLSConfig()
 	if err != nil {
 		return nil, err
 	}
 	s := &grpcSender{
-		addr: cfg.ServerAddr.String(),
+		cfg:   cfg,
+		creds: insecure.NewCredentials(),
+		addr:  cfg.ServerAddr.String(),
+	}
+	if tlsConfig != nil {
+		s.creds = credentials.NewTLS(tlsConfig)
 	}
 
 	return s, nil
 }
 
 func (s *grpcSender) Send(metrics []metrics.Metric) error {
-	cfg, err := config.GetAgentConfig()
-	if err != nil {
-		return err
+	md := metadata.New(map[string]string{"X-Real-IP": s.cfg.LocalIP})
+	if s.cfg.APIToken != "" {
+		md.Set("authorization", auth.BearerAuthorization(s.cfg.APIToken))
 	}
-	md := metadata.New(map[string]string{"X-Real-IP": cfg.LocalIP})
 	ctx := metadata.NewOutgoingContext(context.Background(), md)
 
 	pbMetrics := make([]*pb.Metric, 0, len(metrics))
@@ -49,7 +57,7 @@ func (s *grpcSender) Send(metrics []metrics.Metric) error {
 		pbMetrics = append(pbMetrics, metric)
 	}
 
-	conn, err := grpc.NewClient(":8080", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := grpc.NewClient(s.addr, grpc.WithTransportCredentials(s.creds))
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -75,5 +83,5 @@ func (s *grpcSender) Send(metrics []metrics.Metric) error {
 		return nil
 	}
 
-	return <-utils.RetryFunc(sendMetrics, cfg.Delays)
+	return <-utils.RetryFunc(sendMetrics, s.cfg.Delays.Values())
 }
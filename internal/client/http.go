@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/SpaceSlow/execenv/internal/auth"
 	"github.com/SpaceSlow/execenv/internal/config"
 	"github.com/SpaceSlow/execenv/internal/metrics"
 	"github.com/SpaceSlow/execenv/internal/utils"
@@ -18,17 +19,27 @@ import (
 var _ Sender = (*httpSender)(nil)
 
 type httpSender struct {
-	url  string
-	cert *rsa.PublicKey
+	cfg    *config.AgentConfig
+	client *http.Client
+	cert   *rsa.PublicKey
+	url    string
 }
 
-func newHTTPSender() (*httpSender, error) {
-	cfg, err := config.GetAgentConfig()
+func newHTTPSender(cfg *config.AgentConfig) (*httpSender, error) {
+	tlsConfig, err := cfg.TLSConfig()
 	if err != nil {
 		return nil, err
 	}
 	s := &httpSender{
-		url: "http://" + cfg.ServerAddr.String() + "/updates/",
+		cfg:    cfg,
+		client: http.DefaultClient,
+		url:    "http://" + cfg.ServerAddr.String() + "/updates/",
+	}
+	if tlsConfig != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		s.client = &http.Client{Transport: transport}
+		s.url = "https://" + cfg.ServerAddr.String() + "/updates/"
 	}
 
 	if cfg.CertFile != "" {
@@ -42,19 +53,15 @@ func newHTTPSender() (*httpSender, error) {
 }
 
 func (s *httpSender) Send(metrics []metrics.Metric) error {
-	cfg, err := config.GetAgentConfig()
-	if err != nil {
-		return err
-	}
 	data, err := json.Marshal(metrics)
 	if err != nil {
 		return err
 	}
 
 	var hash string
-	if cfg.Key != "" {
+	if s.cfg.Key != "" {
 		h := sha256.New()
-		h.Write(append(data, []byte(cfg.Key)...))
+		h.Write(append(data, []byte(s.cfg.Key)...))
 		hash = hex.EncodeToString(h.Sum(nil))
 	}
 
@@ -76,7 +83,10 @@ func (s *httpSender) Send(metrics []metrics.Metric) error {
 	}
 	req.Header.Set("Content-Encoding", "gzip")
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-Real-IP", cfg.LocalIP)
+	req.Header.Set("X-Real-IP", s.cfg.LocalIP)
+	if s.cfg.APIToken != "" {
+		req.Header.Set("Authorization", auth.BearerAuthorization(s.cfg.APIToken))
+	}
 
 	if hash != "" {
 		req.Header.Set("Hash", hash)
@@ -86,7 +96,7 @@ func (s *httpSender) Send(metrics []metrics.Metric) error {
 	defer close(resCh)
 	sendMetrics := func() error {
 		var res *http.Response
-		res, err = http.DefaultClient.Do(req)
+		res, err = s.client.Do(req)
 		if err != nil {
 			if len(resCh) > 0 {
 				<-resCh
@@ -104,5 +114,5 @@ func (s *httpSender) Send(metrics []metrics.Metric) error {
 		return nil
 	}
 
-	return <-utils.RetryFunc(sendMetrics, cfg.Delays)
+	return <-utils.RetryFunc(sendMetrics, s.cfg.Delays.Values())
 }
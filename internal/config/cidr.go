@@ -2,14 +2,18 @@ package config
 
 import (
 	"encoding"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"net"
+	"strings"
 )
 
 var (
 	_ flag.Value               = (*CIDR)(nil)
 	_ encoding.TextUnmarshaler = (*CIDR)(nil)
+	_ flag.Value               = (*CIDRs)(nil)
+	_ json.Unmarshaler         = (*CIDRs)(nil)
 )
 
 type CIDR struct {
@@ -41,3 +45,64 @@ func (c *CIDR) Set(s string) error {
 func (c *CIDR) UnmarshalText(text []byte) error {
 	return c.Set(string(text))
 }
+
+// CIDRs список подсетей IPv4 и IPv6.
+type CIDRs []CIDR
+
+func (c *CIDRs) String() string {
+	if c == nil {
+		return ""
+	}
+	cidrs := make([]string, 0, len(*c))
+	for _, cidr := range *c {
+		cidrs = append(cidrs, cidr.String())
+	}
+	return strings.Join(cidrs, ",")
+}
+
+// Set добавляет подсети, перечисленные через запятую, позволяя указывать флаг несколько раз.
+func (c *CIDRs) Set(s string) error {
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		var cidr CIDR
+		if err := cidr.Set(part); err != nil {
+			return err
+		}
+		*c = append(*c, cidr)
+	}
+	return nil
+}
+
+// UnmarshalJSON принимает как строку с подсетями через запятую, так и массив строк.
+func (c *CIDRs) UnmarshalJSON(data []byte) error {
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return fmt.Errorf("parse cidr list error: %w", err)
+		}
+		list = []string{s}
+	}
+
+	*c = nil
+	for _, s := range list {
+		if err := c.Set(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IPNets возвращает подсети списка.
+func (c CIDRs) IPNets() []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(c))
+	for _, cidr := range c {
+		if cidr.IPNet != nil {
+			nets = append(nets, cidr.IPNet)
+		}
+	}
+	return nets
+}
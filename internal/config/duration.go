@@ -4,6 +4,7 @@ import (
 	"encoding"
 	"encoding/json"
 	"flag"
+	"strings"
 	"time"
 )
 
@@ -36,3 +37,67 @@ func (d *Duration) UnmarshalJSON(b []byte) error {
 	d.Duration, err = time.ParseDuration(value)
 	return err
 }
+
+var (
+	_ flag.Value       = (*Durations)(nil)
+	_ json.Unmarshaler = (*Durations)(nil)
+)
+
+// Durations список интервалов, например задержек между повторными попытками.
+type Durations []Duration
+
+func NewDurations(durations ...time.Duration) Durations {
+	d := make(Durations, 0, len(durations))
+	for _, duration := range durations {
+		d = append(d, Duration{duration})
+	}
+	return d
+}
+
+func (d *Durations) String() string {
+	if d == nil {
+		return ""
+	}
+	durations := make([]string, 0, len(*d))
+	for _, duration := range *d {
+		durations = append(durations, duration.String())
+	}
+	return strings.Join(durations, ",")
+}
+
+// Set заменяет список интервалами, перечисленными через запятую.
+func (d *Durations) Set(s string) error {
+	durations := make(Durations, 0)
+	for _, value := range strings.Split(s, ",") {
+		var duration Duration
+		if err := duration.Set(strings.TrimSpace(value)); err != nil {
+			return err
+		}
+		durations = append(durations, duration)
+	}
+	*d = durations
+	return nil
+}
+
+// UnmarshalJSON принимает массив интервалов или строку с интервалами, перечисленными через запятую.
+func (d *Durations) UnmarshalJSON(b []byte) error {
+	var value string
+	if err := json.Unmarshal(b, &value); err == nil {
+		return d.Set(value)
+	}
+	var durations []Duration
+	if err := json.Unmarshal(b, &durations); err != nil {
+		return err
+	}
+	*d = durations
+	return nil
+}
+
+// Values возвращает интервалы списка.
+func (d Durations) Values() []time.Duration {
+	values := make([]time.Duration, 0, len(d))
+	for _, duration := range d {
+		values = append(values, duration.Duration)
+	}
+	return values
+}
@@ -51,3 +51,40 @@ func TestDuration_UnmarshalJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestDurations_Set(t *testing.T) {
+	tests := []struct {
+		name              string
+		value             string
+		expectedDurations Durations
+		wantErr           bool
+	}{
+		{
+			name:              "single duration",
+			value:             "1s",
+			expectedDurations: NewDurations(time.Second),
+		},
+		{
+			name:              "durations separated by commas",
+			value:             "1s, 500ms,2m",
+			expectedDurations: NewDurations(time.Second, 500*time.Millisecond, 2*time.Minute),
+		},
+		{
+			name:    "incorrect duration",
+			value:   "1s,incorrect",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDurations(time.Hour)
+
+			err := d.Set(tt.value)
+			require.Equal(t, tt.wantErr, err != nil)
+
+			if err == nil {
+				assert.Equal(t, tt.expectedDurations, d)
+			}
+		})
+	}
+}
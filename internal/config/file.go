@@ -0,0 +1,159 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+var ErrUndefinedVariable = errors.New("undefined environment variable")
+
+// fileEnvSuffix суффикс переменной окружения с путём к файлу, содержащему значение параметра (например, KEY_FILE).
+const fileEnvSuffix = "_FILE"
+
+// fileEnvFields параметры-секреты, значения которых можно передать в файле из переменной окружения <ИМЯ>_FILE
+// (Docker secrets, Kubernetes). Параметры, значение которых само является путём к файлу (CRYPTO_KEY, TLS_KEY),
+// сюда не входят.
+var fileEnvFields = map[string]struct{}{
+	"key":          {},
+	"database_dsn": {},
+	"api_token":    {},
+}
+
+// hasFileEnv сообщает, можно ли передать значение параметра field в файле из переменной окружения <ИМЯ>_FILE.
+func hasFileEnv(field reflect.StructField) bool {
+	if field.Type.Kind() != reflect.String {
+		return false
+	}
+	_, ok := fileEnvFields[fieldName(field)]
+	return ok
+}
+
+// variablePattern ссылка на переменную окружения ${NAME} в значении параметра файла конфигурации.
+var variablePattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// readFile читает параметры из файла конфигурации path в формате YAML (.yaml, .yml), TOML (.toml)
+// или JSON (остальные расширения) и подставляет в строковые значения переменные окружения ${NAME}.
+func readFile(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]any)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &fields)
+	case ".toml":
+		err = toml.Unmarshal(data, &fields)
+	default:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		// большие целые числа не должны терять точность при преобразовании во float64
+		decoder.UseNumber()
+		err = decoder.Decode(&fields)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	expanded, err := expandVariables(fields)
+	if err != nil {
+		return nil, err
+	}
+	return expanded.(map[string]any), nil
+}
+
+// decodeFile записывает в target параметры из файла конфигурации path (readFile).
+// Значения разбираются так же, как в JSON-файле конфигурации, независимо от формата файла.
+func decodeFile(path string, target any) error {
+	fields, err := readFile(path)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
+
+// expandVariables подставляет переменные окружения ${NAME} во все строковые значения value.
+func expandVariables(value any) (any, error) {
+	switch v := value.(type) {
+	case string:
+		var err error
+		expanded := variablePattern.ReplaceAllStringFunc(v, func(ref string) string {
+			name := variablePattern.FindStringSubmatch(ref)[1]
+			variable, ok := os.LookupEnv(name)
+			if !ok && err == nil {
+				err = fmt.Errorf("%w: %s", ErrUndefinedVariable, name)
+			}
+			return variable
+		})
+		return expanded, err
+	case map[string]any:
+		for key, item := range v {
+			expanded, err := expandVariables(item)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = expanded
+		}
+		return v, nil
+	case []any:
+		for i, item := range v {
+			expanded, err := expandVariables(item)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = expanded
+		}
+		return v, nil
+	default:
+		return value, nil
+	}
+}
+
+// envFileValue возвращает содержимое файла, указанного в переменной окружения <key>_FILE, если переменная
+// окружения key не задана. Так передаются секреты, например KEY_FILE или DATABASE_DSN_FILE.
+func envFileValue(key string) (string, bool, error) {
+	path := os.Getenv(key + fileEnvSuffix)
+	if os.Getenv(key) != "" || path == "" {
+		return "", false, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("read %s: %w", key+fileEnvSuffix, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+// parseEnvFiles записывает в параметры-секреты target (fileEnvFields) значения из файлов, указанных
+// в переменных окружения <ИМЯ>_FILE (envFileValue).
+func parseEnvFiles(target any) error {
+	value := reflect.ValueOf(target).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		key := field.Tag.Get("env")
+		if key == "" || !hasFileEnv(field) {
+			continue
+		}
+		fileValue, ok, err := envFileValue(key)
+		if err != nil {
+			return err
+		}
+		if ok {
+			value.Field(i).SetString(fileValue)
+		}
+	}
+	return nil
+}
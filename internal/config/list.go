@@ -0,0 +1,27 @@
+package config
+
+import "flag"
+
+// listValue значение флага-списка. Первое указание флага заменяет список, заданный в файле конфигурации,
+// повторные указания дополняют его.
+type listValue struct {
+	flag.Value
+	reset func()
+	set   bool
+}
+
+func (v *listValue) Set(s string) error {
+	if !v.set {
+		v.reset()
+		v.set = true
+	}
+	return v.Value.Set(s)
+}
+
+// listVar определяет флаг name для списка list (listValue).
+func listVar[S ~[]E, E any, P interface {
+	*S
+	flag.Value
+}](flagSet *flag.FlagSet, list P, name, usage string) {
+	flagSet.Var(&listValue{Value: list, reset: func() { *list = nil }}, name, usage)
+}
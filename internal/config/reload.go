@@ -0,0 +1,109 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var ErrNotLoaded = errors.New("server configuration is not loaded from command line")
+
+// reloadableFields параметры конфигурации (по имени в JSON), изменения которых применяются без перезапуска сервера.
+var reloadableFields = map[string]struct{}{
+	"trusted_subnet":  {},
+	"denied_subnets":  {},
+	"trusted_proxies": {},
+	"key":             {},
+	"log_level":       {},
+	"store_interval":  {},
+}
+
+// secretFields параметры конфигурации, значения которых не выводятся в журнал.
+var secretFields = map[string]struct{}{
+	"key":          {},
+	"database_dsn": {},
+	"api_tokens":   {},
+}
+
+const hiddenValue = "***"
+
+// Change изменение параметра конфигурации.
+type Change struct {
+	// Field имя параметра в JSON-файле конфигурации
+	Field string
+	Old   string
+	New   string
+	// Applied изменение применено без перезапуска сервера
+	Applied bool
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %q -> %q", c.Field, c.Old, c.New)
+}
+
+// Diff возвращает параметры, значения которых в конфигурации other отличаются от c.
+// Значения секретных параметров скрываются.
+func (c *ServerConfig) Diff(other *ServerConfig) []Change {
+	changes := make([]Change, 0)
+	oldValue, newValue := reflect.ValueOf(c).Elem(), reflect.ValueOf(other).Elem()
+	for i := 0; i < oldValue.NumField(); i++ {
+		field := oldValue.Type().Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "" || name == "-" {
+			continue
+		}
+		if reflect.DeepEqual(oldValue.Field(i).Interface(), newValue.Field(i).Interface()) {
+			continue
+		}
+
+		change := Change{
+			Field: name,
+			Old:   fmt.Sprint(oldValue.Field(i).Interface()),
+			New:   fmt.Sprint(newValue.Field(i).Interface()),
+		}
+		if _, ok := secretFields[name]; ok {
+			change.Old, change.New = hiddenValue, hiddenValue
+		}
+		_, change.Applied = reloadableFields[name]
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// withReloadable возвращает копию конфигурации c с параметрами, применяемыми без перезапуска, из конфигурации other.
+func (c *ServerConfig) withReloadable(other *ServerConfig) *ServerConfig {
+	cfg := *c
+	cfg.TrustedSubnet = other.TrustedSubnet
+	cfg.DeniedSubnets = other.DeniedSubnets
+	cfg.TrustedProxies = other.TrustedProxies
+	cfg.Key = other.Key
+	cfg.LogLevel = other.LogLevel
+	cfg.StoreInterval = other.StoreInterval
+	return &cfg
+}
+
+// Reload повторно читает конфигурацию сервера из файла, флагов запуска и переменных окружения, переданных
+// в NewServerConfig, и возвращает копию c с новыми значениями параметров, не требующих перезапуска сервера
+// (Change.Applied), и изменения относительно конфигурации, прочитанной при предыдущем Reload (или c).
+// Изменения, требующие перезапуска, возвращаются для вывода предупреждения один раз.
+func (c *ServerConfig) Reload() (*ServerConfig, []Change, error) {
+	if c.programName == "" {
+		return nil, nil, ErrNotLoaded
+	}
+
+	loaded, err := getServerConfig(c.programName, c.args)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = loaded.validate(serverSources(c.programName, c.args, loaded.ConfigFilePath)); err != nil {
+		return nil, nil, err
+	}
+	previous := c
+	if c.loaded != nil {
+		previous = c.loaded
+	}
+	cfg := c.withReloadable(loaded)
+	cfg.loaded = loaded
+	return cfg, previous.Diff(loaded), nil
+}
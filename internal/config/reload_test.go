@@ -0,0 +1,74 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerConfig_Diff(t *testing.T) {
+	current := defaultServerConfig
+	other := defaultServerConfig
+	other.Key = "new-key"
+	other.StoreInterval = Duration{time.Minute}
+	other.ServerAddr = NetAddress{Host: "example.com", Port: 80}
+
+	assert.Equal(t, []Change{
+		{Field: "key", Old: hiddenValue, New: hiddenValue, Applied: true},
+		{Field: "address", Old: "localhost:8080", New: "example.com:80"},
+		{Field: "store_interval", Old: "5m0s", New: "1m0s", Applied: true},
+	}, current.Diff(&other))
+
+	assert.Empty(t, current.Diff(&current))
+}
+
+func TestServerConfig_Reload(t *testing.T) {
+	filename, err := generateConfigFile([]byte(`{"address": "localhost:8080", "store_interval": "1s", "log_level": "info"}`))
+	require.NoError(t, err)
+	defer os.Remove(filename)
+	args := []string{"-config", filename}
+
+	notLoaded := defaultServerConfig
+	_, _, err = notLoaded.Reload()
+	require.ErrorIs(t, err, ErrNotLoaded)
+
+	initial, err := NewServerConfig("program", args)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filename, []byte(`{"address": "localhost:9090", "store_interval": "2s", "log_level": "debug", "trusted_subnet": "10.0.0.0/8"}`), 0o600))
+	cfg, changes, err := initial.Reload()
+	require.NoError(t, err)
+
+	assert.Equal(t, Duration{time.Second}, initial.StoreInterval, "initial config must not be changed")
+	assert.Equal(t, Duration{2 * time.Second}, cfg.StoreInterval)
+	assert.Equal(t, "debug", cfg.LogLevel)
+	assert.Equal(t, CIDRs{NewCIDR("10.0.0.0/8")}, cfg.TrustedSubnet)
+	assert.Equal(t, initial.ServerAddr, cfg.ServerAddr, "address must not be changed without restart")
+
+	applied := make(map[string]bool)
+	for _, change := range changes {
+		applied[change.Field] = change.Applied
+	}
+	assert.Equal(t, map[string]bool{
+		"address":        false,
+		"store_interval": true,
+		"log_level":      true,
+		"trusted_subnet": true,
+	}, applied)
+
+	_, changes, err = cfg.Reload()
+	require.NoError(t, err)
+	assert.Empty(t, changes, "pending changes must not be reported again")
+
+	require.NoError(t, os.WriteFile(filename, []byte(`{"address": "localhost:8080", "store_interval": "2s", "log_level": "debug", "trusted_subnet": "10.0.0.0/8"}`), 0o600))
+	_, changes, err = cfg.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, []Change{{Field: "address", Old: "localhost:9090", New: "localhost:8080"}}, changes)
+
+	require.NoError(t, os.WriteFile(filename, []byte(`{"log_level": "verbose"}`), 0o600))
+	_, _, err = cfg.Reload()
+	require.ErrorIs(t, err, ErrIncorrectLogLevel)
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"flag"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// SourceKind вид источника значения параметра конфигурации.
+type SourceKind string
+
+const (
+	SourceDefault SourceKind = "default"
+	SourceFile    SourceKind = "file"
+	SourceFlag    SourceKind = "flag"
+	SourceEnv     SourceKind = "env"
+)
+
+// Source источник значения параметра конфигурации: файл, флаг запуска или переменная окружения.
+type Source struct {
+	Kind SourceKind
+	// Name путь к файлу конфигурации, имя флага или переменной окружения
+	Name string
+}
+
+func (s Source) String() string {
+	switch s.Kind {
+	case SourceFile:
+		return "file " + s.Name
+	case SourceFlag:
+		return "flag -" + s.Name
+	case SourceEnv:
+		return "env " + s.Name
+	default:
+		return string(SourceDefault)
+	}
+}
+
+// fieldName возвращает имя параметра конфигурации: имя в файле конфигурации или, если параметр
+// не читается из файла, имя поля структуры.
+func fieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
+
+// valueSources возвращает источники значений параметров конфигурации target с учётом приоритета:
+// значения из файла configFile переопределяются флагами, флаги — переменными окружения.
+// Флаги flagSet должны быть определены для полей target и уже разобраны.
+func valueSources(target any, flagSet *flag.FlagSet, configFile string) map[string]Source {
+	sources := make(map[string]Source)
+	value := reflect.ValueOf(target).Elem()
+
+	if configFile != "" {
+		if fileFields, err := readFile(configFile); err == nil {
+			for i := 0; i < value.NumField(); i++ {
+				name := fieldName(value.Type().Field(i))
+				if _, ok := fileFields[name]; ok {
+					sources[name] = Source{Kind: SourceFile, Name: configFile}
+				}
+			}
+		}
+	}
+
+	// флаг связан с полем по адресу значения флага
+	fieldsByAddr := make(map[uintptr]string)
+	for i := 0; i < value.NumField(); i++ {
+		if value.Type().Field(i).IsExported() {
+			fieldsByAddr[value.Field(i).Addr().Pointer()] = fieldName(value.Type().Field(i))
+		}
+	}
+	flagSet.Visit(func(f *flag.Flag) {
+		v := f.Value
+		if list, ok := v.(*listValue); ok {
+			v = list.Value
+		}
+		flagValue := reflect.ValueOf(v)
+		if flagValue.Kind() != reflect.Pointer {
+			return
+		}
+		if name, ok := fieldsByAddr[flagValue.Pointer()]; ok {
+			sources[name] = Source{Kind: SourceFlag, Name: f.Name}
+		}
+	})
+
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		key := field.Tag.Get("env")
+		switch {
+		case key == "":
+		case os.Getenv(key) != "":
+			sources[fieldName(field)] = Source{Kind: SourceEnv, Name: key}
+		case hasFileEnv(field) && os.Getenv(key+fileEnvSuffix) != "":
+			sources[fieldName(field)] = Source{Kind: SourceEnv, Name: key + fileEnvSuffix}
+		}
+	}
+	return sources
+}
+
+// serverSources возвращает источники значений параметров конфигурации сервера.
+func serverSources(programName string, args []string, configFile string) map[string]Source {
+	cfg := defaultServerConfig
+	flagSet := cfg.flagSet(programName)
+	flagSet.SetOutput(io.Discard)
+	_ = flagSet.Parse(args)
+	return valueSources(&cfg, flagSet, configFile)
+}
+
+// agentSources возвращает источники значений параметров конфигурации агента.
+func agentSources(programName string, args []string, configFile string) map[string]Source {
+	cfg := defaultAgentConfig
+	flagSet := cfg.flagSet(programName)
+	flagSet.SetOutput(io.Discard)
+	_ = flagSet.Parse(args)
+	return valueSources(&cfg, flagSet, configFile)
+}
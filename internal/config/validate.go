@@ -0,0 +1,205 @@
+package config
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/SpaceSlow/execenv/internal/utils"
+)
+
+var (
+	ErrNotPositive           = errors.New("must be positive")
+	ErrNegative              = errors.New("must not be negative")
+	ErrReportShorterThanPoll = errors.New("must not be shorter than poll interval")
+	ErrDrainNotShorter       = errors.New("must be shorter than shutdown timeout")
+	ErrIncompleteKeyPair     = errors.New("certificate and key must be specified together")
+	ErrClientCAWithoutTLS    = errors.New("requires server certificate and key")
+	ErrIncorrectLogLevel     = errors.New("incorrect log level, expected debug, info, warn or error")
+)
+
+// FieldError ошибка значения параметра конфигурации с указанием источника значения.
+type FieldError struct {
+	Err error
+	// Field имя параметра в JSON-файле конфигурации
+	Field  string
+	Value  string
+	Source Source
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s=%q (%s): %s", e.Field, e.Value, e.Source, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// validator накапливает ошибки значений параметров конфигурации target.
+type validator struct {
+	target  reflect.Value
+	sources map[string]Source
+	errs    []error
+}
+
+func newValidator(target any, sources map[string]Source) *validator {
+	return &validator{
+		target:  reflect.ValueOf(target).Elem(),
+		sources: sources,
+	}
+}
+
+// check добавляет ошибку err параметра field, если err != nil.
+func (v *validator) check(field string, err error) {
+	if err == nil {
+		return
+	}
+	source, ok := v.sources[field]
+	if !ok {
+		source = Source{Kind: SourceDefault}
+	}
+	fieldErr := &FieldError{Err: err, Field: field, Source: source}
+	for i := 0; i < v.target.NumField(); i++ {
+		if fieldName(v.target.Type().Field(i)) == field {
+			fieldErr.Value = fmt.Sprint(v.target.Field(i).Interface())
+			break
+		}
+	}
+	v.errs = append(v.errs, fieldErr)
+}
+
+// err возвращает все накопленные ошибки.
+func (v *validator) err() error {
+	return errors.Join(v.errs...)
+}
+
+func positive[T ~int | ~int64 | ~float64](value T) error {
+	if value <= 0 {
+		return ErrNotPositive
+	}
+	return nil
+}
+
+func notNegative[T ~int | ~int64 | ~float64](value T) error {
+	if value < 0 {
+		return ErrNegative
+	}
+	return nil
+}
+
+func retryDelays(delays Durations) error {
+	for _, delay := range delays {
+		if err := notNegative(delay.Duration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readable проверяет, что файл path, если он указан, доступен для чтения.
+func readable(path string) error {
+	if path == "" {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// keyPair проверяет, что сертификат и ключ указаны вместе.
+func keyPair(certFile, keyFile string) error {
+	if (certFile == "") != (keyFile == "") {
+		return ErrIncompleteKeyPair
+	}
+	return nil
+}
+
+func logLevel(level string) error {
+	if _, err := zapcore.ParseLevel(level); err != nil {
+		return ErrIncorrectLogLevel
+	}
+	return nil
+}
+
+func loadPrivateKey(path string) (*rsa.PrivateKey, error) {
+	keyBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	keyBlock, _ := pem.Decode(keyBytes)
+	if keyBlock == nil {
+		return nil, utils.ErrDecodePEMBlock
+	}
+	return x509.ParsePKCS1PrivateKey(keyBlock.Bytes)
+}
+
+// validate проверяет конфигурацию сервера и возвращает все найденные ошибки (FieldError).
+func (c *ServerConfig) validate(sources map[string]Source) error {
+	v := newValidator(c, sources)
+
+	v.check("store_interval", notNegative(c.StoreInterval.Duration))
+	v.check("shutdown_drain", notNegative(c.ShutdownDrain.Duration))
+	v.check("shutdown_timeout", positive(c.TimeoutShutdown.Duration))
+	// сервер принудительно завершается по истечении shutdown_timeout с начала остановки, включая ожидание shutdown_drain
+	if c.TimeoutShutdown.Duration > 0 && c.ShutdownDrain.Duration >= c.TimeoutShutdown.Duration {
+		v.check("shutdown_drain", ErrDrainNotShorter)
+	}
+	v.check("retry_delays", retryDelays(c.Delays))
+	v.check("self_metrics_interval", notNegative(c.SelfMetricsInterval.Duration))
+	if c.StatsdAddr.String() != "" {
+		v.check("statsd_flush_interval", positive(c.StatsdFlushInterval.Duration))
+	}
+	v.check("log_level", logLevel(c.LogLevel))
+
+	v.check("agent_rate_limit", notNegative(c.AgentRateLimit))
+	v.check("agent_burst", notNegative(c.AgentBurst))
+	v.check("max_request_size", notNegative(c.MaxRequestSize))
+	v.check("max_batch_size", notNegative(c.MaxBatchSize))
+	v.check("max_series_per_agent", notNegative(c.MaxSeriesPerAgent))
+
+	if c.PrivateKeyFile != "" {
+		_, err := loadPrivateKey(c.PrivateKeyFile)
+		v.check("crypto_key", err)
+	}
+	v.check("tls_cert", readable(c.TLSCertFile))
+	v.check("tls_key", readable(c.TLSKeyFile))
+	v.check("tls_client_ca", readable(c.TLSClientCAFile))
+	v.check("tls_key", keyPair(c.TLSCertFile, c.TLSKeyFile))
+	if c.TLSClientCAFile != "" && c.TLSCertFile == "" {
+		v.check("tls_client_ca", ErrClientCAWithoutTLS)
+	}
+
+	return v.err()
+}
+
+// validate проверяет конфигурацию агента и возвращает все найденные ошибки (FieldError).
+func (c *AgentConfig) validate(sources map[string]Source) error {
+	v := newValidator(c, sources)
+
+	v.check("poll_interval", positive(c.PollInterval.Duration))
+	v.check("report_interval", positive(c.ReportInterval.Duration))
+	if c.PollInterval.Duration > 0 && c.ReportInterval.Duration > 0 && c.ReportInterval.Duration < c.PollInterval.Duration {
+		v.check("report_interval", ErrReportShorterThanPoll)
+	}
+	v.check("rate_limit", positive(c.RateLimit))
+	v.check("retry_delays", retryDelays(c.Delays))
+
+	if c.CertFile != "" {
+		_, err := utils.GetPublicKey(c.CertFile)
+		v.check("crypto_key", err)
+	}
+	v.check("tls_ca", readable(c.TLSCAFile))
+	v.check("tls_cert", readable(c.TLSCertFile))
+	v.check("tls_key", readable(c.TLSKeyFile))
+	v.check("tls_key", keyPair(c.TLSCertFile, c.TLSKeyFile))
+
+	return v.err()
+}
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fieldErrors(t *testing.T, err error) map[string]*FieldError {
+	t.Helper()
+	joined, ok := err.(interface{ Unwrap() []error })
+	require.True(t, ok, "expected joined errors, got: %v", err)
+
+	fieldErrs := make(map[string]*FieldError)
+	for _, err := range joined.Unwrap() {
+		var fieldErr *FieldError
+		require.True(t, errors.As(err, &fieldErr))
+		fieldErrs[fieldErr.Field] = fieldErr
+	}
+	return fieldErrs
+}
+
+func TestNewAgentConfig_validation(t *testing.T) {
+	filename, err := generateConfigFile([]byte(`{"poll_interval": "0s", "crypto_key": "/not/exists.pem"}`))
+	require.NoError(t, err)
+	defer os.Remove(filename)
+	t.Setenv("CONFIG", filename)
+	t.Setenv("RATE_LIMIT", "0")
+
+	_, err = NewAgentConfig("program", []string{"-r=-1s", "-tls-cert=/not/exists.crt"})
+	require.Error(t, err)
+
+	fieldErrs := fieldErrors(t, err)
+	require.Len(t, fieldErrs, 6)
+
+	assert.ErrorIs(t, fieldErrs["poll_interval"], ErrNotPositive)
+	assert.Equal(t, Source{Kind: SourceFile, Name: filename}, fieldErrs["poll_interval"].Source)
+
+	assert.ErrorIs(t, fieldErrs["report_interval"], ErrNotPositive)
+	assert.Equal(t, Source{Kind: SourceFlag, Name: "r"}, fieldErrs["report_interval"].Source)
+	assert.Equal(t, "-1s", fieldErrs["report_interval"].Value)
+
+	assert.ErrorIs(t, fieldErrs["rate_limit"], ErrNotPositive)
+	assert.Equal(t, Source{Kind: SourceEnv, Name: "RATE_LIMIT"}, fieldErrs["rate_limit"].Source)
+
+	assert.ErrorIs(t, fieldErrs["crypto_key"], os.ErrNotExist)
+	assert.Equal(t, Source{Kind: SourceFile, Name: filename}, fieldErrs["crypto_key"].Source)
+
+	assert.ErrorIs(t, fieldErrs["tls_cert"], os.ErrNotExist)
+	assert.Equal(t, Source{Kind: SourceFlag, Name: "tls-cert"}, fieldErrs["tls_cert"].Source)
+
+	// вместе с сертификатом не указан ключ
+	assert.ErrorIs(t, fieldErrs["tls_key"], ErrIncompleteKeyPair)
+	assert.Equal(t, Source{Kind: SourceDefault}, fieldErrs["tls_key"].Source)
+}
+
+func TestAgentConfig_validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(cfg *AgentConfig)
+		wantErr error
+	}{
+		{
+			name:   "default config",
+			modify: func(cfg *AgentConfig) {},
+		},
+		{
+			name: "report interval shorter than poll interval",
+			modify: func(cfg *AgentConfig) {
+				cfg.ReportInterval = Duration{cfg.PollInterval.Duration / 2}
+			},
+			wantErr: ErrReportShorterThanPoll,
+		},
+		{
+			name: "zero poll interval",
+			modify: func(cfg *AgentConfig) {
+				cfg.PollInterval = Duration{}
+			},
+			wantErr: ErrNotPositive,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultAgentConfig
+			tt.modify(&cfg)
+			err := cfg.validate(nil)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestServerConfig_validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(cfg *ServerConfig)
+		wantErr error
+	}{
+		{
+			name:   "default config",
+			modify: func(cfg *ServerConfig) {},
+		},
+		{
+			name: "incorrect log level",
+			modify: func(cfg *ServerConfig) {
+				cfg.LogLevel = "verbose"
+			},
+			wantErr: ErrIncorrectLogLevel,
+		},
+		{
+			name: "negative limit",
+			modify: func(cfg *ServerConfig) {
+				cfg.MaxBatchSize = -1
+			},
+			wantErr: ErrNegative,
+		},
+		{
+			name: "zero StatsD flush interval",
+			modify: func(cfg *ServerConfig) {
+				cfg.StatsdAddr = NetAddress{Host: "localhost", Port: 8125}
+				cfg.StatsdFlushInterval = Duration{}
+			},
+			wantErr: ErrNotPositive,
+		},
+		{
+			name: "shutdown drain not shorter than shutdown timeout",
+			modify: func(cfg *ServerConfig) {
+				cfg.ShutdownDrain = cfg.TimeoutShutdown
+			},
+			wantErr: ErrDrainNotShorter,
+		},
+		{
+			name: "client CA without server certificate",
+			modify: func(cfg *ServerConfig) {
+				cfg.TLSClientCAFile = os.DevNull
+			},
+			wantErr: ErrClientCAWithoutTLS,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultServerConfig
+			tt.modify(&cfg)
+			err := cfg.validate(nil)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,54 @@
+// Package dashboard содержит встроенную веб-панель сервера метрик.
+//
+// Панель не использует внешних ресурсов: страница, скрипт и стили встроены в бинарный файл (embed.FS).
+// Метрики загружаются из /api/v2/metrics; история значений для спарклайнов накапливается в браузере
+// при автообновлении и хранится в sessionStorage.
+package dashboard
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+//go:embed static
+var static embed.FS
+
+var files, _ = fs.Sub(static, "static")
+
+// AcceptsHTML проверяет, ожидает ли клиент HTML-страницу (заголовок Accept содержит text/html).
+func AcceptsHTML(req *http.Request) bool {
+	return strings.Contains(req.Header.Get("Accept"), "text/html")
+}
+
+// ServeIndex отдаёт страницу панели.
+func ServeIndex(res http.ResponseWriter, req *http.Request) {
+	serveFile(res, req, "index.html")
+}
+
+// Handler отдаёт файл панели, имя которого задано параметром пути {file}.
+func Handler(res http.ResponseWriter, req *http.Request) {
+	name := req.URL.Path[strings.LastIndex(req.URL.Path, "/")+1:]
+	serveFile(res, req, name)
+}
+
+func serveFile(res http.ResponseWriter, req *http.Request, name string) {
+	data, err := fs.ReadFile(files, name)
+	if err != nil {
+		http.NotFound(res, req)
+		return
+	}
+
+	switch {
+	case strings.HasSuffix(name, ".html"):
+		res.Header().Set("Content-Type", "text/html; charset=utf-8")
+	case strings.HasSuffix(name, ".js"):
+		res.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+	case strings.HasSuffix(name, ".css"):
+		res.Header().Set("Content-Type", "text/css; charset=utf-8")
+	}
+	res.Header().Set("Cache-Control", "no-cache")
+	res.WriteHeader(http.StatusOK)
+	res.Write(data)
+}
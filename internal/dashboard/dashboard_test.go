@@ -0,0 +1,65 @@
+package dashboard
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAcceptsHTML(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept string
+		want   bool
+	}{
+		{name: "browser", accept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", want: true},
+		{name: "any", accept: "*/*", want: false},
+		{name: "empty", accept: "", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept", tt.accept)
+			assert.Equal(t, tt.want, AcceptsHTML(req))
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		path            string
+		wantContentType string
+		wantStatus      int
+	}{
+		{path: "/dashboard/dashboard.js", wantStatus: http.StatusOK, wantContentType: "text/javascript; charset=utf-8"},
+		{path: "/dashboard/dashboard.css", wantStatus: http.StatusOK, wantContentType: "text/css; charset=utf-8"},
+		{path: "/dashboard/index.html", wantStatus: http.StatusOK, wantContentType: "text/html; charset=utf-8"},
+		{path: "/dashboard/unknown.js", wantStatus: http.StatusNotFound},
+		{path: "/dashboard/", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			Handler(res, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.wantStatus, res.Code)
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, tt.wantContentType, res.Header().Get("Content-Type"))
+				assert.NotEmpty(t, res.Body.String())
+			}
+		})
+	}
+}
+
+func TestServeIndex(t *testing.T) {
+	res := httptest.NewRecorder()
+	ServeIndex(res, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "text/html; charset=utf-8", res.Header().Get("Content-Type"))
+	assert.Contains(t, res.Body.String(), `<script src="/dashboard/dashboard.js"></script>`)
+	assert.NotContains(t, res.Body.String(), "http://")
+	assert.NotContains(t, res.Body.String(), "https://")
+}
@@ -0,0 +1,203 @@
+"use strict";
+
+// Количество хранимых значений каждой метрики для спарклайнов.
+const HISTORY_SIZE = 60;
+const HISTORY_KEY = "execenv.history";
+const TOKEN_KEY = "execenv.token";
+const PAGE_LIMIT = 1000;
+
+const elements = {
+    search: document.getElementById("search"),
+    type: document.getElementById("type"),
+    interval: document.getElementById("interval"),
+    token: document.getElementById("token"),
+    refresh: document.getElementById("refresh"),
+    status: document.getElementById("status"),
+    metrics: document.getElementById("metrics"),
+};
+
+let metrics = [];
+let history = loadHistory();
+let timer = null;
+
+function loadHistory() {
+    try {
+        return JSON.parse(sessionStorage.getItem(HISTORY_KEY)) || {};
+    } catch (e) {
+        return {};
+    }
+}
+
+function saveHistory() {
+    try {
+        sessionStorage.setItem(HISTORY_KEY, JSON.stringify(history));
+    } catch (e) {
+        // история остаётся только в памяти страницы
+    }
+}
+
+function metricKey(metric) {
+    return metric.type + ":" + metric.id;
+}
+
+function metricValue(metric) {
+    return metric.type === "counter" ? metric.delta : metric.value;
+}
+
+async function fetchMetrics() {
+    const result = [];
+    let cursor = "";
+    do {
+        const params = new URLSearchParams({limit: PAGE_LIMIT, sort: "name"});
+        if (cursor) {
+            params.set("cursor", cursor);
+        }
+        const headers = {Accept: "application/json"};
+        if (elements.token.value) {
+            headers.Authorization = "Bearer " + elements.token.value;
+        }
+        const response = await fetch("/api/v2/metrics?" + params, {headers: headers});
+        if (response.status === 401 || response.status === 403) {
+            elements.token.focus();
+        }
+        if (!response.ok) {
+            let detail = response.statusText;
+            try {
+                const problem = await response.json();
+                detail = problem.detail || problem.title || detail;
+            } catch (e) {
+                // тело ответа не в формате problem+json
+            }
+            throw new Error(response.status + ": " + detail);
+        }
+        const page = await response.json();
+        result.push(...page.metrics);
+        cursor = page.next_cursor || "";
+    } while (cursor);
+    return result;
+}
+
+function recordHistory(items) {
+    const now = Date.now();
+    const present = new Set();
+    for (const metric of items) {
+        const key = metricKey(metric);
+        present.add(key);
+        const points = history[key] || [];
+        points.push([now, metricValue(metric)]);
+        history[key] = points.slice(-HISTORY_SIZE);
+    }
+    for (const key of Object.keys(history)) {
+        if (!present.has(key)) {
+            delete history[key];
+        }
+    }
+    saveHistory();
+}
+
+function sparkline(points) {
+    const svgNS = "http://www.w3.org/2000/svg";
+    const svg = document.createElementNS(svgNS, "svg");
+    svg.setAttribute("class", "sparkline");
+    svg.setAttribute("viewBox", "0 0 120 24");
+    svg.setAttribute("preserveAspectRatio", "none");
+    if (!points || points.length < 2) {
+        return svg;
+    }
+
+    const values = points.map((p) => p[1]);
+    const min = Math.min(...values);
+    const max = Math.max(...values);
+    const span = max - min || 1;
+    const step = 120 / (HISTORY_SIZE - 1);
+    const offset = 120 - step * (points.length - 1);
+    const coords = values.map((v, i) => {
+        const x = offset + i * step;
+        const y = max === min ? 12 : 22 - ((v - min) / span) * 20;
+        return x.toFixed(1) + "," + y.toFixed(1);
+    });
+
+    const line = document.createElementNS(svgNS, "polyline");
+    line.setAttribute("points", coords.join(" "));
+    svg.appendChild(line);
+
+    const title = document.createElementNS(svgNS, "title");
+    title.textContent = "min " + min + ", max " + max;
+    svg.appendChild(title);
+    return svg;
+}
+
+function cell(text, className) {
+    const td = document.createElement("td");
+    td.textContent = text;
+    if (className) {
+        td.className = className;
+    }
+    return td;
+}
+
+function render() {
+    const query = elements.search.value.trim().toLowerCase();
+    const type = elements.type.value;
+    const visible = metrics.filter((m) =>
+        (!type || m.type === type) && (!query || m.id.toLowerCase().includes(query)));
+
+    const rows = visible.map((metric) => {
+        const tr = document.createElement("tr");
+        tr.appendChild(cell(metric.id, "name"));
+        tr.appendChild(cell(metric.type));
+        tr.appendChild(cell(String(metricValue(metric)), "value"));
+        const chart = document.createElement("td");
+        chart.appendChild(sparkline(history[metricKey(metric)]));
+        tr.appendChild(chart);
+        return tr;
+    });
+    elements.metrics.replaceChildren(...rows);
+
+    setStatus("Показано " + visible.length + " из " + metrics.length + ", обновлено в " + new Date().toLocaleTimeString());
+}
+
+function setStatus(text, isError) {
+    elements.status.textContent = text;
+    elements.status.classList.toggle("error", Boolean(isError));
+}
+
+async function refresh() {
+    try {
+        metrics = await fetchMetrics();
+        recordHistory(metrics);
+        render();
+    } catch (e) {
+        setStatus("Не удалось загрузить метрики: " + e.message, true);
+    }
+}
+
+function schedule() {
+    clearInterval(timer);
+    timer = null;
+    const interval = Number(elements.interval.value);
+    if (interval > 0 && !document.hidden) {
+        timer = setInterval(refresh, interval);
+    }
+}
+
+// токен хранится только до закрытия вкладки
+elements.token.value = sessionStorage.getItem(TOKEN_KEY) || "";
+elements.token.addEventListener("change", () => {
+    sessionStorage.setItem(TOKEN_KEY, elements.token.value);
+    refresh();
+});
+
+elements.search.addEventListener("input", render);
+elements.type.addEventListener("change", render);
+elements.interval.addEventListener("change", schedule);
+elements.refresh.addEventListener("click", refresh);
+document.addEventListener("visibilitychange", () => {
+    if (!document.hidden) {
+        refresh();
+    }
+    schedule();
+});
+
+refresh();
+schedule();
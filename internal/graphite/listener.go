@@ -0,0 +1,263 @@
+// Package graphite реализует приём метрик по протоколу Graphite plaintext (TCP) в формате <path> <value> <timestamp>.
+//
+// Значения сохраняются как gauge. Имя метрики получается из пути с помощью шаблонов Templates.
+package graphite
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"math"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/SpaceSlow/execenv/internal/logger"
+	"github.com/SpaceSlow/execenv/internal/metrics"
+	"github.com/SpaceSlow/execenv/internal/storages"
+)
+
+var ErrIncorrectLine = errors.New("incorrect graphite line")
+
+// maxBatchSize максимальное количество метрик, записываемых одним Batch.
+const maxBatchSize = 1000
+
+// Ограничения соединения: соединение закрывается при строке длиннее maxLineSize
+// или если данные не поступают в течение readTimeout.
+const (
+	maxLineSize = 64 * 1024
+	readTimeout = time.Minute
+)
+
+// writeTimeout максимальное время записи пачки метрик в хранилище.
+const writeTimeout = 10 * time.Second
+
+type point struct {
+	timestamp time.Time
+	metric    metrics.Metric
+}
+
+// parseLine разбирает строку <path> <value> [<timestamp>]. Временная метка -1 или её отсутствие соответствуют now.
+func (t Templates) parseLine(line string, now time.Time) (point, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || len(fields) > 3 {
+		return point{}, ErrIncorrectLine
+	}
+
+	name, err := t.MetricName(fields[0])
+	if err != nil {
+		return point{}, err
+	}
+
+	value, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil || math.IsNaN(value) {
+		return point{}, ErrIncorrectLine
+	}
+
+	p := point{timestamp: now, metric: metrics.NewGauge(name, value)}
+	if len(fields) == 3 && fields[2] != "-1" {
+		ts, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil {
+			return point{}, ErrIncorrectLine
+		}
+		p.timestamp = time.Unix(0, int64(ts*float64(time.Second)))
+	}
+	return p, nil
+}
+
+// Listener принимает метрики Graphite по TCP.
+type Listener struct {
+	storage   storages.MetricStorage
+	listener  net.Listener
+	conns     map[net.Conn]struct{}
+	done      chan struct{}
+	stopped   chan struct{}
+	address   string
+	templates Templates
+	mu        sync.Mutex
+	closeOnce sync.Once
+}
+
+// NewListener создаёт Listener на адресе address.
+func NewListener(address string, templates Templates, storage storages.MetricStorage) *Listener {
+	return &Listener{
+		storage:   storage,
+		conns:     make(map[net.Conn]struct{}),
+		done:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+		address:   address,
+		templates: templates,
+	}
+}
+
+// Run начинает приём соединений и блокируется до вызова Shutdown.
+func (l *Listener) Run() error {
+	defer close(l.stopped)
+
+	if err := l.listen(); err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for {
+		conn, err := l.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				logger.Log.Error("failed to accept graphite connection", zap.Error(err))
+			}
+			wg.Wait()
+			return nil
+		}
+
+		l.mu.Lock()
+		select {
+		case <-l.done:
+			l.mu.Unlock()
+			conn.Close()
+			continue
+		default:
+		}
+		l.conns[conn] = struct{}{}
+		l.mu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.serveConn(conn)
+		}()
+	}
+}
+
+// Shutdown прекращает приём соединений, закрывает открытые соединения и дожидается записи полученных метрик.
+func (l *Listener) Shutdown(ctx context.Context) error {
+	l.closeOnce.Do(func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		close(l.done)
+		if l.listener != nil {
+			l.listener.Close()
+		}
+		for conn := range l.conns {
+			conn.Close()
+		}
+	})
+
+	select {
+	case <-l.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Addr возвращает адрес, на котором принимаются соединения, или nil, если Listener не запущен.
+func (l *Listener) Addr() net.Addr {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.listener == nil {
+		return nil
+	}
+	return l.listener.Addr()
+}
+
+func (l *Listener) listen() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	listener, err := net.Listen("tcp", l.address)
+	if err != nil {
+		return err
+	}
+	l.listener = listener
+
+	select {
+	case <-l.done:
+		// Shutdown вызван до запуска
+		listener.Close()
+	default:
+		logger.Log.Info("started graphite listener", zap.String("address", listener.Addr().String()))
+	}
+	return nil
+}
+
+// serveConn читает строки соединения и записывает их пачками:
+// пачка сохраняется, когда прочитаны все полученные на данный момент данные или набрано maxBatchSize метрик.
+func (l *Listener) serveConn(conn net.Conn) {
+	defer func() {
+		l.mu.Lock()
+		delete(l.conns, conn)
+		l.mu.Unlock()
+		conn.Close()
+	}()
+
+	points := make([]point, 0)
+	scanner := bufio.NewScanner(connReader{
+		conn: conn,
+		beforeRead: func() {
+			points = l.flush(points)
+		},
+	})
+	scanner.Buffer(make([]byte, 0, 4096), maxLineSize)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			p, err := l.templates.parseLine(line, time.Now())
+			if err != nil {
+				logger.Log.Debug("skipped graphite line", zap.String("line", line), zap.Error(err))
+			} else {
+				points = append(points, p)
+			}
+		}
+		if len(points) >= maxBatchSize {
+			points = l.flush(points)
+		}
+	}
+	l.flush(points)
+	if err := scanner.Err(); err != nil && !errors.Is(err, net.ErrClosed) {
+		logger.Log.Debug("closed graphite connection", zap.String("remote", conn.RemoteAddr().String()), zap.Error(err))
+	}
+}
+
+// connReader читает данные соединения, ограничивая время ожидания readTimeout.
+// beforeRead вызывается перед каждым чтением из соединения, когда все полученные строки уже обработаны.
+type connReader struct {
+	conn       net.Conn
+	beforeRead func()
+}
+
+func (r connReader) Read(p []byte) (int, error) {
+	r.beforeRead()
+	if err := r.conn.SetReadDeadline(time.Now().Add(readTimeout)); err != nil {
+		return 0, err
+	}
+	return r.conn.Read(p)
+}
+
+// flush записывает точки в хранилище и возвращает пустой срез для следующей пачки.
+func (l *Listener) flush(points []point) []point {
+	if len(points) == 0 {
+		return points
+	}
+
+	// при нескольких значениях одной метрики сохраняется значение с наибольшей временной меткой
+	slices.SortStableFunc(points, func(a, b point) int {
+		return a.timestamp.Compare(b.timestamp)
+	})
+	metricSlice := make([]metrics.Metric, 0, len(points))
+	for _, p := range points {
+		metricSlice = append(metricSlice, p.metric)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+	if err := l.storage.Batch(ctx, metricSlice); err != nil {
+		logger.Log.Error("failed to write graphite metrics", zap.Error(err), zap.Int("count", len(metricSlice)))
+	}
+	return points[:0]
+}
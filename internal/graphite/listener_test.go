@@ -0,0 +1,120 @@
+package graphite
+
+import (
+	"context"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SpaceSlow/execenv/internal/metrics"
+	"github.com/SpaceSlow/execenv/internal/storages"
+)
+
+func TestTemplates_parseLine(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name      string
+		line      string
+		wantPoint point
+		wantErr   error
+	}{
+		{
+			name:      "with timestamp",
+			line:      "cron.backup.duration 12.5 1700000100",
+			wantPoint: point{timestamp: time.Unix(1700000100, 0), metric: metrics.NewGauge("cron.backup.duration", 12.5)},
+		},
+		{
+			name:      "without timestamp",
+			line:      "cron.backup.duration 12",
+			wantPoint: point{timestamp: now, metric: metrics.NewGauge("cron.backup.duration", 12)},
+		},
+		{
+			name:      "negative timestamp",
+			line:      "cron.backup.duration 12 -1",
+			wantPoint: point{timestamp: now, metric: metrics.NewGauge("cron.backup.duration", 12)},
+		},
+		{name: "without value", line: "cron.backup.duration", wantErr: ErrIncorrectLine},
+		{name: "incorrect value", line: "cron.backup.duration abc 1700000100", wantErr: ErrIncorrectLine},
+		{name: "nan value", line: "cron.backup.duration nan 1700000100", wantErr: ErrIncorrectLine},
+		{name: "incorrect timestamp", line: "cron.backup.duration 1 now", wantErr: ErrIncorrectLine},
+		{name: "extra fields", line: "cron.backup.duration 1 1700000100 1", wantErr: ErrIncorrectLine},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Templates(nil).parseLine(tt.line, now)
+			require.ErrorIs(t, err, tt.wantErr)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.wantPoint.metric, p.metric)
+			assert.True(t, tt.wantPoint.timestamp.Equal(p.timestamp))
+		})
+	}
+}
+
+func TestListener(t *testing.T) {
+	storage := storages.NewMemStorage()
+	l := NewListener("127.0.0.1:0", nil, storage)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- l.Run()
+	}()
+	require.Eventually(t, func() bool { return l.Addr() != nil }, time.Second, 10*time.Millisecond)
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("cron.backup.duration 20 1700000200\ncron.backup.duration 10 1700000100\nincorrect\ncron.cleanup.duration 3.5\n"))
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		_, err := storage.Get(context.Background(), metrics.Gauge, "cron.cleanup.duration")
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, l.Shutdown(context.Background()))
+	require.NoError(t, <-errCh)
+
+	list, err := storage.List(context.Background())
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []metrics.Metric{
+		metrics.NewGauge("cron.backup.duration", 20),
+		metrics.NewGauge("cron.cleanup.duration", 3.5),
+	}, list)
+}
+
+func TestListener_longLine(t *testing.T) {
+	storage := storages.NewMemStorage()
+	l := NewListener("127.0.0.1:0", nil, storage)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- l.Run()
+	}()
+	require.Eventually(t, func() bool { return l.Addr() != nil }, time.Second, 10*time.Millisecond)
+	defer func() {
+		require.NoError(t, l.Shutdown(context.Background()))
+		require.NoError(t, <-errCh)
+	}()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("cron.cleanup.duration 3.5\n" + strings.Repeat("a", maxLineSize+1)))
+	require.NoError(t, err)
+
+	// строка длиннее maxLineSize закрывает соединение, полученные до неё метрики сохраняются
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	_, err = conn.Read(make([]byte, 1))
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, os.ErrDeadlineExceeded)
+	_, err = storage.Get(context.Background(), metrics.Gauge, "cron.cleanup.duration")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,195 @@
+package graphite
+
+import (
+	"errors"
+	"flag"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/SpaceSlow/execenv/internal/metrics"
+	"github.com/SpaceSlow/execenv/internal/selfmetrics"
+)
+
+var (
+	_ flag.Value = (*Templates)(nil)
+
+	ErrIncorrectTemplate = errors.New("need template in a form [<filter>=]<template>, template parts: measurement, measurement*, field, field*, <tag name> or empty")
+)
+
+// Части шаблона со специальным значением, остальные непустые части задают имя тега.
+const (
+	measurementPart     = "measurement"
+	measurementRestPart = "measurement*"
+	fieldPart           = "field"
+	fieldRestPart       = "field*"
+)
+
+// Template шаблон преобразования пути Graphite в имя метрики и теги.
+//
+// Например, шаблон ".host.measurement.field*" для пути "servers.web01.cpu.load.1"
+// даёт имя "cpu.load.1{host=web01}".
+// Filter — разделённые точками шаблоны path.Match для начальных частей пути, пустой фильтр подходит к любому пути.
+type Template struct {
+	Filter []string
+	Parts  []string
+}
+
+func (t *Template) UnmarshalText(text []byte) error {
+	filter, template, ok := strings.Cut(string(text), "=")
+	if !ok {
+		filter, template = "", filter
+	}
+	if template == "" {
+		return ErrIncorrectTemplate
+	}
+
+	t.Filter = nil
+	if filter != "" {
+		t.Filter = strings.Split(filter, ".")
+		for _, part := range t.Filter {
+			if _, err := path.Match(part, ""); err != nil {
+				return ErrIncorrectTemplate
+			}
+		}
+	}
+
+	t.Parts = strings.Split(template, ".")
+	hasMeasurement := false
+	for i, part := range t.Parts {
+		switch part {
+		case measurementPart:
+			hasMeasurement = true
+		case measurementRestPart, fieldRestPart:
+			hasMeasurement = hasMeasurement || part == measurementRestPart
+			if i != len(t.Parts)-1 {
+				return ErrIncorrectTemplate
+			}
+		}
+	}
+	if !hasMeasurement {
+		return ErrIncorrectTemplate
+	}
+
+	return nil
+}
+
+func (t Template) String() string {
+	template := strings.Join(t.Parts, ".")
+	if len(t.Filter) == 0 {
+		return template
+	}
+	return strings.Join(t.Filter, ".") + "=" + template
+}
+
+func (t Template) match(parts []string) bool {
+	if len(t.Filter) > len(parts) {
+		return false
+	}
+	for i, filter := range t.Filter {
+		if ok, _ := path.Match(filter, parts[i]); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (t Template) apply(parts []string) (name string, tags []string) {
+	var measurement, field []string
+	for i, part := range t.Parts {
+		if i >= len(parts) {
+			break
+		}
+		switch part {
+		case "":
+		case measurementPart:
+			measurement = append(measurement, parts[i])
+		case fieldPart:
+			field = append(field, parts[i])
+		case measurementRestPart:
+			measurement = append(measurement, parts[i:]...)
+		case fieldRestPart:
+			field = append(field, parts[i:]...)
+		default:
+			tags = append(tags, part+"="+parts[i])
+		}
+	}
+
+	name = strings.Join(append(measurement, field...), ".")
+	return name, tags
+}
+
+// Templates набор шаблонов, применяется первый подходящий по фильтру.
+type Templates []Template
+
+func (t *Templates) String() string {
+	if t == nil {
+		return ""
+	}
+	templates := make([]string, 0, len(*t))
+	for _, template := range *t {
+		templates = append(templates, template.String())
+	}
+	return strings.Join(templates, ",")
+}
+
+// Set добавляет шаблон, позволяя указывать флаг несколько раз.
+func (t *Templates) Set(s string) error {
+	var template Template
+	if err := template.UnmarshalText([]byte(s)); err != nil {
+		return err
+	}
+	*t = append(*t, template)
+	return nil
+}
+
+// MetricName возвращает имя метрики для пути Graphite.
+// Путь может содержать теги в формате Graphite: <path>;<tag>=<value>;...
+// Если ни один шаблон не подошёл, именем метрики становится сам путь.
+// Теги добавляются к имени в виде {key=value,...} в порядке возрастания ключей.
+// Возвращает metrics.ErrMetricNameTooLong, если имя метрики длиннее metrics.MaxNameLength,
+// и metrics.ErrReservedMetricName, если имя начинается с префикса внутренних метрик сервера.
+func (t Templates) MetricName(graphitePath string) (string, error) {
+	graphitePath, tagged, _ := strings.Cut(graphitePath, ";")
+	if graphitePath == "" {
+		return "", ErrIncorrectLine
+	}
+
+	name, tags := graphitePath, []string(nil)
+	parts := strings.Split(graphitePath, ".")
+	for _, template := range t {
+		if template.match(parts) {
+			name, tags = template.apply(parts)
+			if name == "" {
+				name = graphitePath
+			}
+			break
+		}
+	}
+
+	if tagged != "" {
+		for _, tag := range strings.Split(tagged, ";") {
+			key, value, ok := strings.Cut(tag, "=")
+			if !ok || key == "" || value == "" {
+				return "", ErrIncorrectLine
+			}
+			tags = append(tags, tag)
+		}
+	}
+
+	if len(tags) > 0 {
+		slices.SortFunc(tags, func(a, b string) int {
+			keyA, _, _ := strings.Cut(a, "=")
+			keyB, _, _ := strings.Cut(b, "=")
+			return strings.Compare(keyA, keyB)
+		})
+		name += "{" + strings.Join(tags, ",") + "}"
+	}
+	if len(name) > metrics.MaxNameLength {
+		return "", metrics.ErrMetricNameTooLong
+	}
+	if selfmetrics.IsReserved(name) {
+		return "", metrics.ErrReservedMetricName
+	}
+	return name, nil
+}
@@ -0,0 +1,61 @@
+package graphite
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SpaceSlow/execenv/internal/metrics"
+)
+
+func TestTemplates_Set(t *testing.T) {
+	var templates Templates
+	require.NoError(t, templates.Set("servers.*=.host.measurement.field*"))
+	require.NoError(t, templates.Set("measurement*"))
+	assert.Equal(t, Templates{
+		{Filter: []string{"servers", "*"}, Parts: []string{"", "host", "measurement", "field*"}},
+		{Parts: []string{"measurement*"}},
+	}, templates)
+	assert.Equal(t, "servers.*=.host.measurement.field*,measurement*", templates.String())
+
+	for _, incorrect := range []string{"", "servers.*=", "host.field", "measurement*.host", "[.*=measurement"} {
+		assert.ErrorIs(t, templates.Set(incorrect), ErrIncorrectTemplate, incorrect)
+	}
+
+	var fromJSON Templates
+	require.NoError(t, json.Unmarshal([]byte(`["servers.*=.host.measurement.field*", "measurement*"]`), &fromJSON))
+	assert.Equal(t, templates, fromJSON)
+}
+
+func TestTemplates_MetricName(t *testing.T) {
+	var templates Templates
+	require.NoError(t, templates.Set("servers.*=.host.measurement.field*"))
+	require.NoError(t, templates.Set("jobs.*.*=.job.region.measurement"))
+
+	tests := []struct {
+		path     string
+		wantName string
+		wantErr  error
+	}{
+		{path: "servers.web01.cpu.load.1", wantName: "cpu.load.1{host=web01}"},
+		{path: "jobs.backup.eu.duration", wantName: "duration{job=backup,region=eu}"},
+		{path: "jobs.backup", wantName: "jobs.backup"},
+		{path: "cron.cleanup.duration", wantName: "cron.cleanup.duration"},
+		{path: "cron.cleanup.duration;env=prod;dc=b", wantName: "cron.cleanup.duration{dc=b,env=prod}"},
+		{path: "servers.web01.cpu;dc=b", wantName: "cpu{dc=b,host=web01}"},
+		{path: "cron.cleanup;env", wantErr: ErrIncorrectLine},
+		{path: ";env=prod", wantErr: ErrIncorrectLine},
+		{path: "cron.cleanup;env=" + strings.Repeat("a", metrics.MaxNameLength), wantErr: metrics.ErrMetricNameTooLong},
+		{path: "_srv.http.req.2xx", wantErr: metrics.ErrReservedMetricName},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			name, err := templates.MetricName(tt.path)
+			require.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.wantName, name)
+		})
+	}
+}
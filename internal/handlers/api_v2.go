@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/SpaceSlow/execenv/internal/metrics"
+	"github.com/SpaceSlow/execenv/internal/problems"
+	"github.com/SpaceSlow/execenv/internal/storages"
+)
+
+const (
+	defaultPageLimit = 100
+	maxPageLimit     = 1000
+)
+
+var (
+	ErrIncorrectCursor = errors.New("incorrect cursor")
+	ErrIncorrectLimit  = errors.New("limit must be a number in the range 1-1000")
+	ErrIncorrectSort   = errors.New("sort must be one of: name, -name, type, -type")
+)
+
+var listOrders = map[string]storages.ListOrder{
+	"":      storages.OrderByName,
+	"name":  storages.OrderByName,
+	"-name": storages.OrderByNameDesc,
+	"type":  storages.OrderByType,
+	"-type": storages.OrderByTypeDesc,
+}
+
+// MetricsPage страница списка метрик API v2.
+type MetricsPage struct {
+	// NextCursor курсор следующей страницы, пустой для последней страницы
+	NextCursor string           `json:"next_cursor,omitempty"`
+	Metrics    []metrics.Metric `json:"metrics"`
+}
+
+// APIv2Handler хэндлер для ресурса /api/v2/metrics.
+type APIv2Handler struct {
+	MetricStorage storages.MetricStorage
+}
+
+// List возвращает страницу списка метрик.
+//
+// Параметры запроса: type (counter, gauge), prefix (префикс имени), regex (регулярное выражение для имени),
+// sort (name, -name, type, -type), limit (1-1000, по умолчанию 100), cursor (значение next_cursor предыдущей страницы).
+// Поддерживает условные запросы по ETag (If-None-Match).
+func (h APIv2Handler) List(res http.ResponseWriter, req *http.Request) {
+	filter, err := parseListFilter(req)
+	if err != nil {
+		problems.Write(res, req, http.StatusBadRequest, problems.CodeBadRequest, err.Error())
+		return
+	}
+	limit := filter.Limit
+	// на одну метрику больше, чтобы узнать о наличии следующей страницы
+	filter.Limit++
+
+	metricSlice, err := h.MetricStorage.ListFiltered(req.Context(), filter)
+	if err != nil {
+		writeError(res, req, err)
+		return
+	}
+
+	page := MetricsPage{Metrics: metricSlice}
+	if len(metricSlice) > limit {
+		page.Metrics = metricSlice[:limit]
+		last := page.Metrics[limit-1]
+		page.NextCursor = encodeCursor(storages.MetricKey{Name: last.Name, Type: last.Type})
+	}
+
+	body, err := json.Marshal(page)
+	if err != nil {
+		problems.Internal(res, req)
+		return
+	}
+
+	hash := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(hash[:16]) + `"`
+	res.Header().Set("ETag", etag)
+	res.Header().Set("Cache-Control", "no-cache")
+	if matchETag(req.Header.Get("If-None-Match"), etag) {
+		res.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusOK)
+	res.Write(body)
+}
+
+func parseListFilter(req *http.Request) (storages.ListFilter, error) {
+	query := req.URL.Query()
+	filter := storages.ListFilter{
+		NamePrefix: query.Get("prefix"),
+		Limit:      defaultPageLimit,
+	}
+
+	if mType := query.Get("type"); mType != "" {
+		t, err := metrics.ParseMetricType(mType)
+		if err != nil {
+			return filter, err
+		}
+		filter.Type = t
+	}
+
+	if expr := query.Get("regex"); expr != "" {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return filter, err
+		}
+		filter.NameRegexp = re
+	}
+
+	order, ok := listOrders[query.Get("sort")]
+	if !ok {
+		return filter, ErrIncorrectSort
+	}
+	filter.Order = order
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxPageLimit {
+			return filter, ErrIncorrectLimit
+		}
+		filter.Limit = n
+	}
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		key, err := decodeCursor(cursor)
+		if err != nil {
+			return filter, err
+		}
+		filter.After = &key
+	}
+
+	return filter, nil
+}
+
+// encodeCursor кодирует ключ метрики в непрозрачный для клиента курсор.
+func encodeCursor(key storages.MetricKey) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(key.Type.String() + "/" + key.Name))
+}
+
+func decodeCursor(cursor string) (storages.MetricKey, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return storages.MetricKey{}, ErrIncorrectCursor
+	}
+	mType, name, ok := strings.Cut(string(data), "/")
+	if !ok {
+		return storages.MetricKey{}, ErrIncorrectCursor
+	}
+	t, err := metrics.ParseMetricType(mType)
+	if err != nil {
+		return storages.MetricKey{}, ErrIncorrectCursor
+	}
+	return storages.MetricKey{Name: name, Type: t}, nil
+}
+
+func matchETag(ifNoneMatch, etag string) bool {
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == etag || tag == "*" {
+			return true
+		}
+	}
+	return false
+}
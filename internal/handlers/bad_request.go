@@ -1,8 +1,24 @@
 package handlers
 
-import "net/http"
+import (
+	"errors"
+	"net/http"
+
+	"github.com/SpaceSlow/execenv/internal/metrics"
+	"github.com/SpaceSlow/execenv/internal/problems"
+)
 
 // BadRequestHandlerFunc вовзращает 400 Bad Request.
-func BadRequestHandlerFunc(res http.ResponseWriter, _ *http.Request) {
-	res.WriteHeader(http.StatusBadRequest)
+func BadRequestHandlerFunc(res http.ResponseWriter, req *http.Request) {
+	problems.Write(res, req, http.StatusBadRequest, problems.CodeBadRequest, "")
+}
+
+// writeDecodeError отправляет проблему для ошибки разбора тела запроса: ошибки метрик получают код своей причины,
+// прочие ошибки — bad_request.
+func writeDecodeError(res http.ResponseWriter, req *http.Request, err error) {
+	if errors.Is(err, metrics.ErrIncorrectMetricTypeOrValue) {
+		writeError(res, req, err)
+		return
+	}
+	problems.Write(res, req, http.StatusBadRequest, problems.CodeBadRequest, err.Error())
 }
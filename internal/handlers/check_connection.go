@@ -3,6 +3,7 @@ package handlers
 import (
 	"net/http"
 
+	"github.com/SpaceSlow/execenv/internal/problems"
 	"github.com/SpaceSlow/execenv/internal/storages"
 )
 
@@ -14,14 +15,14 @@ func NewCheckConnectionHandler(storage storages.MetricStorage) *CheckConnectionH
 	if s, ok := storage.(storages.ICheckConnection); ok {
 		return &CheckConnectionHandler{storage: s}
 	}
-	return &CheckConnectionHandler{storage: nil}
+	return &CheckConnectionHandler{}
 }
 
-// Ping проверяет соединение с БД.
-func (h CheckConnectionHandler) Ping(res http.ResponseWriter, _ *http.Request) {
-	if h.storage.CheckConnection() {
+// Ping проверяет соединение с БД. Хранилища без проверки соединения (в памяти) всегда доступны.
+func (h CheckConnectionHandler) Ping(res http.ResponseWriter, req *http.Request) {
+	if h.storage == nil || h.storage.CheckConnection(req.Context()) {
 		res.WriteHeader(http.StatusOK)
 		return
 	}
-	res.WriteHeader(http.StatusInternalServerError)
+	problems.Write(res, req, http.StatusInternalServerError, problems.CodeStorageUnavailable, storages.ErrNoConnection.Error())
 }
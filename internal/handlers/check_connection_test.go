@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -16,7 +17,7 @@ type MockCheckStorage struct {
 	returnCheck bool
 }
 
-func (s *MockCheckStorage) CheckConnection() bool {
+func (s *MockCheckStorage) CheckConnection(_ context.Context) bool {
 	return s.returnCheck
 }
 
@@ -36,6 +37,11 @@ func TestDBHandler_Ping(t *testing.T) {
 			storage:        &MockCheckStorage{returnCheck: false},
 			expectedStatus: http.StatusInternalServerError,
 		},
+		{
+			name:           "storage without connection check",
+			storage:        nil,
+			expectedStatus: http.StatusOK,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
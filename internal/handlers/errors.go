@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/SpaceSlow/execenv/internal/problems"
+	"github.com/SpaceSlow/execenv/internal/ratelimit"
+)
+
+// errorProblem возвращает проблему, соответствующую ошибке хранилища или метрики (problems.FromError).
+// Превышение ограничений агента: ratelimit.ErrBatchTooLarge — 413, ratelimit.ErrSeriesQuotaExceeded — 429.
+func errorProblem(err error) problems.Problem {
+	switch {
+	case errors.Is(err, ratelimit.ErrBatchTooLarge):
+		return problems.New(http.StatusRequestEntityTooLarge, problems.CodeRequestTooLarge, err.Error())
+	case errors.Is(err, ratelimit.ErrSeriesQuotaExceeded):
+		return problems.New(http.StatusTooManyRequests, problems.CodeQuotaExceeded, err.Error())
+	default:
+		return problems.FromError(err)
+	}
+}
+
+// writeError отправляет проблему, соответствующую ошибке err (errorProblem).
+func writeError(res http.ResponseWriter, req *http.Request, err error) {
+	errorProblem(err).Write(res, req)
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SpaceSlow/execenv/internal/metrics"
+	"github.com/SpaceSlow/execenv/internal/problems"
+	"github.com/SpaceSlow/execenv/internal/ratelimit"
+	"github.com/SpaceSlow/execenv/internal/storages"
+)
+
+func Test_errorProblem(t *testing.T) {
+	tests := []struct {
+		err        error
+		name       string
+		wantCode   problems.Code
+		wantStatus int
+	}{
+		{name: "batch too large", err: ratelimit.ErrBatchTooLarge, wantStatus: http.StatusRequestEntityTooLarge, wantCode: problems.CodeRequestTooLarge},
+		{name: "rejected batch", err: fmt.Errorf("%w: %w", storages.ErrBatchRejected, ratelimit.ErrBatchTooLarge), wantStatus: http.StatusRequestEntityTooLarge, wantCode: problems.CodeRequestTooLarge},
+		{name: "series quota exceeded", err: ratelimit.ErrSeriesQuotaExceeded, wantStatus: http.StatusTooManyRequests, wantCode: problems.CodeQuotaExceeded},
+		{name: "metric error", err: metrics.ErrUnknownMetricType, wantStatus: http.StatusBadRequest, wantCode: problems.CodeUnknownMetricType},
+		{name: "storage error", err: errors.New("connection refused"), wantStatus: http.StatusServiceUnavailable, wantCode: problems.CodeStorageUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problem := errorProblem(tt.err)
+			assert.Equal(t, tt.wantStatus, problem.Status)
+			assert.Equal(t, tt.wantCode, problem.Code)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/SpaceSlow/execenv/internal/health"
+)
+
+// HealthHandler хэндлер для ресурсов /healthz и /readyz.
+type HealthHandler struct {
+	Registry *health.Registry
+}
+
+// Healthz возвращает состояние компонентов сервера: 200 — все компоненты исправны, 503 — иначе.
+func (h HealthHandler) Healthz(res http.ResponseWriter, req *http.Request) {
+	writeHealthReport(res, h.Registry.Health(req.Context()))
+}
+
+// Readyz возвращает готовность сервера принимать запросы: 503, если неисправен какой-либо компонент
+// или сервер завершает работу.
+func (h HealthHandler) Readyz(res http.ResponseWriter, req *http.Request) {
+	writeHealthReport(res, h.Registry.Ready(req.Context()))
+}
+
+func writeHealthReport(res http.ResponseWriter, report health.Report) {
+	body, err := json.Marshal(report)
+	if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	status := http.StatusOK
+	if report.Status != health.StatusUp {
+		status = http.StatusServiceUnavailable
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Header().Set("Cache-Control", "no-store")
+	res.WriteHeader(status)
+	res.Write(body)
+}
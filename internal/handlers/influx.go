@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/SpaceSlow/execenv/internal/influx"
+	"github.com/SpaceSlow/execenv/internal/problems"
+)
+
+// InfluxHandler хэндлер для приёма метрик в формате InfluxDB line protocol (совместим с /api/v2/write).
+type InfluxHandler struct {
+	Receiver *influx.Receiver
+}
+
+func (h InfluxHandler) Write(res http.ResponseWriter, req *http.Request) {
+	precision, err := influx.ParsePrecision(req.URL.Query().Get("precision"))
+	if err != nil {
+		problems.Write(res, req, http.StatusBadRequest, problems.CodeBadRequest, err.Error())
+		return
+	}
+
+	data, err := io.ReadAll(req.Body)
+	if err != nil {
+		problems.Write(res, req, http.StatusBadRequest, problems.CodeBadRequest, err.Error())
+		return
+	}
+	if err = req.Body.Close(); err != nil {
+		problems.Internal(res, req)
+		return
+	}
+
+	points, err := influx.Parse(string(data), precision, time.Now())
+	if err != nil {
+		problems.Write(res, req, http.StatusBadRequest, problems.CodeBadRequest, err.Error())
+		return
+	}
+	// при нескольких значениях одной gauge в запросе сохраняется значение с наибольшей временной меткой
+	slices.SortStableFunc(points, func(a, b influx.Point) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+
+	if err = h.Receiver.Write(req.Context(), points); err != nil {
+		writeError(res, req, err)
+		return
+	}
+
+	res.WriteHeader(http.StatusNoContent)
+}
@@ -3,8 +3,10 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"slices"
 
 	"github.com/SpaceSlow/execenv/internal/metrics"
+	"github.com/SpaceSlow/execenv/internal/problems"
 	"github.com/SpaceSlow/execenv/internal/storages"
 )
 
@@ -16,23 +18,23 @@ type JSONMetricHandler struct {
 func (h JSONMetricHandler) Post(res http.ResponseWriter, req *http.Request) {
 	metric := &metrics.Metric{}
 	if err := json.NewDecoder(req.Body).Decode(metric); err != nil {
-		http.Error(res, err.Error(), http.StatusBadRequest)
+		writeDecodeError(res, req, err)
 		return
 	}
 	if err := req.Body.Close(); err != nil {
-		http.Error(res, err.Error(), http.StatusInternalServerError)
+		problems.Internal(res, req)
 		return
 	}
 
 	var err error
-	if metric, err = h.MetricStorage.Add(metric); err != nil {
-		http.Error(res, err.Error(), http.StatusBadRequest)
+	if metric, err = h.MetricStorage.Add(req.Context(), metric); err != nil {
+		writeError(res, req, err)
 		return
 	}
 
 	metricJSON, err := metric.MarshalJSON()
 	if err != nil {
-		res.WriteHeader(http.StatusInternalServerError)
+		problems.Internal(res, req)
 		return
 	}
 
@@ -41,25 +43,98 @@ func (h JSONMetricHandler) Post(res http.ResponseWriter, req *http.Request) {
 	res.Write(metricJSON)
 }
 
+// BatchResult ответ на пакетное добавление метрик в режиме partial.
+type BatchResult struct {
+	Errors   []BatchError `json:"errors,omitempty"`
+	Accepted int          `json:"accepted"`
+}
+
+// BatchError ошибка метрики с индексом Index в пакете.
+type BatchError struct {
+	Error string        `json:"error"`
+	Code  problems.Code `json:"code"`
+	Index int           `json:"index"`
+}
+
+func newBatchError(index int, err error) BatchError {
+	return BatchError{Index: index, Error: err.Error(), Code: errorProblem(err).Code}
+}
+
+// BatchPost добавляет пакет метрик. По умолчанию пакет применяется атомарно и при первой некорректной метрике отклоняется целиком.
+// С параметром partial=true каждая метрика проверяется и добавляется независимо, а в ответе возвращается BatchResult.
 func (h JSONMetricHandler) BatchPost(res http.ResponseWriter, req *http.Request) {
+	if req.URL.Query().Get("partial") == "true" {
+		h.partialBatchPost(res, req)
+		return
+	}
+
 	metricSlice := make([]metrics.Metric, 0)
 	if err := json.NewDecoder(req.Body).Decode(&metricSlice); err != nil {
-		http.Error(res, err.Error(), http.StatusBadRequest)
+		writeDecodeError(res, req, err)
 		return
 	}
 	if err := req.Body.Close(); err != nil {
-		http.Error(res, err.Error(), http.StatusInternalServerError)
+		problems.Internal(res, req)
 		return
 	}
 
 	var err error
-	if err = h.MetricStorage.Batch(metricSlice); err != nil {
-		http.Error(res, err.Error(), http.StatusBadRequest)
+	if err = h.MetricStorage.Batch(req.Context(), metricSlice); err != nil {
+		writeError(res, req, err)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusOK)
+}
+
+func (h JSONMetricHandler) partialBatchPost(res http.ResponseWriter, req *http.Request) {
+	items := make([]json.RawMessage, 0)
+	if err := json.NewDecoder(req.Body).Decode(&items); err != nil {
+		writeDecodeError(res, req, err)
+		return
+	}
+	if err := req.Body.Close(); err != nil {
+		problems.Internal(res, req)
 		return
 	}
 
+	var (
+		result      BatchResult
+		metricSlice = make([]metrics.Metric, 0, len(items))
+		indexes     = make([]int, 0, len(items))
+	)
+	for i, item := range items {
+		var metric metrics.Metric
+		if err := metric.UnmarshalJSON(item); err != nil {
+			result.Errors = append(result.Errors, newBatchError(i, err))
+			continue
+		}
+		metricSlice = append(metricSlice, metric)
+		indexes = append(indexes, i)
+	}
+
+	accepted, itemErrors, err := storages.BatchEach(req.Context(), h.MetricStorage, metricSlice)
+	if err != nil {
+		writeError(res, req, err)
+		return
+	}
+	result.Accepted = accepted
+	for _, itemErr := range itemErrors {
+		result.Errors = append(result.Errors, newBatchError(indexes[itemErr.Index], itemErr.Err))
+	}
+	slices.SortFunc(result.Errors, func(a, b BatchError) int {
+		return a.Index - b.Index
+	})
+
+	body, err := json.Marshal(result)
+	if err != nil {
+		problems.Internal(res, req)
+		return
+	}
 	res.Header().Set("Content-Type", "application/json")
 	res.WriteHeader(http.StatusOK)
+	res.Write(body)
 }
 
 func (h JSONMetricHandler) Get(res http.ResponseWriter, req *http.Request) {
@@ -67,28 +142,28 @@ func (h JSONMetricHandler) Get(res http.ResponseWriter, req *http.Request) {
 	res.Header().Set("Content-Type", "application/json")
 
 	if err := json.NewDecoder(req.Body).Decode(&jsonMetric); err != nil {
-		http.Error(res, err.Error(), http.StatusBadRequest)
+		writeDecodeError(res, req, err)
 		return
 	}
 
 	if err := req.Body.Close(); err != nil {
-		http.Error(res, err.Error(), http.StatusInternalServerError)
+		problems.Internal(res, req)
 		return
 	}
 
 	mType, err := metrics.ParseMetricType(jsonMetric.MType)
 	if err != nil {
-		res.WriteHeader(http.StatusBadRequest)
+		writeError(res, req, err)
 		return
 	}
-	metric, ok := h.MetricStorage.Get(mType, jsonMetric.ID)
-	if !ok {
-		res.WriteHeader(http.StatusNotFound)
+	metric, err := h.MetricStorage.Get(req.Context(), mType, jsonMetric.ID)
+	if err != nil {
+		writeError(res, req, err)
 		return
 	}
 	metricJSON, err := metric.MarshalJSON()
 	if err != nil {
-		res.WriteHeader(http.StatusInternalServerError)
+		problems.Internal(res, req)
 		return
 	}
 
@@ -8,6 +8,7 @@ import (
 	"github.com/go-chi/chi/v5"
 
 	"github.com/SpaceSlow/execenv/internal/metrics"
+	"github.com/SpaceSlow/execenv/internal/problems"
 	"github.com/SpaceSlow/execenv/internal/storages"
 )
 
@@ -18,27 +19,24 @@ type MetricHandler struct {
 
 func (h MetricHandler) Post(res http.ResponseWriter, req *http.Request) {
 	mType, err := metrics.ParseMetricType(chi.URLParam(req, "type"))
-	if errors.Is(err, metrics.ErrIncorrectMetricTypeOrValue) {
-		res.WriteHeader(http.StatusBadRequest)
+	if err != nil {
+		writeError(res, req, err)
 		return
 	}
 
 	name := chi.URLParam(req, "name")
 	value := chi.URLParam(req, "value")
 	metric, err := metrics.NewMetric(mType, name, value)
-	if errors.Is(err, metrics.ErrIncorrectMetricTypeOrValue) {
-		res.WriteHeader(http.StatusBadRequest)
-		return
-	} else if errors.Is(err, metrics.ErrEmptyMetricName) {
-		res.WriteHeader(http.StatusNotFound)
+	if errors.Is(err, metrics.ErrEmptyMetricName) {
+		problems.Write(res, req, http.StatusNotFound, problems.CodeEmptyMetricName, err.Error())
 		return
 	} else if err != nil {
-		res.WriteHeader(http.StatusInternalServerError)
+		writeError(res, req, err)
 		return
 	}
 
-	if _, err := h.MetricStorage.Add(metric); err != nil {
-		http.Error(res, err.Error(), http.StatusBadRequest)
+	if _, err := h.MetricStorage.Add(req.Context(), metric); err != nil {
+		writeError(res, req, err)
 		return
 	}
 
@@ -47,13 +45,13 @@ func (h MetricHandler) Post(res http.ResponseWriter, req *http.Request) {
 
 func (h MetricHandler) Get(res http.ResponseWriter, req *http.Request) {
 	mType, err := metrics.ParseMetricType(chi.URLParam(req, "type"))
-	if errors.Is(err, metrics.ErrIncorrectMetricTypeOrValue) {
-		res.WriteHeader(http.StatusBadRequest)
+	if err != nil {
+		writeError(res, req, err)
 		return
 	}
-	metric, ok := h.MetricStorage.Get(mType, chi.URLParam(req, "name"))
-	if !ok {
-		res.WriteHeader(http.StatusNotFound)
+	metric, err := h.MetricStorage.Get(req.Context(), mType, chi.URLParam(req, "name"))
+	if err != nil {
+		writeError(res, req, err)
 		return
 	}
 
@@ -61,15 +59,21 @@ func (h MetricHandler) Get(res http.ResponseWriter, req *http.Request) {
 	res.Write([]byte(metric.ValueAsString()))
 }
 
-func (h MetricHandler) List(res http.ResponseWriter, _ *http.Request) {
-	result := strings.Builder{}
+// List отдаёт список метрик в текстовом виде.
+func (h MetricHandler) List(res http.ResponseWriter, req *http.Request) {
+	metricSlice, err := h.MetricStorage.List(req.Context())
+	if err != nil {
+		writeError(res, req, err)
+		return
+	}
 
-	for _, metric := range h.MetricStorage.List() {
+	result := strings.Builder{}
+	for _, metric := range metricSlice {
 		result.WriteString(metric.String())
 		result.WriteString("\n")
 	}
 
-	res.Header().Set("Content-Type", "text/html")
+	res.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	res.WriteHeader(http.StatusOK)
 	res.Write([]byte(result.String()))
 }
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	collectorpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/SpaceSlow/execenv/internal/otlp"
+	"github.com/SpaceSlow/execenv/internal/problems"
+	"github.com/SpaceSlow/execenv/internal/ratelimit"
+)
+
+const (
+	protobufContentType = "application/x-protobuf"
+	jsonContentType     = "application/json"
+)
+
+// OTLPHandler хэндлер для приёма метрик по протоколу OTLP/HTTP (protobuf и JSON).
+type OTLPHandler struct {
+	Receiver *otlp.Receiver
+}
+
+func (h OTLPHandler) Post(res http.ResponseWriter, req *http.Request) {
+	contentType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+
+	var (
+		unmarshal func(b []byte, m proto.Message) error
+		marshal   func(m proto.Message) ([]byte, error)
+	)
+	switch contentType {
+	case protobufContentType:
+		unmarshal, marshal = proto.Unmarshal, proto.Marshal
+	case jsonContentType:
+		unmarshal, marshal = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal, protojson.Marshal
+	default:
+		problems.Write(res, req, http.StatusUnsupportedMediaType, problems.CodeUnsupportedMediaType,
+			"content type must be "+protobufContentType+" or "+jsonContentType)
+		return
+	}
+
+	data, err := io.ReadAll(req.Body)
+	if err != nil {
+		problems.Write(res, req, http.StatusBadRequest, problems.CodeBadRequest, err.Error())
+		return
+	}
+	if err = req.Body.Close(); err != nil {
+		problems.Internal(res, req)
+		return
+	}
+
+	var exportReq collectorpb.ExportMetricsServiceRequest
+	if err = unmarshal(data, &exportReq); err != nil {
+		problems.Write(res, req, http.StatusBadRequest, problems.CodeBadRequest, err.Error())
+		return
+	}
+
+	rejected, err := h.Receiver.Export(req.Context(), &exportReq)
+	if errors.Is(err, ratelimit.ErrBatchTooLarge) || errors.Is(err, ratelimit.ErrSeriesQuotaExceeded) {
+		writeError(res, req, err)
+		return
+	}
+	if err != nil {
+		// 503 означает для экспортёров OTLP возможность повторной отправки
+		problems.Write(res, req, http.StatusServiceUnavailable, problems.CodeStorageUnavailable, err.Error())
+		return
+	}
+
+	response, err := marshal(otlp.ExportResponse(rejected))
+	if err != nil {
+		problems.Internal(res, req)
+		return
+	}
+
+	res.Header().Set("Content-Type", contentType)
+	res.WriteHeader(http.StatusOK)
+	res.Write(response)
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/SpaceSlow/execenv/internal/problems"
+	"github.com/SpaceSlow/execenv/internal/remotewrite"
+)
+
+// RemoteWriteHandler хэндлер для приёма метрик по протоколу Prometheus remote write.
+//
+// Prometheus повторяет отправку при ответах 5xx и 429, поэтому ошибки, которые не исчезнут при повторе
+// (некорректный запрос или некорректные метрики), возвращаются с кодом 400.
+type RemoteWriteHandler struct {
+	Receiver *remotewrite.Receiver
+	// MaxRequestSize максимальный размер распакованного тела запроса в байтах, 0 — без ограничения
+	MaxRequestSize int64
+}
+
+func (h RemoteWriteHandler) Post(res http.ResponseWriter, req *http.Request) {
+	data, err := io.ReadAll(req.Body)
+	if err != nil {
+		problems.Write(res, req, http.StatusBadRequest, problems.CodeBadRequest, err.Error())
+		return
+	}
+	if err = req.Body.Close(); err != nil {
+		problems.Internal(res, req)
+		return
+	}
+
+	writeReq, err := remotewrite.DecodeWriteRequest(data, h.MaxRequestSize)
+	switch {
+	case errors.Is(err, remotewrite.ErrRequestTooLarge):
+		problems.Write(res, req, http.StatusRequestEntityTooLarge, problems.CodeRequestTooLarge, err.Error())
+		return
+	case err != nil:
+		problems.Write(res, req, http.StatusBadRequest, problems.CodeBadRequest, err.Error())
+		return
+	}
+
+	err = h.Receiver.Write(req.Context(), writeReq)
+	switch {
+	case err == nil:
+		res.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, remotewrite.ErrIncorrectRequest):
+		problems.Write(res, req, http.StatusBadRequest, problems.CodeBadRequest, err.Error())
+	default:
+		writeError(res, req, err)
+	}
+}
@@ -0,0 +1,151 @@
+// Package health реализует реестр состояния компонентов сервера для проверок /healthz и /readyz.
+//
+// Компоненты либо регистрируют функцию проверки (Checker), вызываемую при каждом запросе состояния,
+// либо сами сообщают о своём состоянии через State.
+package health
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// ErrStarting компонент ещё не запущен.
+	ErrStarting = errors.New("starting")
+	// ErrStopped компонент остановлен.
+	ErrStopped = errors.New("stopped")
+	// ErrDraining сервер завершает работу и не принимает новые запросы.
+	ErrDraining = errors.New("shutting down")
+)
+
+// Status состояние компонента или сервера в целом.
+type Status string
+
+const (
+	StatusUp   Status = "up"
+	StatusDown Status = "down"
+)
+
+// Checker реализуется компонентами, поддерживающими проверку состояния.
+// CheckHealth возвращает nil, если компонент исправен.
+type Checker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+// CheckerFunc позволяет использовать функцию в качестве Checker.
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) CheckHealth(ctx context.Context) error {
+	return f(ctx)
+}
+
+// State хранит последнее состояние, сообщённое компонентом.
+type State struct {
+	err error
+	mu  sync.RWMutex
+}
+
+// NewState возвращает State с начальным состоянием err.
+func NewState(err error) *State {
+	return &State{err: err}
+}
+
+// Set устанавливает состояние компонента: nil — компонент исправен.
+func (s *State) Set(err error) {
+	s.mu.Lock()
+	s.err = err
+	s.mu.Unlock()
+}
+
+func (s *State) CheckHealth(_ context.Context) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.err
+}
+
+// ComponentReport состояние отдельного компонента.
+type ComponentReport struct {
+	Status Status `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Report состояние сервера с разбивкой по компонентам.
+type Report struct {
+	Components map[string]ComponentReport `json:"components"`
+	Status     Status                     `json:"status"`
+	Error      string                     `json:"error,omitempty"`
+}
+
+// Registry реестр проверяемых компонентов сервера.
+type Registry struct {
+	checkers map[string]Checker
+	names    []string
+	mu       sync.RWMutex
+	draining atomic.Bool
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		checkers: make(map[string]Checker),
+	}
+}
+
+// Register добавляет компонент name, повторная регистрация заменяет проверку.
+func (r *Registry) Register(name string, checker Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.checkers[name]; !ok {
+		r.names = append(r.names, name)
+		slices.Sort(r.names)
+	}
+	r.checkers[name] = checker
+}
+
+// StartDraining переводит сервер в состояние завершения работы: Ready начинает сообщать о неготовности.
+func (r *Registry) StartDraining() {
+	r.draining.Store(true)
+}
+
+// Draining сообщает, завершает ли сервер работу.
+func (r *Registry) Draining() bool {
+	return r.draining.Load()
+}
+
+// Health проверяет все компоненты. Сервер исправен, если исправны все компоненты.
+func (r *Registry) Health(ctx context.Context) Report {
+	r.mu.RLock()
+	names := slices.Clone(r.names)
+	checkers := make([]Checker, len(names))
+	for i, name := range names {
+		checkers[i] = r.checkers[name]
+	}
+	r.mu.RUnlock()
+
+	report := Report{
+		Components: make(map[string]ComponentReport, len(names)),
+		Status:     StatusUp,
+	}
+	for i, name := range names {
+		component := ComponentReport{Status: StatusUp}
+		if err := checkers[i].CheckHealth(ctx); err != nil {
+			component = ComponentReport{Status: StatusDown, Error: err.Error()}
+			report.Status = StatusDown
+		}
+		report.Components[name] = component
+	}
+	return report
+}
+
+// Ready проверяет готовность сервера принимать запросы: все компоненты исправны и сервер не завершает работу.
+func (r *Registry) Ready(ctx context.Context) Report {
+	report := r.Health(ctx)
+	if r.Draining() {
+		report.Status = StatusDown
+		report.Error = ErrDraining.Error()
+	}
+	return report
+}
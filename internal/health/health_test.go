@@ -0,0 +1,99 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	errNoConnection := errors.New("no connection")
+
+	tests := []struct {
+		checkers  map[string]Checker
+		want      Report
+		name      string
+		draining  bool
+		wantReady Status
+	}{
+		{
+			name:      "no components",
+			checkers:  map[string]Checker{},
+			want:      Report{Components: map[string]ComponentReport{}, Status: StatusUp},
+			wantReady: StatusUp,
+		},
+		{
+			name: "all components up",
+			checkers: map[string]Checker{
+				"storage": CheckerFunc(func(context.Context) error { return nil }),
+				"statsd":  NewState(nil),
+			},
+			want: Report{
+				Components: map[string]ComponentReport{
+					"storage": {Status: StatusUp},
+					"statsd":  {Status: StatusUp},
+				},
+				Status: StatusUp,
+			},
+			wantReady: StatusUp,
+		},
+		{
+			name: "one component down",
+			checkers: map[string]Checker{
+				"storage": CheckerFunc(func(context.Context) error { return errNoConnection }),
+				"statsd":  NewState(nil),
+			},
+			want: Report{
+				Components: map[string]ComponentReport{
+					"storage": {Status: StatusDown, Error: "no connection"},
+					"statsd":  {Status: StatusUp},
+				},
+				Status: StatusDown,
+			},
+			wantReady: StatusDown,
+		},
+		{
+			name: "draining",
+			checkers: map[string]Checker{
+				"storage": NewState(nil),
+			},
+			draining: true,
+			want: Report{
+				Components: map[string]ComponentReport{
+					"storage": {Status: StatusUp},
+				},
+				Status: StatusUp,
+			},
+			wantReady: StatusDown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry()
+			for name, checker := range tt.checkers {
+				r.Register(name, checker)
+			}
+			if tt.draining {
+				r.StartDraining()
+			}
+
+			assert.Equal(t, tt.want, r.Health(context.Background()))
+			ready := r.Ready(context.Background())
+			assert.Equal(t, tt.wantReady, ready.Status)
+			assert.Equal(t, tt.want.Components, ready.Components)
+		})
+	}
+}
+
+func TestState(t *testing.T) {
+	state := NewState(ErrStarting)
+	assert.ErrorIs(t, state.CheckHealth(context.Background()), ErrStarting)
+
+	state.Set(nil)
+	assert.NoError(t, state.CheckHealth(context.Background()))
+
+	state.Set(ErrStopped)
+	assert.ErrorIs(t, state.CheckHealth(context.Background()), ErrStopped)
+}
@@ -0,0 +1,221 @@
+// Package influx реализует разбор InfluxDB line protocol и преобразование точек в метрики.
+//
+// Формат строки: <measurement>[,<tag>=<value>...] <field>=<value>[,<field>=<value>...] [<timestamp>].
+package influx
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrIncorrectLine      = errors.New("incorrect line protocol")
+	ErrIncorrectPrecision = errors.New("incorrect precision")
+)
+
+type FieldType int
+
+const (
+	FloatField FieldType = iota
+	IntegerField
+	UnsignedField
+	BooleanField
+	StringField
+)
+
+// Field значение поля точки. Для числовых и логических полей заполняется Number (true — 1, false — 0), для строковых — String.
+type Field struct {
+	Key    string
+	String string
+	Number float64
+	Type   FieldType
+}
+
+// Tag тег точки.
+type Tag struct {
+	Key   string
+	Value string
+}
+
+// Point точка InfluxDB. Теги отсортированы по ключу.
+type Point struct {
+	Timestamp   time.Time
+	Measurement string
+	Tags        []Tag
+	Fields      []Field
+}
+
+// ParsePrecision возвращает единицу измерения временных меток по значению параметра precision.
+// Пустое значение соответствует наносекундам.
+func ParsePrecision(precision string) (time.Duration, error) {
+	switch precision {
+	case "", "ns", "n":
+		return time.Nanosecond, nil
+	case "us", "u":
+		return time.Microsecond, nil
+	case "ms":
+		return time.Millisecond, nil
+	case "s":
+		return time.Second, nil
+	default:
+		return 0, ErrIncorrectPrecision
+	}
+}
+
+// Parse разбирает строки line protocol. Точки без временной метки получают время now.
+func Parse(data string, precision time.Duration, now time.Time) ([]Point, error) {
+	points := make([]Point, 0)
+	for i, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		point, err := parseLine(line, precision, now)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+func parseLine(line string, precision time.Duration, now time.Time) (Point, error) {
+	var point Point
+
+	series, rest, ok := cut(line, ' ', false)
+	if !ok {
+		return point, ErrIncorrectLine
+	}
+	fields, timestamp, _ := cut(strings.TrimLeft(rest, " "), ' ', true)
+
+	seriesParts := split(series, ',', false)
+	point.Measurement = unescape(seriesParts[0])
+	if point.Measurement == "" {
+		return point, ErrIncorrectLine
+	}
+	for _, part := range seriesParts[1:] {
+		key, value, ok := cut(part, '=', false)
+		if !ok || key == "" || value == "" {
+			return point, ErrIncorrectLine
+		}
+		point.Tags = append(point.Tags, Tag{Key: unescape(key), Value: unescape(value)})
+	}
+	slices.SortFunc(point.Tags, func(a, b Tag) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+
+	for _, part := range split(fields, ',', true) {
+		key, value, ok := cut(part, '=', false)
+		if !ok || key == "" {
+			return point, ErrIncorrectLine
+		}
+		field, err := parseField(value)
+		if err != nil {
+			return point, err
+		}
+		field.Key = unescape(key)
+		point.Fields = append(point.Fields, field)
+	}
+
+	timestamp = strings.TrimSpace(timestamp)
+	if timestamp == "" {
+		point.Timestamp = now
+		return point, nil
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return point, ErrIncorrectLine
+	}
+	point.Timestamp = time.Unix(0, ts*int64(precision))
+
+	return point, nil
+}
+
+func parseField(value string) (Field, error) {
+	if value == "" {
+		return Field{}, ErrIncorrectLine
+	}
+
+	switch {
+	case value[0] == '"':
+		if len(value) < 2 || value[len(value)-1] != '"' {
+			return Field{}, ErrIncorrectLine
+		}
+		s := strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(value[1 : len(value)-1])
+		return Field{Type: StringField, String: s}, nil
+	case strings.HasSuffix(value, "i"):
+		n, err := strconv.ParseInt(value[:len(value)-1], 10, 64)
+		if err != nil {
+			return Field{}, ErrIncorrectLine
+		}
+		return Field{Type: IntegerField, Number: float64(n)}, nil
+	case strings.HasSuffix(value, "u"):
+		n, err := strconv.ParseUint(value[:len(value)-1], 10, 64)
+		if err != nil {
+			return Field{}, ErrIncorrectLine
+		}
+		return Field{Type: UnsignedField, Number: float64(n)}, nil
+	}
+
+	switch value {
+	case "t", "T", "true", "True", "TRUE":
+		return Field{Type: BooleanField, Number: 1}, nil
+	case "f", "F", "false", "False", "FALSE":
+		return Field{Type: BooleanField, Number: 0}, nil
+	}
+
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return Field{}, ErrIncorrectLine
+	}
+	return Field{Type: FloatField, Number: n}, nil
+}
+
+// cut разделяет s по первому неэкранированному символу sep (вне кавычек, если quoted).
+func cut(s string, sep byte, quoted bool) (before, after string, found bool) {
+	if i := index(s, sep, quoted); i >= 0 {
+		return s[:i], s[i+1:], true
+	}
+	return s, "", false
+}
+
+// split разделяет s по всем неэкранированным символам sep (вне кавычек, если quoted).
+func split(s string, sep byte, quoted bool) []string {
+	parts := make([]string, 0, 1)
+	for {
+		before, after, found := cut(s, sep, quoted)
+		parts = append(parts, before)
+		if !found {
+			return parts
+		}
+		s = after
+	}
+}
+
+func index(s string, sep byte, quoted bool) int {
+	inQuotes := false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			i++
+		case quoted && s[i] == '"':
+			inQuotes = !inQuotes
+		case s[i] == sep && !inQuotes:
+			return i
+		}
+	}
+	return -1
+}
+
+var escapeReplacer = strings.NewReplacer(`\,`, ",", `\=`, "=", `\ `, " ", `\\`, `\`)
+
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	return escapeReplacer.Replace(s)
+}
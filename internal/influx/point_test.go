@@ -0,0 +1,86 @@
+package influx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name       string
+		data       string
+		precision  time.Duration
+		wantPoints []Point
+		wantErr    error
+	}{
+		{
+			name:      "all field types",
+			data:      `weather,location=us-midwest,season=summer temperature=82,count=3i,total=5u,raining=true,note="it's \"hot\"" 1465839830100400200`,
+			precision: time.Nanosecond,
+			wantPoints: []Point{{
+				Measurement: "weather",
+				Tags:        []Tag{{Key: "location", Value: "us-midwest"}, {Key: "season", Value: "summer"}},
+				Fields: []Field{
+					{Key: "temperature", Type: FloatField, Number: 82},
+					{Key: "count", Type: IntegerField, Number: 3},
+					{Key: "total", Type: UnsignedField, Number: 5},
+					{Key: "raining", Type: BooleanField, Number: 1},
+					{Key: "note", Type: StringField, String: `it's "hot"`},
+				},
+				Timestamp: time.Unix(0, 1465839830100400200),
+			}},
+		},
+		{
+			name:      "escaped characters, unsorted tags, comments and precision",
+			data:      "# comment\n\nmy\\ measurement,z=1,a\\,b=x\\ y field\\=key=1 1700000001\n",
+			precision: time.Second,
+			wantPoints: []Point{{
+				Measurement: "my measurement",
+				Tags:        []Tag{{Key: "a,b", Value: "x y"}, {Key: "z", Value: "1"}},
+				Fields:      []Field{{Key: "field=key", Type: FloatField, Number: 1}},
+				Timestamp:   time.Unix(1700000001, 0),
+			}},
+		},
+		{
+			name:      "string field with separators and without timestamp",
+			data:      `log message="a, b=c d"`,
+			precision: time.Nanosecond,
+			wantPoints: []Point{{
+				Measurement: "log",
+				Fields:      []Field{{Key: "message", Type: StringField, String: "a, b=c d"}},
+				Timestamp:   now,
+			}},
+		},
+		{name: "without fields", data: "cpu", wantErr: ErrIncorrectLine},
+		{name: "empty measurement", data: ",host=a value=1", wantErr: ErrIncorrectLine},
+		{name: "incorrect tag", data: "cpu,host value=1", wantErr: ErrIncorrectLine},
+		{name: "incorrect field value", data: "cpu value=abc", wantErr: ErrIncorrectLine},
+		{name: "incorrect integer value", data: "cpu value=1.5i", wantErr: ErrIncorrectLine},
+		{name: "incorrect timestamp", data: "cpu value=1 now", wantErr: ErrIncorrectLine},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points, err := Parse(tt.data, tt.precision, now)
+			require.ErrorIs(t, err, tt.wantErr)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.wantPoints, points)
+		})
+	}
+}
+
+func TestParsePrecision(t *testing.T) {
+	for precision, want := range map[string]time.Duration{"": time.Nanosecond, "ns": time.Nanosecond, "us": time.Microsecond, "ms": time.Millisecond, "s": time.Second} {
+		got, err := ParsePrecision(precision)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+	_, err := ParsePrecision("h")
+	assert.ErrorIs(t, err, ErrIncorrectPrecision)
+}
@@ -0,0 +1,146 @@
+package influx
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/SpaceSlow/execenv/internal/metrics"
+	"github.com/SpaceSlow/execenv/internal/storages"
+)
+
+// seriesIdleTimeout время без точек, после которого остаток ряда counter удаляется.
+const seriesIdleTimeout = 10 * time.Minute
+
+// counterSeries состояние ряда counter.
+type counterSeries struct {
+	updated time.Time
+	// remainder дробная часть значений, не записанная в хранилище
+	remainder float64
+}
+
+// seriesUpdate изменение состояния ряда запросом: prev — состояние до запроса (nil для нового ряда), next — после.
+type seriesUpdate struct {
+	prev *counterSeries
+	next *counterSeries
+}
+
+// Receiver преобразует точки в метрики по правилам Rules и сохраняет их в хранилище.
+//
+// Дробные значения не теряются при округлении counter: неучтённая дробная часть значения добавляется
+// к следующему значению того же ряда. Состояние ряда без точек в течение seriesIdleTimeout удаляется.
+type Receiver struct {
+	storage storages.MetricStorage
+	rules   Rules
+	now     func() time.Time
+	// series состояние рядов counter, ключ — имя метрики
+	series    map[string]*counterSeries
+	lastSweep time.Time
+	mu        sync.Mutex
+}
+
+func NewReceiver(storage storages.MetricStorage, rules Rules) *Receiver {
+	return &Receiver{
+		storage: storage,
+		rules:   rules,
+		now:     time.Now,
+		series:  make(map[string]*counterSeries),
+	}
+}
+
+// Write сохраняет метрики точек одним Batch. Точки должны быть упорядочены по времени: для gauge
+// сохраняется последнее значение. При ошибке записи восстанавливается состояние рядов,
+// не изменённых с тех пор другими запросами.
+// Возвращает metrics.ErrMetricNameTooLong, если имя метрики длиннее metrics.MaxNameLength.
+func (r *Receiver) Write(ctx context.Context, points []Point) error {
+	values, err := r.rules.values(points)
+	if err != nil {
+		return err
+	}
+
+	metricSlice, updates := r.convert(values)
+	if len(metricSlice) == 0 {
+		return nil
+	}
+	if err = r.storage.Batch(ctx, metricSlice); err != nil {
+		r.restore(updates)
+		return err
+	}
+	return nil
+}
+
+// convert преобразует значения полей в метрики и обновляет состояние рядов counter.
+func (r *Receiver) convert(values []fieldValue) ([]metrics.Metric, map[string]seriesUpdate) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	r.sweep(now)
+
+	metricSlice := make([]metrics.Metric, 0, len(values))
+	updates := make(map[string]seriesUpdate)
+	for _, v := range values {
+		if !v.counter {
+			metricSlice = append(metricSlice, metrics.NewGauge(v.name, v.value))
+			continue
+		}
+		series := r.update(updates, v.name, now)
+		metricSlice = append(metricSlice, metrics.NewCounter(v.name, series.delta(v.value)))
+	}
+	return metricSlice, updates
+}
+
+// update возвращает состояние ряда key, изменяемое запросом. При первом обращении в запросе
+// состояние копируется, чтобы его можно было восстановить (restore). Требует блокировки r.mu.
+func (r *Receiver) update(updates map[string]seriesUpdate, key string, now time.Time) *counterSeries {
+	if u, ok := updates[key]; ok {
+		return u.next
+	}
+	prev := r.series[key]
+	next := &counterSeries{}
+	if prev != nil {
+		*next = *prev
+	}
+	next.updated = now
+	r.series[key] = next
+	updates[key] = seriesUpdate{prev: prev, next: next}
+	return next
+}
+
+// restore восстанавливает состояние рядов, изменённых запросом, если другие запросы их с тех пор не изменяли.
+func (r *Receiver) restore(updates map[string]seriesUpdate) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, u := range updates {
+		switch {
+		case r.series[key] != u.next:
+		case u.prev == nil:
+			delete(r.series, key)
+		default:
+			r.series[key] = u.prev
+		}
+	}
+}
+
+// sweep удаляет состояние рядов без точек в течение seriesIdleTimeout. Требует блокировки r.mu.
+func (r *Receiver) sweep(now time.Time) {
+	if now.Sub(r.lastSweep) <= seriesIdleTimeout {
+		return
+	}
+	r.lastSweep = now
+	for key, series := range r.series {
+		if now.Sub(series.updated) > seriesIdleTimeout {
+			delete(r.series, key)
+		}
+	}
+}
+
+// delta возвращает округлённое значение с учётом остатка предыдущих значений ряда и запоминает новый остаток.
+func (s *counterSeries) delta(value float64) int64 {
+	value += s.remainder
+	rounded := math.Round(value)
+	s.remainder = value - rounded
+	return int64(rounded)
+}
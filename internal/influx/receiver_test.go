@@ -0,0 +1,118 @@
+package influx
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SpaceSlow/execenv/internal/metrics"
+	"github.com/SpaceSlow/execenv/internal/storages"
+)
+
+func TestReceiver_Write(t *testing.T) {
+	ctx := context.Background()
+	storage := storages.NewMemStorage()
+	receiver := NewReceiver(storage, Rules{
+		{Pattern: "net.bytes_*", Action: CounterAction},
+		{Pattern: "net.*", Action: SkipAction},
+	})
+	points := []Point{
+		{
+			Measurement: "net",
+			Tags:        []Tag{{Key: "host", Value: "a"}, {Key: "iface", Value: "eth0"}},
+			Fields: []Field{
+				{Key: "bytes_recv", Type: IntegerField, Number: 100},
+				{Key: "bytes_sent", Type: FloatField, Number: 10.6},
+				{Key: "drop_in", Type: IntegerField, Number: 1},
+			},
+			Timestamp: time.Now(),
+		},
+		{
+			Measurement: "system",
+			Fields: []Field{
+				{Key: "load1", Type: FloatField, Number: 0.5},
+				{Key: "online", Type: BooleanField, Number: 1},
+				{Key: "uptime_format", Type: StringField, String: "1 day"},
+			},
+			Timestamp: time.Now(),
+		},
+	}
+
+	require.NoError(t, receiver.Write(ctx, points))
+	list, err := storage.List(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []metrics.Metric{
+		metrics.NewCounter("net.bytes_recv{host=a,iface=eth0}", 100),
+		metrics.NewCounter("net.bytes_sent{host=a,iface=eth0}", 11),
+		metrics.NewGauge("system.load1", 0.5),
+		metrics.NewGauge("system.online", 1),
+	}, list)
+
+	points = []Point{{
+		Measurement: "net",
+		Tags:        []Tag{{Key: "host", Value: strings.Repeat("a", metrics.MaxNameLength)}},
+		Fields:      []Field{{Key: "bytes_recv", Type: IntegerField, Number: 1}},
+	}}
+	assert.ErrorIs(t, receiver.Write(ctx, points), metrics.ErrMetricNameTooLong)
+}
+
+func TestReceiver_WriteFractional(t *testing.T) {
+	ctx := context.Background()
+	storage := storages.NewMemStorage()
+	receiver := NewReceiver(storage, Rules{{Pattern: "cpu.time", Action: CounterAction}})
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, receiver.Write(ctx, []Point{{Measurement: "cpu", Fields: []Field{{Key: "time", Type: FloatField, Number: 0.4}}}}))
+	}
+
+	// 5 значений по 0.4 дают 2
+	metric, err := storage.Get(ctx, metrics.Counter, "cpu.time")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), metric.Delta)
+}
+
+// failingStorage отклоняет запись метрик.
+type failingStorage struct {
+	storages.MetricStorage
+}
+
+func (failingStorage) Batch(context.Context, []metrics.Metric) error {
+	return errors.New("storage is unavailable")
+}
+
+func TestReceiver_WriteStorageError(t *testing.T) {
+	ctx := context.Background()
+	storage := storages.NewMemStorage()
+	receiver := NewReceiver(storage, Rules{{Pattern: "cpu.time", Action: CounterAction}})
+	point := Point{Measurement: "cpu", Fields: []Field{{Key: "time", Type: FloatField, Number: 0.4}}}
+
+	require.NoError(t, receiver.Write(ctx, []Point{point}))
+	receiver.storage = failingStorage{MetricStorage: storage}
+	require.Error(t, receiver.Write(ctx, []Point{point}))
+
+	// остаток неудачной записи не учитывается
+	receiver.storage = storage
+	require.NoError(t, receiver.Write(ctx, []Point{point}))
+	metric, err := storage.Get(ctx, metrics.Counter, "cpu.time")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), metric.Delta)
+}
+
+func TestReceiver_WriteIdleSeries(t *testing.T) {
+	ctx := context.Background()
+	receiver := NewReceiver(storages.NewMemStorage(), Rules{{Pattern: "*", Action: CounterAction}})
+	now := time.Unix(0, 0)
+	receiver.now = func() time.Time { return now }
+
+	require.NoError(t, receiver.Write(ctx, []Point{{Measurement: "cpu", Fields: []Field{{Key: "time", Type: FloatField, Number: 0.4}}}}))
+	require.Len(t, receiver.series, 1)
+
+	now = now.Add(2 * seriesIdleTimeout)
+	require.NoError(t, receiver.Write(ctx, []Point{{Measurement: "io", Fields: []Field{{Key: "time", Type: FloatField, Number: 0.4}}}}))
+	assert.Len(t, receiver.series, 1, "idle series must be removed")
+}
@@ -0,0 +1,144 @@
+package influx
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/SpaceSlow/execenv/internal/metrics"
+)
+
+var (
+	_ flag.Value = (*Rules)(nil)
+
+	ErrIncorrectRule = errors.New("need rule in a form <measurement.field pattern>=<gauge|counter|skip>")
+)
+
+// Action способ сохранения поля, подходящего под правило.
+type Action int
+
+const (
+	GaugeAction Action = iota
+	CounterAction
+	SkipAction
+)
+
+// Rule правило преобразования полей точек в метрики.
+// Pattern сопоставляется (path.Match) с именем <measurement>.<field>.
+type Rule struct {
+	Pattern string
+	Action  Action
+}
+
+func (r *Rule) UnmarshalText(text []byte) error {
+	pattern, action, ok := strings.Cut(string(text), "=")
+	if !ok || pattern == "" {
+		return ErrIncorrectRule
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return ErrIncorrectRule
+	}
+
+	switch action {
+	case "gauge":
+		r.Action = GaugeAction
+	case "counter":
+		r.Action = CounterAction
+	case "skip":
+		r.Action = SkipAction
+	default:
+		return ErrIncorrectRule
+	}
+	r.Pattern = pattern
+	return nil
+}
+
+func (r Rule) String() string {
+	switch r.Action {
+	case CounterAction:
+		return r.Pattern + "=counter"
+	case SkipAction:
+		return r.Pattern + "=skip"
+	default:
+		return r.Pattern + "=gauge"
+	}
+}
+
+// Rules набор правил, применяется первое подходящее.
+// Числовые и логические поля, не подходящие ни под одно правило, сохраняются как gauge, строковые поля пропускаются.
+// Для counter значение поля считается приращением и округляется до целого (см. Receiver).
+type Rules []Rule
+
+func (r *Rules) String() string {
+	if r == nil {
+		return ""
+	}
+	rules := make([]string, 0, len(*r))
+	for _, rule := range *r {
+		rules = append(rules, rule.String())
+	}
+	return strings.Join(rules, ",")
+}
+
+// Set добавляет правило, позволяя указывать флаг несколько раз.
+func (r *Rules) Set(s string) error {
+	var rule Rule
+	if err := rule.UnmarshalText([]byte(s)); err != nil {
+		return err
+	}
+	*r = append(*r, rule)
+	return nil
+}
+
+func (r Rules) action(name string) Action {
+	for _, rule := range r {
+		if ok, _ := path.Match(rule.Pattern, name); ok {
+			return rule.Action
+		}
+	}
+	return GaugeAction
+}
+
+// fieldValue значение поля точки, сохраняемое как метрика name.
+type fieldValue struct {
+	name    string
+	value   float64
+	counter bool
+}
+
+// values возвращает значения полей точек, сохраняемых как метрики.
+// Имя метрики — <measurement>.<field>, при наличии тегов к нему добавляются теги в виде {key=value,...}.
+// Возвращает metrics.ErrMetricNameTooLong, если имя метрики длиннее metrics.MaxNameLength.
+func (r Rules) values(points []Point) ([]fieldValue, error) {
+	values := make([]fieldValue, 0, len(points))
+	for _, point := range points {
+		var tags string
+		if len(point.Tags) > 0 {
+			pairs := make([]string, 0, len(point.Tags))
+			for _, tag := range point.Tags {
+				pairs = append(pairs, tag.Key+"="+tag.Value)
+			}
+			tags = "{" + strings.Join(pairs, ",") + "}"
+		}
+
+		for _, field := range point.Fields {
+			if field.Type == StringField {
+				continue
+			}
+
+			name := point.Measurement + "." + field.Key
+			if len(name+tags) > metrics.MaxNameLength {
+				return nil, fmt.Errorf("%w: %s", metrics.ErrMetricNameTooLong, name+tags)
+			}
+			switch r.action(name) {
+			case GaugeAction:
+				values = append(values, fieldValue{name: name + tags, value: field.Number})
+			case CounterAction:
+				values = append(values, fieldValue{name: name + tags, value: field.Number, counter: true})
+			}
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,25 @@
+package influx
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRules_Set(t *testing.T) {
+	var rules Rules
+	require.NoError(t, rules.Set("net.bytes_*=counter"))
+	require.NoError(t, rules.Set("cpu.*=skip"))
+	assert.Equal(t, Rules{{Pattern: "net.bytes_*", Action: CounterAction}, {Pattern: "cpu.*", Action: SkipAction}}, rules)
+	assert.Equal(t, "net.bytes_*=counter,cpu.*=skip", rules.String())
+
+	for _, incorrect := range []string{"net.bytes_*", "=gauge", "net.*=histogram", "[=gauge"} {
+		assert.ErrorIs(t, rules.Set(incorrect), ErrIncorrectRule, incorrect)
+	}
+
+	var fromJSON Rules
+	require.NoError(t, json.Unmarshal([]byte(`["net.bytes_*=counter", "cpu.*=skip"]`), &fromJSON))
+	assert.Equal(t, rules, fromJSON)
+}
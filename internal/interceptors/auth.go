@@ -0,0 +1,65 @@
+package interceptors
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/SpaceSlow/execenv/internal/auth"
+	"github.com/SpaceSlow/execenv/internal/ratelimit"
+	"github.com/SpaceSlow/execenv/internal/subnet"
+)
+
+// AuthUnaryInterceptor возвращает интерсептор, пропускающий только запросы с токеном (метаданные authorization:
+// Bearer <токен>), которому разрешена область доступа метода (scopes, для неуказанных методов — auth.ScopeAdmin).
+// Вызовы методов с областью auth.ScopeWrite записываются в журнал аудита.
+// Если токены не настроены, запросы не проверяются.
+func AuthUnaryInterceptor(tokens auth.Tokens, scopes map[string]auth.Scope) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !tokens.Enabled() {
+			return handler(ctx, req)
+		}
+
+		scope, ok := scopes[info.FullMethod]
+		if !ok {
+			scope = auth.ScopeAdmin
+		}
+
+		var authorization string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get("authorization"); len(values) == 1 {
+				authorization = values[0]
+			}
+		}
+		token, err := tokens.Authorize(authorization, scope)
+		switch {
+		case errors.Is(err, auth.ErrForbidden):
+			return nil, status.Errorf(codes.PermissionDenied, "token %s has no %s scope", token.Name, scope)
+		case err != nil:
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+
+		response, err := handler(auth.WithToken(ctx, token), req)
+		if scope == auth.ScopeWrite {
+			auth.Audit(token,
+				zap.String("grpc method", info.FullMethod),
+				zap.String("agent", auditAgent(ctx)),
+				zap.Any("status", status.Code(err)),
+			)
+		}
+		return response, err
+	}
+}
+
+// auditAgent возвращает агента, определённого RateLimitUnaryInterceptor, или, без ограничения запросов, адрес клиента.
+func auditAgent(ctx context.Context) string {
+	if agent, ok := ratelimit.AgentFromContext(ctx); ok {
+		return agent
+	}
+	return agentFromContext(ctx, subnet.Filter{})
+}
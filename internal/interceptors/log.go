@@ -9,9 +9,11 @@ import (
 
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
 	"github.com/SpaceSlow/execenv/internal/logger"
+	"github.com/SpaceSlow/execenv/internal/selfmetrics"
 )
 
 func size(response interface{}) int {
@@ -24,19 +26,34 @@ func size(response interface{}) int {
 	return binary.Size(buff.Bytes())
 }
 
-func LogUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-	start := time.Now()
-	response, err := handler(ctx, req)
-
-	duration := time.Since(start)
-
-	logger.Log.Info(
-		"request/response",
-		zap.String("grpc method", info.FullMethod),
-		zap.Duration("duration", duration),
-		zap.Any("status", status.Code(err)),
-		zap.Int("size", size(response)),
-	)
+// LogUnaryInterceptor возвращает интерсептор, логирующий запросы. Количество запросов и продолжительность обработки
+// учитываются во внутренних метриках сервера (recorder).
+func LogUnaryInterceptor(recorder *selfmetrics.Recorder) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		response, err := handler(ctx, req)
+
+		duration := time.Since(start)
+		recordGRPCRequest(recorder, status.Code(err), duration)
+
+		logger.Log.Info(
+			"request/response",
+			zap.String("grpc method", info.FullMethod),
+			zap.Duration("duration", duration),
+			zap.Any("status", status.Code(err)),
+			zap.Int("size", size(response)),
+		)
+
+		return response, err
+	}
+}
 
-	return response, err
+// recordGRPCRequest учитывает запрос во внутренних метриках: grpc.req.ok или grpc.req.err и grpc.dur.
+func recordGRPCRequest(recorder *selfmetrics.Recorder, code codes.Code, duration time.Duration) {
+	if code == codes.OK {
+		recorder.Add("grpc.req.ok", 1)
+	} else {
+		recorder.Add("grpc.req.err", 1)
+	}
+	recorder.ObserveDuration("grpc.dur", duration)
 }
@@ -0,0 +1,58 @@
+package interceptors
+
+import (
+	"context"
+	"math"
+	"net"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/SpaceSlow/execenv/internal/mtls"
+	"github.com/SpaceSlow/execenv/internal/ratelimit"
+	"github.com/SpaceSlow/execenv/internal/subnet"
+)
+
+// RateLimitUnaryInterceptor возвращает интерсептор, ограничивающий частоту запросов агентов.
+// При превышении возвращается ResourceExhausted и заголовок retry-after с количеством секунд до следующей попытки.
+// Агент определяется по клиентскому сертификату (mtls.AgentFromCertificates), а при его отсутствии —
+// по адресу клиента, который берётся из метаданных только для запросов через доверенные прокси
+// текущих ограничений filter (subnet.Filter.ClientIP). Агент передаётся в контексте
+// запроса (ratelimit.WithAgent) для проверки квот метрик хранилищем (ratelimit.QuotaStorage).
+func RateLimitUnaryInterceptor(limiter *ratelimit.Limiter, filter func() subnet.Filter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		agent := agentFromContext(ctx, filter())
+		if ok, retryAfter := limiter.Allow(agent); !ok {
+			grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))))
+			return nil, status.Error(codes.ResourceExhausted, "too many requests from agent "+agent)
+		}
+		return handler(ratelimit.WithAgent(ctx, agent), req)
+	}
+}
+
+func agentFromContext(ctx context.Context, filter subnet.Filter) string {
+	p, hasPeer := peer.FromContext(ctx)
+	if hasPeer {
+		if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			if agent, ok := mtls.AgentFromCertificates(&tlsInfo.State); ok {
+				return agent
+			}
+		}
+	}
+	if ip := clientIP(ctx, filter); ip != nil {
+		return ip.String()
+	}
+	if !hasPeer {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
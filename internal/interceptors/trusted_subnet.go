@@ -7,26 +7,41 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 
-	"github.com/SpaceSlow/execenv/internal/config"
+	"github.com/SpaceSlow/execenv/internal/subnet"
 )
 
-func WithCheckingTrustedSubnetUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-	cfg, err := config.GetServerConfig()
-	if err != nil {
-		return nil, err
-	}
-
-	if cfg.TrustedSubnet != config.NewCIDR("") {
-		md, ok := metadata.FromIncomingContext(ctx)
-		if !ok {
-			return nil, status.Error(codes.PermissionDenied, "")
+// WithCheckingTrustedSubnetUnaryInterceptor возвращает интерсептор, пропускающий запросы только от клиентов
+// с адресами, разрешёнными текущими ограничениями filter. Адрес клиента определяется по адресу соединения, а для запросов
+// через доверенные прокси — по метаданным x-forwarded-for и x-real-ip (subnet.Filter.ClientIP).
+func WithCheckingTrustedSubnetUnaryInterceptor(filter func() subnet.Filter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		filter := filter()
+		if !filter.Enabled() {
+			return handler(ctx, req)
 		}
-		if realIP := md.Get("X-Real-IP"); len(realIP) != 1 || !cfg.TrustedSubnet.Contains(net.ParseIP(realIP[0])) {
-			return nil, status.Error(codes.PermissionDenied, "")
+
+		if err := filter.Check(clientIP(ctx, filter)); err != nil {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
 		}
+		return handler(ctx, req)
 	}
+}
 
-	return handler(ctx, req)
+// clientIP возвращает адрес клиента запроса с учётом доверенных прокси (subnet.Filter.ClientIP).
+func clientIP(ctx context.Context, filter subnet.Filter) net.IP {
+	var remoteAddr, realIP string
+	var forwardedFor []string
+	if p, ok := peer.FromContext(ctx); ok {
+		remoteAddr = p.Addr.String()
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		forwardedFor = md.Get("X-Forwarded-For")
+		if values := md.Get("X-Real-IP"); len(values) == 1 {
+			realIP = values[0]
+		}
+	}
+	return filter.ClientIP(remoteAddr, forwardedFor, realIP)
 }
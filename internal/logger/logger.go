@@ -4,6 +4,9 @@ import "go.uber.org/zap"
 
 var Log = zap.NewNop()
 
+// atomicLevel уровень журнала Log, позволяет изменять его без пересоздания журнала (SetLevel).
+var atomicLevel = zap.NewAtomicLevel()
+
 func Initialize(level string) error {
 	lvl, err := zap.ParseAtomicLevel(level)
 	if err != nil {
@@ -16,5 +19,16 @@ func Initialize(level string) error {
 		return err
 	}
 	Log = zl
+	atomicLevel = lvl
+	return nil
+}
+
+// SetLevel изменяет уровень журнала, созданного Initialize.
+func SetLevel(level string) error {
+	lvl, err := zap.ParseAtomicLevel(level)
+	if err != nil {
+		return err
+	}
+	atomicLevel.SetLevel(lvl.Level())
 	return nil
 }
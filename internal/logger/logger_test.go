@@ -1,6 +1,10 @@
 package logger
 
-import "testing"
+import (
+	"testing"
+
+	"go.uber.org/zap"
+)
 
 func TestInitialize(t *testing.T) {
 	tests := []struct {
@@ -32,3 +36,23 @@ func TestInitialize(t *testing.T) {
 		})
 	}
 }
+
+func TestSetLevel(t *testing.T) {
+	if err := Initialize("info"); err != nil {
+		t.Fatal(err)
+	}
+	if Log.Core().Enabled(zap.DebugLevel) {
+		t.Fatal("debug level enabled before SetLevel")
+	}
+
+	if err := SetLevel("debug"); err != nil {
+		t.Fatal(err)
+	}
+	if !Log.Core().Enabled(zap.DebugLevel) {
+		t.Error("debug level not enabled after SetLevel")
+	}
+
+	if err := SetLevel("unknown"); err == nil {
+		t.Error("SetLevel() with unknown level: expected error")
+	}
+}
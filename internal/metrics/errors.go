@@ -5,4 +5,21 @@ import "errors"
 var (
 	ErrIncorrectMetricTypeOrValue = errors.New("incorrect metric type or value")
 	ErrEmptyMetricName            = errors.New("empty metric name")
+	ErrMetricNameTooLong          = errors.New("metric name too long")
+	ErrReservedMetricName         = errors.New("reserved metric name")
+
+	// ErrUnknownMetricType и ErrIncorrectMetricValue уточняют причину ErrIncorrectMetricTypeOrValue,
+	// errors.Is(err, ErrIncorrectMetricTypeOrValue) для них также выполняется.
+	ErrUnknownMetricType    error = metricError("unknown metric type")
+	ErrIncorrectMetricValue error = metricError("incorrect metric value")
 )
+
+type metricError string
+
+func (e metricError) Error() string {
+	return string(e)
+}
+
+func (e metricError) Is(target error) bool {
+	return target == ErrIncorrectMetricTypeOrValue
+}
@@ -27,7 +27,7 @@ func generateRandomMetric() Metric {
 
 	switch mType {
 	case Counter:
-		metric.Value = rand.Int63n(1000)
+		metric.Delta = rand.Int63n(1000)
 	case Gauge:
 		metric.Value = rand.Float64()
 	}
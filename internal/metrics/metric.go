@@ -19,27 +19,43 @@ type JSONMetric struct {
 	MType string   `json:"type"`
 }
 
+// Metric хранит метрику, значение которой определяется типом Type:
+// для Counter используется поле Delta, для Gauge — поле Value.
 type Metric struct {
-	Value any
 	Name  string
+	Delta int64
+	Value float64
 	Type  MetricType
 }
 
+// NewCounter возвращает метрику типа Counter.
+func NewCounter(name string, delta int64) Metric {
+	return Metric{Type: Counter, Name: name, Delta: delta}
+}
+
+// NewGauge возвращает метрику типа Gauge.
+func NewGauge(name string, value float64) Metric {
+	return Metric{Type: Gauge, Name: name, Value: value}
+}
+
 func NewMetric(metricType MetricType, name, value string) (*Metric, error) {
+	metric := &Metric{Type: metricType, Name: name}
+
 	var err error
-	var val interface{}
 	switch metricType {
 	case Counter:
-		val, err = strconv.ParseInt(value, 10, 64)
+		metric.Delta, err = strconv.ParseInt(value, 10, 64)
 	case Gauge:
-		val, err = strconv.ParseFloat(value, 64)
+		metric.Value, err = strconv.ParseFloat(value, 64)
+	default:
+		return nil, ErrUnknownMetricType
 	}
 
 	if err != nil {
-		return nil, ErrIncorrectMetricTypeOrValue
+		return nil, ErrIncorrectMetricValue
 	}
 
-	return &Metric{Type: metricType, Name: name, Value: val}, nil
+	return metric, nil
 }
 
 func (m *Metric) MarshalJSON() ([]byte, error) {
@@ -50,19 +66,11 @@ func (m *Metric) MarshalJSON() ([]byte, error) {
 
 	switch m.Type {
 	case Counter:
-		delta, ok := m.Value.(int64)
-		if !ok {
-			return nil, ErrIncorrectMetricTypeOrValue
-		}
-		metric.Delta = &delta
+		metric.Delta = &m.Delta
 	case Gauge:
-		value, ok := m.Value.(float64)
-		if !ok {
-			return nil, ErrIncorrectMetricTypeOrValue
-		}
-		metric.Value = &value
+		metric.Value = &m.Value
 	default:
-		return nil, ErrIncorrectMetricTypeOrValue
+		return nil, ErrUnknownMetricType
 	}
 
 	return json.Marshal(metric)
@@ -71,7 +79,7 @@ func (m *Metric) MarshalJSON() ([]byte, error) {
 func (m *Metric) UnmarshalJSON(data []byte) error {
 	var metric JSONMetric
 	if json.Unmarshal(data, &metric) != nil {
-		return ErrIncorrectMetricTypeOrValue
+		return ErrIncorrectMetricValue
 	}
 
 	var mType MetricType
@@ -84,36 +92,57 @@ func (m *Metric) UnmarshalJSON(data []byte) error {
 	m.Type = mType
 	switch mType {
 	case Counter:
-		if metric.Delta != nil {
-			m.Value = *metric.Delta
+		if metric.Delta == nil {
+			return ErrIncorrectMetricValue
 		}
+		m.Delta = *metric.Delta
 	case Gauge:
-		if metric.Value != nil {
-			m.Value = *metric.Value
+		if metric.Value == nil {
+			return ErrIncorrectMetricValue
 		}
-	}
-	if m.Value == nil {
-		return ErrIncorrectMetricTypeOrValue
+		m.Value = *metric.Value
 	}
 	return nil
 }
 
 func (m *Metric) String() string {
-	return fmt.Sprintf("%s = %v (%s)", m.Name, m.Value, m.Type)
+	switch m.Type {
+	case Counter:
+		return fmt.Sprintf("%s = %v (%s)", m.Name, m.Delta, m.Type)
+	case Gauge:
+		return fmt.Sprintf("%s = %v (%s)", m.Name, m.Value, m.Type)
+	default:
+		return fmt.Sprintf("%s = <nil> (%s)", m.Name, m.Type)
+	}
 }
 
 func (m *Metric) ValueAsString() string {
 	switch m.Type {
 	case Counter:
-		return strconv.FormatInt(m.Value.(int64), 10)
+		return strconv.FormatInt(m.Delta, 10)
 	case Gauge:
-		return strconv.FormatFloat(m.Value.(float64), 'f', -1, 64)
+		return strconv.FormatFloat(m.Value, 'f', -1, 64)
 	default:
 		return ""
 	}
 }
 
+// MaxNameLength максимальная длина имени метрики в байтах, включая метки {key=value,...},
+// которые добавляют к имени приёмники метрик.
+const MaxNameLength = 256
+
+// Validate проверяет корректность типа метрики и длину имени.
+func (m *Metric) Validate() error {
+	if !m.Type.isValid() {
+		return ErrUnknownMetricType
+	}
+	if len(m.Name) > MaxNameLength {
+		return ErrMetricNameTooLong
+	}
+	return nil
+}
+
 func (m *Metric) Copy() *Metric {
-	metric, _ := NewMetric(m.Type, m.Name, m.ValueAsString())
-	return metric
+	metric := *m
+	return &metric
 }
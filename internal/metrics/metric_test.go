@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetric_MarshalJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		metric   Metric
+		wantJSON string
+		wantErr  error
+	}{
+		{
+			name:     "counter metric",
+			metric:   NewCounter("PollCount", 42),
+			wantJSON: `{"id": "PollCount", "type": "counter", "delta": 42}`,
+		},
+		{
+			name:     "gauge metric",
+			metric:   NewGauge("RandomValue", 4.2),
+			wantJSON: `{"id": "RandomValue", "type": "gauge", "value": 4.2}`,
+		},
+		{
+			name:    "incorrect metric type",
+			metric:  Metric{Type: MetricType(-1), Name: "PollCount", Delta: 42},
+			wantErr: ErrIncorrectMetricTypeOrValue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(&tt.metric)
+			require.ErrorIs(t, err, tt.wantErr)
+			if err != nil {
+				return
+			}
+			assert.JSONEq(t, tt.wantJSON, string(data))
+		})
+	}
+}
+
+func TestMetric_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string
+		wantMetric Metric
+		wantErr    error
+	}{
+		{
+			name:       "counter metric",
+			data:       `{"id": "PollCount", "type": "counter", "delta": 42}`,
+			wantMetric: NewCounter("PollCount", 42),
+		},
+		{
+			name:       "gauge metric",
+			data:       `{"id": "RandomValue", "type": "gauge", "value": 4.2}`,
+			wantMetric: NewGauge("RandomValue", 4.2),
+		},
+		{
+			name:    "counter metric without delta",
+			data:    `{"id": "PollCount", "type": "counter", "value": 4.2}`,
+			wantErr: ErrIncorrectMetricTypeOrValue,
+		},
+		{
+			name:    "gauge metric without value",
+			data:    `{"id": "RandomValue", "type": "gauge", "delta": 42}`,
+			wantErr: ErrIncorrectMetricTypeOrValue,
+		},
+		{
+			name:    "incorrect metric type",
+			data:    `{"id": "RandomValue", "type": "unknown", "value": 4.2}`,
+			wantErr: ErrIncorrectMetricTypeOrValue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var metric Metric
+			err := json.Unmarshal([]byte(tt.data), &metric)
+			require.ErrorIs(t, err, tt.wantErr)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.wantMetric, metric)
+		})
+	}
+}
+
+func TestNewMetric(t *testing.T) {
+	tests := []struct {
+		name       string
+		metricType MetricType
+		value      string
+		wantMetric *Metric
+		wantErr    error
+	}{
+		{name: "counter metric", metricType: Counter, value: "42", wantMetric: &Metric{Type: Counter, Name: "metric", Delta: 42}},
+		{name: "gauge metric", metricType: Gauge, value: "4.2", wantMetric: &Metric{Type: Gauge, Name: "metric", Value: 4.2}},
+		{name: "float value for counter", metricType: Counter, value: "4.2", wantErr: ErrIncorrectMetricTypeOrValue},
+		{name: "incorrect gauge value", metricType: Gauge, value: "none", wantErr: ErrIncorrectMetricTypeOrValue},
+		{name: "incorrect metric type", metricType: MetricType(-1), value: "42", wantErr: ErrIncorrectMetricTypeOrValue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metric, err := NewMetric(tt.metricType, "metric", tt.value)
+			require.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.wantMetric, metric)
+		})
+	}
+}
@@ -35,6 +35,6 @@ func ParseMetricType(mType string) (MetricType, error) {
 	case "gauge":
 		return Gauge, nil
 	default:
-		return MetricType(-1), ErrIncorrectMetricTypeOrValue
+		return MetricType(-1), ErrUnknownMetricType
 	}
 }
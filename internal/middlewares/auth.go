@@ -0,0 +1,64 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/SpaceSlow/execenv/internal/auth"
+	"github.com/SpaceSlow/execenv/internal/problems"
+	"github.com/SpaceSlow/execenv/internal/ratelimit"
+	"github.com/SpaceSlow/execenv/internal/subnet"
+)
+
+// WithAuthorization возвращает middleware, пропускающую только запросы с токеном (Authorization: Bearer <токен>),
+// которому разрешена область доступа scope. Запросы на запись (auth.ScopeWrite) записываются в журнал аудита.
+// Если токены не настроены, запросы не проверяются.
+func WithAuthorization(tokens auth.Tokens, scope auth.Scope) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !tokens.Enabled() {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, err := tokens.Authorize(r.Header.Get("Authorization"), scope)
+			switch {
+			case errors.Is(err, auth.ErrForbidden):
+				problems.Write(w, r, http.StatusForbidden, problems.CodeForbidden, "token "+token.Name+" has no "+string(scope)+" scope")
+				return
+			case err != nil:
+				w.Header().Set("WWW-Authenticate", `Bearer realm="execenv"`)
+				problems.Write(w, r, http.StatusUnauthorized, problems.CodeUnauthorized, err.Error())
+				return
+			}
+
+			if scope != auth.ScopeWrite {
+				next.ServeHTTP(w, r.WithContext(auth.WithToken(r.Context(), token)))
+				return
+			}
+
+			l := loggingResponseWriter{
+				ResponseWriter: w,
+				response:       &response{},
+			}
+			next.ServeHTTP(&l, r.WithContext(auth.WithToken(r.Context(), token)))
+			if l.response.statusCode == 0 {
+				l.response.statusCode = http.StatusOK
+			}
+			auth.Audit(token,
+				zap.String("method", r.Method),
+				zap.String("uri", r.RequestURI),
+				zap.String("agent", auditAgent(r)),
+				zap.Int("status", l.response.statusCode),
+			)
+		})
+	}
+}
+
+// auditAgent возвращает агента, определённого WithRateLimiting, или, без ограничения запросов, адрес клиента.
+func auditAgent(r *http.Request) string {
+	if agent, ok := ratelimit.AgentFromContext(r.Context()); ok {
+		return agent
+	}
+	return agentFromRequest(r, subnet.Filter{})
+}
@@ -0,0 +1,58 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/SpaceSlow/execenv/internal/auth"
+	"github.com/SpaceSlow/execenv/internal/logger"
+	"github.com/SpaceSlow/execenv/internal/ratelimit"
+)
+
+func TestWithAuthorization_Audit(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	defaultLog := logger.Log
+	logger.Log = zap.New(core)
+	defer func() { logger.Log = defaultLog }()
+
+	tokens := auth.Tokens{{Name: "agent", Hash: auth.HashToken("secret"), Scopes: []auth.Scope{auth.ScopeRead, auth.ScopeWrite}}}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := auth.TokenFromContext(r.Context())
+		assert.True(t, ok)
+		assert.Equal(t, "agent", token.Name)
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	for _, scope := range []auth.Scope{auth.ScopeRead, auth.ScopeWrite} {
+		req := httptest.NewRequest(http.MethodPost, "/updates/", nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		req = req.WithContext(ratelimit.WithAgent(req.Context(), "10.0.0.1"))
+		res := httptest.NewRecorder()
+		WithAuthorization(tokens, scope)(next).ServeHTTP(res, req)
+		assert.Equal(t, http.StatusAccepted, res.Code)
+	}
+
+	audit := logs.FilterMessage("audit").AllUntimed()
+	if assert.Len(t, audit, 1, "only writes are audited") {
+		assert.Equal(t, map[string]interface{}{
+			"token":  "agent",
+			"method": http.MethodPost,
+			"uri":    "/updates/",
+			"agent":  "10.0.0.1",
+			"status": int64(http.StatusAccepted),
+		}, audit[0].ContextMap())
+	}
+}
+
+func TestWithAuthorization_Disabled(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	res := httptest.NewRecorder()
+	WithAuthorization(nil, auth.ScopeAdmin)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+}
@@ -1,11 +1,14 @@
 package middlewares
 
 import (
+	"bytes"
 	"compress/gzip"
 	"io"
 	"net/http"
 	"slices"
 	"strings"
+
+	"github.com/SpaceSlow/execenv/internal/problems"
 )
 
 var (
@@ -75,27 +78,45 @@ func (c *compressReader) Close() error {
 	return c.zr.Close()
 }
 
-// WithCompressing middleware обрабатывает запросы с сжатыми данными (поддерживаемый алгоритм: CompressionAlgorithm).
-func WithCompressing(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		isContainsCompression := strings.Contains(r.Header.Get("Content-Encoding"), CompressionAlgorithm)
-		if isContainsCompression {
-			cr, err := newCompressReader(r.Body)
-			if err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-				return
+// WithCompressing возвращает middleware, обрабатывающую запросы с сжатыми данными (поддерживаемый алгоритм:
+// CompressionAlgorithm) и сжимающую ответы. Если maxRequestSize > 0, распакованное тело запроса ограничивается
+// этим размером: иначе небольшое сжатое тело проходило бы проверку WithRateLimiting, а распаковывалось без ограничений.
+func WithCompressing(maxRequestSize int64) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			isContainsCompression := strings.Contains(r.Header.Get("Content-Encoding"), CompressionAlgorithm)
+			if isContainsCompression {
+				cr, err := newCompressReader(r.Body)
+				if err != nil {
+					problems.Write(w, r, http.StatusBadRequest, problems.CodeBadRequest, "request body is not "+CompressionAlgorithm+" compressed")
+					return
+				}
+				defer cr.Close()
+				r.Body = cr
+
+				if maxRequestSize > 0 {
+					body, err := io.ReadAll(io.LimitReader(cr, maxRequestSize+1))
+					if err != nil {
+						problems.Write(w, r, http.StatusBadRequest, problems.CodeBadRequest, err.Error())
+						return
+					}
+					if int64(len(body)) > maxRequestSize {
+						writeRequestTooLarge(w, r, maxRequestSize)
+						return
+					}
+					r.Body = io.NopCloser(bytes.NewReader(body))
+					r.ContentLength = int64(len(body))
+				}
+			}
+
+			isMatchCompressionAlgorithm := strings.Contains(r.Header.Get("Accept-Encoding"), CompressionAlgorithm)
+			if isMatchCompressionAlgorithm {
+				cw := newCompressResponseWriter(w)
+				w = cw
+				defer cw.compressWriter.Close()
 			}
-			r.Body = cr
-			defer cr.Close()
-		}
-
-		isMatchCompressionAlgorithm := strings.Contains(r.Header.Get("Accept-Encoding"), CompressionAlgorithm)
-		if isMatchCompressionAlgorithm {
-			cw := newCompressResponseWriter(w)
-			w = cw
-			defer cw.compressWriter.Close()
-		}
-
-		next.ServeHTTP(w, r)
-	})
+
+			next.ServeHTTP(w, r)
+		})
+	}
 }
@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/SpaceSlow/execenv/internal/utils"
 )
@@ -39,7 +40,7 @@ func TestWithCompressing_compressedResponse(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			handler := WithCompressing(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handler := WithCompressing(0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				if r.Body == nil {
 					return
 				}
@@ -78,7 +79,7 @@ func TestWithCompressing_compressedRequest(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			handler := WithCompressing(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handler := WithCompressing(0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				if r.Body == nil {
 					return
 				}
@@ -100,6 +101,46 @@ func TestWithCompressing_compressedRequest(t *testing.T) {
 	}
 }
 
+func TestWithCompressing_maxRequestSize(t *testing.T) {
+	tests := []struct {
+		name           string
+		decodedSize    int
+		wantStatusCode int
+	}{
+		{
+			name:           "decompressed body within limit",
+			decodedSize:    1 << 10,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "decompressed body exceeds limit",
+			decodedSize:    1 << 19,
+			wantStatusCode: http.StatusRequestEntityTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := utils.Compress(make([]byte, tt.decodedSize))
+			require.NoError(t, err)
+			// сжатое тело меньше ограничения, поэтому проходит проверку WithRateLimiting
+			require.Less(t, len(data), 1<<10)
+
+			req := httptest.NewRequest(http.MethodPost, "/updates/", bytes.NewReader(data))
+			req.Header.Set("Content-Encoding", CompressionAlgorithm)
+			handler := WithCompressing(1 << 10)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				assert.Len(t, body, tt.decodedSize)
+			}))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.wantStatusCode, rr.Code)
+		})
+	}
+}
+
 func randStringBytes(n int) string {
 	b := make([]byte, n)
 	for i := range b {
@@ -7,36 +7,37 @@ import (
 	"io"
 	"net/http"
 
-	"github.com/SpaceSlow/execenv/internal/config"
+	"github.com/SpaceSlow/execenv/internal/problems"
 )
 
-// WithDecryption middleware предназначенная для расшифрования данных с агента.
-func WithDecryption(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		cfg, err := config.GetServerConfig()
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-		if cfg.PrivateKey() == nil || r.Body == nil {
-			next.ServeHTTP(w, r)
-			return
+// WithDecryption возвращает middleware, расшифровывающую данные с агента закрытым ключом privateKey.
+// Если ключ не задан, данные передаются без изменений.
+func WithDecryption(privateKey *rsa.PrivateKey) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if privateKey == nil {
+			return next
 		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
 
-		encryptedData, err := io.ReadAll(r.Body)
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
+			encryptedData, err := io.ReadAll(r.Body)
+			if err != nil {
+				problems.Internal(w, r)
+				return
+			}
 
-		decryptedData, err := rsa.DecryptPKCS1v15(rand.Reader, cfg.PrivateKey(), encryptedData)
-		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			return
-		}
-		rb := bytes.NewReader(decryptedData)
-		r.Body = io.NopCloser(rb)
+			decryptedData, err := rsa.DecryptPKCS1v15(rand.Reader, privateKey, encryptedData)
+			if err != nil {
+				problems.Write(w, r, http.StatusBadRequest, problems.CodeDecryptionFailed, "request body could not be decrypted")
+				return
+			}
+			rb := bytes.NewReader(decryptedData)
+			r.Body = io.NopCloser(rb)
 
-		next.ServeHTTP(w, r)
-	})
+			next.ServeHTTP(w, r)
+		})
+	}
 }
@@ -4,42 +4,26 @@ import (
 	"bytes"
 	"crypto/rand"
 	"crypto/rsa"
-	"crypto/x509"
-	"encoding/pem"
 	"io"
 	"net/http"
 	"net/http/httptest"
-	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-
-	"github.com/SpaceSlow/execenv/internal/config"
 )
 
-func writeRandomPrivateKeyToFile(filename string) error {
-	privateKey, err := rsa.GenerateKey(rand.Reader, 4096)
-	if err != nil {
-		return err
-	}
-	privateKeyBytes := x509.MarshalPKCS1PrivateKey(privateKey)
-	block := &pem.Block{
-		Type:  "PRIVATE KEY",
-		Bytes: privateKeyBytes,
-	}
-	pemPublicKey := pem.EncodeToMemory(block)
-
-	return os.WriteFile(filename, pemPublicKey, 0600)
-}
-
 func TestWithDecryption(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
 	tests := []struct {
 		name           string
 		method         string
 		reqBody        []byte
 		wantBody       []byte
 		wantStatusCode int
+		withoutKey     bool
 	}{
 		{
 			name:           "post with non-empty request body",
@@ -61,23 +45,22 @@ func TestWithDecryption(t *testing.T) {
 			wantBody:       []byte(""),
 			wantStatusCode: http.StatusOK,
 		},
+		{
+			name:           "post without private key",
+			method:         http.MethodPost,
+			reqBody:        []byte("text"),
+			wantBody:       []byte("text"),
+			wantStatusCode: http.StatusOK,
+			withoutKey:     true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			file, err := os.CreateTemp(t.TempDir(), "private.*.key")
-			require.NoError(t, err)
-			defer os.Remove(file.Name())
-			err = writeRandomPrivateKeyToFile(file.Name())
-			require.NoError(t, err)
-
-			os.Args = []string{"test", ""}
-			os.Setenv("CRYPTO_KEY", file.Name())
-
-			cfg, err := config.GetServerConfig()
-			require.NoError(t, err)
-			require.NotNil(t, cfg.PrivateKey())
-
-			handler := WithDecryption(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := privateKey
+			if tt.withoutKey {
+				key = nil
+			}
+			handler := WithDecryption(key)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				if r.Body == nil {
 					return
 				}
@@ -88,11 +71,17 @@ func TestWithDecryption(t *testing.T) {
 				w.Write(data)
 			}))
 
-			var req *http.Request
+			var (
+				req *http.Request
+				err error
+			)
 			if tt.reqBody != nil {
-				encryptData, encErr := rsa.EncryptPKCS1v15(rand.Reader, &cfg.PrivateKey().PublicKey, tt.reqBody)
-				require.NoError(t, encErr)
-				req, err = http.NewRequest(tt.method, "https://example.com", bytes.NewReader(encryptData))
+				data := tt.reqBody
+				if key != nil {
+					data, err = rsa.EncryptPKCS1v15(rand.Reader, &key.PublicKey, tt.reqBody)
+					require.NoError(t, err)
+				}
+				req, err = http.NewRequest(tt.method, "https://example.com", bytes.NewReader(data))
 				require.NoError(t, err)
 			} else {
 				req, err = http.NewRequest(tt.method, "https://example.com", nil)
@@ -2,11 +2,13 @@ package middlewares
 
 import (
 	"net/http"
+	"strconv"
 	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/SpaceSlow/execenv/internal/logger"
+	"github.com/SpaceSlow/execenv/internal/selfmetrics"
 )
 
 var _ http.ResponseWriter = (*loggingResponseWriter)(nil)
@@ -35,28 +37,41 @@ func (l loggingResponseWriter) WriteHeader(statusCode int) {
 
 // WithLogging middleware предназначенная для логирования запросов пользователей.
 // В логи попадает следующая информация: uri, метод запроса, продолжительность обработки, статус ответа и размер ответа.
-func WithLogging(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-
-		l := loggingResponseWriter{
-			ResponseWriter: w,
-			response: &response{
-				statusCode: 0,
-				size:       0,
-			},
-		}
-		next.ServeHTTP(&l, r)
-
-		duration := time.Since(start)
-
-		logger.Log.Info(
-			"request/response",
-			zap.String("uri", r.RequestURI),
-			zap.String("method", r.Method),
-			zap.Duration("duration", duration),
-			zap.Int("status", l.response.statusCode),
-			zap.Int("size", l.response.size),
-		)
-	})
+// Количество запросов по классам статусов и продолжительность обработки учитываются во внутренних метриках сервера (recorder).
+func WithLogging(recorder *selfmetrics.Recorder) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			l := loggingResponseWriter{
+				ResponseWriter: w,
+				response: &response{
+					statusCode: 0,
+					size:       0,
+				},
+			}
+			next.ServeHTTP(&l, r)
+
+			duration := time.Since(start)
+			recordHTTPRequest(recorder, l.response.statusCode, duration)
+
+			logger.Log.Info(
+				"request/response",
+				zap.String("uri", r.RequestURI),
+				zap.String("method", r.Method),
+				zap.Duration("duration", duration),
+				zap.Int("status", l.response.statusCode),
+				zap.Int("size", l.response.size),
+			)
+		})
+	}
+}
+
+// recordHTTPRequest учитывает запрос во внутренних метриках: http.req.<класс статуса> и http.dur.
+func recordHTTPRequest(recorder *selfmetrics.Recorder, statusCode int, duration time.Duration) {
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+	recorder.Add("http.req."+strconv.Itoa(statusCode/100)+"xx", 1)
+	recorder.ObserveDuration("http.dur", duration)
 }
@@ -14,6 +14,8 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/SpaceSlow/execenv/internal/logger"
+	"github.com/SpaceSlow/execenv/internal/metrics"
+	"github.com/SpaceSlow/execenv/internal/selfmetrics"
 )
 
 func TestWithLogging(t *testing.T) {
@@ -34,7 +36,8 @@ func TestWithLogging(t *testing.T) {
 	logger.Log, err = cfg.Build()
 	require.NoError(t, err)
 
-	handler := WithLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	recorder := selfmetrics.NewRecorder()
+	handler := WithLogging(recorder)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		time.Sleep(expectedDuration)
 		w.WriteHeader(http.StatusOK)
 		w.Write(nil)
@@ -68,4 +71,5 @@ func TestWithLogging(t *testing.T) {
 	assert.Equal(t, 0, logJSON.Size)
 	assert.Equal(t, http.StatusOK, logJSON.Status)
 	assert.GreaterOrEqual(t, logJSON.Duration, expectedDuration)
+	assert.Contains(t, recorder.Flush(), metrics.NewCounter("_srv.http.req.2xx", 1))
 }
@@ -0,0 +1,81 @@
+package middlewares
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/SpaceSlow/execenv/internal/mtls"
+	"github.com/SpaceSlow/execenv/internal/problems"
+	"github.com/SpaceSlow/execenv/internal/ratelimit"
+	"github.com/SpaceSlow/execenv/internal/subnet"
+)
+
+// WithRateLimiting возвращает middleware, ограничивающую запросы агентов: частоту запросов и размер тела запроса.
+// Агент определяется по клиентскому сертификату (mtls.AgentFromCertificates), а при его отсутствии —
+// по адресу клиента, который берётся из заголовков только для запросов через доверенные прокси
+// текущих ограничений filter (subnet.Filter.ClientIP). Агент передаётся в контексте
+// запроса (ratelimit.WithAgent) для проверки квот метрик хранилищем (ratelimit.QuotaStorage).
+func WithRateLimiting(limiter *ratelimit.Limiter, filter func() subnet.Filter) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			agent := agentFromRequest(r, filter())
+			if ok, retryAfter := limiter.Allow(agent); !ok {
+				w.Header().Set("Retry-After", retryAfterSeconds(retryAfter))
+				problems.Write(w, r, http.StatusTooManyRequests, problems.CodeRateLimited, "too many requests from agent "+agent)
+				return
+			}
+
+			if maxSize := limiter.Limits().MaxRequestSize; maxSize > 0 && r.Body != nil {
+				if r.ContentLength > maxSize {
+					writeRequestTooLarge(w, r, maxSize)
+					return
+				}
+				// тело читается заранее, чтобы превышение размера при передаче без Content-Length
+				// не превращалось в ошибку разбора тела в обработчиках
+				body, err := io.ReadAll(io.LimitReader(r.Body, maxSize+1))
+				r.Body.Close()
+				if err != nil {
+					problems.Write(w, r, http.StatusBadRequest, problems.CodeBadRequest, err.Error())
+					return
+				}
+				if int64(len(body)) > maxSize {
+					writeRequestTooLarge(w, r, maxSize)
+					return
+				}
+				r.Body = io.NopCloser(bytes.NewReader(body))
+			}
+
+			next.ServeHTTP(w, r.WithContext(ratelimit.WithAgent(r.Context(), agent)))
+		})
+	}
+}
+
+func agentFromRequest(r *http.Request, filter subnet.Filter) string {
+	if agent, ok := mtls.AgentFromCertificates(r.TLS); ok {
+		return agent
+	}
+	// заголовкам клиента без доверенного прокси верить нельзя: иначе каждый запрос с новым X-Real-IP
+	// получал бы собственные ограничения
+	if ip := clientIP(r, filter); ip != nil {
+		return ip.String()
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func writeRequestTooLarge(w http.ResponseWriter, r *http.Request, maxSize int64) {
+	problems.Write(w, r, http.StatusRequestEntityTooLarge, problems.CodeRequestTooLarge, "request body exceeds "+strconv.FormatInt(maxSize, 10)+" bytes")
+}
+
+// retryAfterSeconds возвращает значение заголовка Retry-After: количество секунд, округлённое вверх.
+func retryAfterSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,163 @@
+package middlewares
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SpaceSlow/execenv/internal/problems"
+	"github.com/SpaceSlow/execenv/internal/ratelimit"
+	"github.com/SpaceSlow/execenv/internal/subnet"
+)
+
+func TestWithRateLimiting(t *testing.T) {
+	tests := []struct {
+		name           string
+		limits         ratelimit.Limits
+		body           string
+		chunked        bool
+		requests       int
+		wantStatusCode int
+		wantCode       problems.Code
+		wantRetryAfter string
+	}{
+		{
+			name:           "unlimited",
+			body:           "PollCount 1",
+			requests:       10,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "rate limit exceeded",
+			limits:         ratelimit.Limits{Rate: 0.5},
+			requests:       2,
+			wantStatusCode: http.StatusTooManyRequests,
+			wantCode:       problems.CodeRateLimited,
+			wantRetryAfter: "2",
+		},
+		{
+			name:           "request size within limit",
+			limits:         ratelimit.Limits{MaxRequestSize: 11},
+			body:           "PollCount 1",
+			requests:       1,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "request too large",
+			limits:         ratelimit.Limits{MaxRequestSize: 10},
+			body:           "PollCount 1",
+			requests:       1,
+			wantStatusCode: http.StatusRequestEntityTooLarge,
+			wantCode:       problems.CodeRequestTooLarge,
+		},
+		{
+			name:           "chunked request too large",
+			limits:         ratelimit.Limits{MaxRequestSize: 10},
+			body:           "PollCount 1",
+			chunked:        true,
+			requests:       1,
+			wantStatusCode: http.StatusRequestEntityTooLarge,
+			wantCode:       problems.CodeRequestTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := WithRateLimiting(ratelimit.NewLimiter(tt.limits), noFilter)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				agent, ok := ratelimit.AgentFromContext(r.Context())
+				assert.True(t, ok)
+				assert.Equal(t, "10.0.0.1", agent)
+
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				assert.Equal(t, tt.body, string(body))
+			}))
+
+			var res *httptest.ResponseRecorder
+			for i := 0; i < tt.requests; i++ {
+				req := httptest.NewRequest(http.MethodPost, "/api/v2/write", strings.NewReader(tt.body))
+				req.RemoteAddr = "10.0.0.1:51234"
+				if tt.chunked {
+					req.ContentLength = -1
+				}
+				res = httptest.NewRecorder()
+				handler.ServeHTTP(res, req)
+			}
+
+			assert.Equal(t, tt.wantStatusCode, res.Code)
+			assert.Equal(t, tt.wantRetryAfter, res.Header().Get("Retry-After"))
+			if tt.wantCode != "" {
+				assert.Equal(t, problems.ContentType, res.Header().Get("Content-Type"))
+				assert.Contains(t, res.Body.String(), `"code":"`+string(tt.wantCode)+`"`)
+			}
+		})
+	}
+}
+
+func TestWithRateLimiting_forwardedAgent(t *testing.T) {
+	_, proxies, _ := net.ParseCIDR("192.168.0.0/24")
+	filter := subnet.Filter{TrustedProxies: []*net.IPNet{proxies}}
+	tests := []struct {
+		name           string
+		remoteAddr     string
+		wantStatusCode int
+	}{
+		{
+			name:           "spoofed X-Real-IP from untrusted peer is throttled",
+			remoteAddr:     "10.0.0.1:51234",
+			wantStatusCode: http.StatusTooManyRequests,
+		},
+		{
+			name:           "X-Real-IP from trusted proxy identifies agent",
+			remoteAddr:     "192.168.0.10:51234",
+			wantStatusCode: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := ratelimit.NewLimiter(ratelimit.Limits{Rate: 0.5})
+			handler := WithRateLimiting(limiter, func() subnet.Filter { return filter })(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			var res *httptest.ResponseRecorder
+			for _, realIP := range []string{"172.16.0.1", "172.16.0.2"} {
+				req := httptest.NewRequest(http.MethodPost, "/api/v2/write", nil)
+				req.RemoteAddr = tt.remoteAddr
+				req.Header.Set("X-Real-IP", realIP)
+				res = httptest.NewRecorder()
+				handler.ServeHTTP(res, req)
+			}
+
+			assert.Equal(t, tt.wantStatusCode, res.Code)
+		})
+	}
+}
+
+func TestAgentFromRequest(t *testing.T) {
+	_, proxies, _ := net.ParseCIDR("192.168.0.0/24")
+	filter := subnet.Filter{TrustedProxies: []*net.IPNet{proxies}}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.10:51234"
+	req.Header.Set("X-Real-IP", "10.0.0.1")
+	assert.Equal(t, "10.0.0.10", agentFromRequest(req, filter))
+
+	req.RemoteAddr = "192.168.0.10:51234"
+	assert.Equal(t, "10.0.0.1", agentFromRequest(req, filter))
+
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{
+		{Subject: pkix.Name{CommonName: "agent-1"}},
+	}}}
+	assert.Equal(t, "agent-1", agentFromRequest(req, filter))
+}
+
+func noFilter() subnet.Filter {
+	return subnet.Filter{}
+}
@@ -9,7 +9,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 
-	"github.com/SpaceSlow/execenv/internal/config"
+	"github.com/SpaceSlow/execenv/internal/problems"
 )
 
 var ErrHashEmptyBody = errors.New("hash empty body error")
@@ -30,49 +30,47 @@ func getHashBody(req *http.Request, key string) (string, error) {
 	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
-// WithSigning middleware предназначенная для подписи данных и проверки подписи.
-func WithSigning(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		cfg, err := config.GetServerConfig()
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-		if headerHash := r.Header.Get("Hash"); headerHash != "none" && headerHash != "" && cfg.Key != "" {
-			var hashSum string
-			hashSum, err = getHashBody(r, cfg.Key)
-			if err != nil && !errors.Is(err, ErrHashEmptyBody) {
-				w.WriteHeader(http.StatusInternalServerError)
-				return
+// WithSigning возвращает middleware, проверяющую подпись запросов и подписывающую ответы текущим ключом key
+// (ключ может изменяться при перечитывании конфигурации). При пустом ключе подпись не проверяется.
+func WithSigning(key func() string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			signKey := key()
+			if headerHash := r.Header.Get("Hash"); headerHash != "none" && headerHash != "" && signKey != "" {
+				hashSum, err := getHashBody(r, signKey)
+				if err != nil && !errors.Is(err, ErrHashEmptyBody) {
+					problems.Internal(w, r)
+					return
+				}
+				if hashSum != headerHash {
+					problems.Write(w, r, http.StatusBadRequest, problems.CodeInvalidSignature, "request body hash does not match Hash header")
+					return
+				}
 			}
-			if hashSum != headerHash {
-				w.WriteHeader(http.StatusBadRequest)
+
+			if signKey == "" {
+				next.ServeHTTP(w, r)
 				return
 			}
-		}
-
-		if cfg.Key == "" {
-			next.ServeHTTP(w, r)
-			return
-		}
 
-		l := httptest.NewRecorder()
-		next.ServeHTTP(l, r)
+			l := httptest.NewRecorder()
+			next.ServeHTTP(l, r)
 
-		h := sha256.New()
-		body, err := io.ReadAll(l.Body)
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-		h.Write(append(body, []byte(cfg.Key)...))
-		for key, values := range l.Header() {
-			for _, value := range values {
-				w.Header().Add(key, value)
+			h := sha256.New()
+			body, err := io.ReadAll(l.Body)
+			if err != nil {
+				problems.Internal(w, r)
+				return
+			}
+			h.Write(append(body, []byte(signKey)...))
+			for key, values := range l.Header() {
+				for _, value := range values {
+					w.Header().Add(key, value)
+				}
 			}
-		}
-		w.Header().Set("Hash", hex.EncodeToString(h.Sum(nil)))
-		w.WriteHeader(l.Code)
-		w.Write(body)
-	})
+			w.Header().Set("Hash", hex.EncodeToString(h.Sum(nil)))
+			w.WriteHeader(l.Code)
+			w.Write(body)
+		})
+	}
 }
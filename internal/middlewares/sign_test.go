@@ -4,13 +4,12 @@ import (
 	"bytes"
 	"net/http"
 	"net/http/httptest"
-	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
-	"github.com/SpaceSlow/execenv/internal/config"
+	"github.com/SpaceSlow/execenv/internal/problems"
 )
 
 func TestWithSigning(t *testing.T) {
@@ -104,16 +103,14 @@ func TestWithSigning(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			os.Args = []string{"test"}
-			c, err := config.GetServerConfig()
-			require.NoError(t, err)
-			c.Key = tt.key
-
-			handler := WithSigning(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handler := WithSigning(func() string { return tt.key })(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				w.Write(tt.responseBody)
 			}))
 
-			var req *http.Request
+			var (
+				req *http.Request
+				err error
+			)
 			if tt.reqBody != nil {
 				req, err = http.NewRequest(tt.method, "https://example.com", bytes.NewReader(tt.reqBody))
 			} else {
@@ -130,6 +127,10 @@ func TestWithSigning(t *testing.T) {
 				require.Len(t, gotHeaderHash, 1)
 				assert.Equal(t, tt.respHeaderHash, gotHeaderHash[0])
 			}
+			if tt.wantStatusCode == http.StatusBadRequest {
+				assert.Equal(t, problems.ContentType, rr.Header().Get("Content-Type"))
+				assert.Contains(t, rr.Body.String(), `"code":"`+string(problems.CodeInvalidSignature)+`"`)
+			}
 		})
 	}
 }
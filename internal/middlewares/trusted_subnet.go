@@ -1,27 +1,42 @@
 package middlewares
 
 import (
+	"errors"
 	"net"
 	"net/http"
 
-	"github.com/SpaceSlow/execenv/internal/config"
+	"github.com/SpaceSlow/execenv/internal/problems"
+	"github.com/SpaceSlow/execenv/internal/subnet"
 )
 
-// WithCheckingTrustedSubnet middleware предназначена для проверки исходящих запросов из доверенной подсети.
-func WithCheckingTrustedSubnet(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		cfg, err := config.GetServerConfig()
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
+// WithCheckingTrustedSubnet возвращает middleware, пропускающую запросы только от клиентов с адресами,
+// разрешёнными текущими ограничениями filter (ограничения могут изменяться при перечитывании конфигурации).
+// Адрес клиента определяется по адресу соединения, а для запросов через доверенные прокси — по заголовкам
+// X-Forwarded-For и X-Real-IP (subnet.Filter.ClientIP).
+func WithCheckingTrustedSubnet(filter func() subnet.Filter) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			filter := filter()
+			if !filter.Enabled() {
+				next.ServeHTTP(w, r)
+				return
+			}
 
-		realIP := r.Header.Get("X-Real-IP")
-		if cfg.TrustedSubnet != config.NewCIDR("") && !cfg.TrustedSubnet.Contains(net.ParseIP(realIP)) {
-			w.WriteHeader(http.StatusForbidden)
-			return
-		}
+			if err := filter.Check(clientIP(r, filter)); err != nil {
+				detail := "client address is not in trusted subnet"
+				if errors.Is(err, subnet.ErrDenied) {
+					detail = "client address is in denied subnet"
+				}
+				problems.Write(w, r, http.StatusForbidden, problems.CodeUntrustedSubnet, detail)
+				return
+			}
 
-		next.ServeHTTP(w, r)
-	})
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientIP возвращает адрес клиента запроса с учётом доверенных прокси (subnet.Filter.ClientIP).
+func clientIP(r *http.Request, filter subnet.Filter) net.IP {
+	return filter.ClientIP(r.RemoteAddr, r.Header.Values("X-Forwarded-For"), r.Header.Get("X-Real-IP"))
 }
@@ -0,0 +1,71 @@
+package middlewares
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SpaceSlow/execenv/internal/subnet"
+)
+
+func TestWithCheckingTrustedSubnet(t *testing.T) {
+	_, trusted, _ := net.ParseCIDR("192.168.0.0/24")
+	_, proxies, _ := net.ParseCIDR("10.0.0.0/8")
+	filter := subnet.Filter{
+		Allowed:        []*net.IPNet{trusted},
+		TrustedProxies: []*net.IPNet{proxies},
+	}
+	tests := []struct {
+		name       string
+		filter     subnet.Filter
+		remoteAddr string
+		realIP     string
+		wantStatus int
+	}{
+		{
+			name:       "trusted client",
+			filter:     filter,
+			remoteAddr: "192.168.0.10:1234",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "spoofed x-real-ip",
+			filter:     filter,
+			remoteAddr: "172.16.0.1:1234",
+			realIP:     "192.168.0.10",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "trusted client through proxy",
+			filter:     filter,
+			remoteAddr: "10.0.0.1:1234",
+			realIP:     "192.168.0.10",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "disabled filter",
+			remoteAddr: "172.16.0.1:1234",
+			wantStatus: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := WithCheckingTrustedSubnet(func() subnet.Filter { return tt.filter })(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
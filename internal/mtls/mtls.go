@@ -0,0 +1,97 @@
+// Package mtls настраивает TLS для сервера и агента, в том числе взаимную аутентификацию по клиентским сертификатам.
+//
+// При проверке клиентских сертификатов агент определяется по полю Common Name субъекта сертификата
+// (AgentFromCertificates), а не по сообщаемому самим агентом заголовку X-Real-IP.
+package mtls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+var (
+	ErrNoCertificates = errors.New("no PEM certificates found")
+	ErrNoKeyPair      = errors.New("certificate and key files must be specified together")
+)
+
+// ServerConfig возвращает настройки TLS сервера с сертификатом certFile и ключом keyFile.
+// Если указан clientCAFile, сервер требует от клиентов сертификат, подписанный одним из удостоверяющих центров файла.
+func ServerConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, ErrNoKeyPair
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load server certificate: %w", err)
+	}
+
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if clientCAFile != "" {
+		cfg.ClientCAs, err = loadCertPool(clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client CA: %w", err)
+		}
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg, nil
+}
+
+// ClientConfig возвращает настройки TLS агента. Если указан caFile, сертификат сервера проверяется
+// удостоверяющими центрами файла вместо системных; если указаны certFile и keyFile, агент предъявляет
+// клиентский сертификат.
+func ClientConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	var err error
+	if caFile != "" {
+		cfg.RootCAs, err = loadCertPool(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("load CA: %w", err)
+		}
+	}
+
+	if (certFile == "") != (keyFile == "") {
+		return nil, ErrNoKeyPair
+	}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// AgentFromCertificates возвращает имя агента (Common Name субъекта) из проверенного клиентского сертификата
+// или false, если клиент не предъявил сертификат.
+func AgentFromCertificates(state *tls.ConnectionState) (string, bool) {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return "", false
+	}
+	subject := state.VerifiedChains[0][0].Subject
+	if subject.CommonName == "" {
+		return "", false
+	}
+	return subject.CommonName, true
+}
+
+func loadCertPool(file string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, ErrNoCertificates
+	}
+	return pool, nil
+}
@@ -0,0 +1,147 @@
+// Package openapi описывает HTTP API сервера метрик в формате OpenAPI 3 и проверяет запросы на соответствие описанию.
+//
+// Описание строится в коде (см. Spec), поэтому одни и те же схемы используются и в документе /openapi.json,
+// и при проверке тел запросов (см. Document.Validator).
+// Поддерживается подмножество JSON Schema, используемое в описании: type, enum, required, properties,
+// additionalProperties, items, minLength, minimum, maximum, oneOf и ссылки $ref на components/schemas.
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Version версия спецификации OpenAPI.
+const Version = "3.0.3"
+
+// Document документ OpenAPI.
+type Document struct {
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+	Info       Info                `json:"info"`
+	OpenAPI    string              `json:"openapi"`
+}
+
+// Info сведения об API.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// PathItem операции пути, ключ — HTTP-метод в нижнем регистре.
+type PathItem map[string]*Operation
+
+// Operation операция API.
+type Operation struct {
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+	OperationID string              `json:"operationId"`
+	// Scope область доступа API-токена, необходимая для операции (при включённой аутентификации)
+	Scope      string                `json:"x-required-scope,omitempty"`
+	Security   []map[string][]string `json:"security,omitempty"`
+	Summary    string                `json:"summary"`
+	Parameters []Parameter           `json:"parameters,omitempty"`
+}
+
+// Parameter параметр пути или запроса.
+type Parameter struct {
+	Schema      *Schema `json:"schema"`
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required"`
+}
+
+// RequestBody тело запроса, ключ Content — тип содержимого.
+type RequestBody struct {
+	Content  map[string]MediaType `json:"content"`
+	Required bool                 `json:"required"`
+}
+
+// Response ответ операции.
+type Response struct {
+	Content     map[string]MediaType `json:"content,omitempty"`
+	Description string               `json:"description"`
+}
+
+// MediaType описание содержимого.
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// Components переиспользуемые схемы.
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme способ аутентификации.
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	Scheme      string `json:"scheme,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Schema схема JSON-значения.
+type Schema struct {
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	MinLength            int                `json:"minLength,omitempty"`
+}
+
+// ServeHTTP отдаёт документ в формате JSON.
+func (d *Document) ServeHTTP(res http.ResponseWriter, _ *http.Request) {
+	body, err := json.Marshal(d)
+	if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusOK)
+	res.Write(body)
+}
+
+// Operation возвращает операцию и шаблон пути, соответствующие методу и пути запроса,
+// параметры пути ({name}) соответствуют одному непустому сегменту.
+func (d *Document) Operation(method, path string) (*Operation, string) {
+	method = strings.ToLower(method)
+	for template, item := range d.Paths {
+		operation, ok := item[method]
+		if ok && matchPath(template, path) {
+			return operation, template
+		}
+	}
+	return nil, ""
+}
+
+func matchPath(template, path string) bool {
+	templateParts := strings.Split(template, "/")
+	pathParts := strings.Split(path, "/")
+	if len(templateParts) != len(pathParts) {
+		return false
+	}
+	for i, part := range templateParts {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			if pathParts[i] == "" {
+				return false
+			}
+			continue
+		}
+		if part != pathParts[i] {
+			return false
+		}
+	}
+	return true
+}
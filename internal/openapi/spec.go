@@ -0,0 +1,416 @@
+package openapi
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/SpaceSlow/execenv/internal/auth"
+)
+
+const (
+	problemContentType = "application/problem+json"
+	textContentType    = "text/plain"
+	htmlContentType    = "text/html"
+)
+
+// Spec возвращает описание API, обслуживаемого routers.MetricRouter.
+func Spec() *Document {
+	doc := &Document{
+		OpenAPI: Version,
+		Info: Info{
+			Title:       "execenv metrics server",
+			Description: "Сервер сбора метрик (counter и gauge).",
+			Version:     "1.0.0",
+		},
+		Paths: map[string]PathItem{
+			"/": {
+				"get": {
+					OperationID: "listMetricsText",
+					Summary:     "Веб-панель (при Accept: text/html) или список всех метрик в текстовом виде",
+					Responses: responses(contents{
+						http.StatusOK: {
+							htmlContentType: {Schema: &Schema{Type: "string"}},
+							textContentType: {Schema: &Schema{Type: "string"}},
+						},
+					}),
+				},
+			},
+			"/dashboard/{file}": {
+				"get": {
+					OperationID: "getDashboardFile",
+					Summary:     "Файлы веб-панели",
+					Parameters:  []Parameter{pathParameter("file", &Schema{Type: "string"})},
+					Responses: responses(contents{
+						http.StatusOK:       content("application/octet-stream", &Schema{Type: "string", Format: "binary"}),
+						http.StatusNotFound: nil,
+					}),
+				},
+			},
+			"/ping": {
+				"get": {
+					OperationID: "ping",
+					Summary:     "Проверка соединения с хранилищем",
+					Responses:   responses(contents{http.StatusOK: nil, http.StatusInternalServerError: problem()}),
+				},
+			},
+			"/healthz": {
+				"get": {
+					OperationID: "healthz",
+					Summary:     "Состояние компонентов сервера",
+					Responses: responses(contents{
+						http.StatusOK:                 content(jsonContentType, ref("HealthReport")),
+						http.StatusServiceUnavailable: content(jsonContentType, ref("HealthReport")),
+					}),
+				},
+			},
+			"/readyz": {
+				"get": {
+					OperationID: "readyz",
+					Summary:     "Готовность сервера принимать запросы (не готов при неисправности компонентов и при завершении работы)",
+					Responses: responses(contents{
+						http.StatusOK:                 content(jsonContentType, ref("HealthReport")),
+						http.StatusServiceUnavailable: content(jsonContentType, ref("HealthReport")),
+					}),
+				},
+			},
+			"/openapi.json": {
+				"get": {
+					OperationID: "getOpenAPI",
+					Summary:     "Описание API в формате OpenAPI",
+					Responses:   responses(contents{http.StatusOK: content(jsonContentType, &Schema{Type: "object"})}),
+				},
+			},
+			"/update/{type}/{name}/{value}": {
+				"post": {
+					OperationID: "updateMetricByPath",
+					Summary:     "Добавление метрики, параметры передаются в пути",
+					Parameters: []Parameter{
+						pathParameter("type", ref("MetricType")),
+						pathParameter("name", &Schema{Type: "string", MinLength: 1}),
+						pathParameter("value", &Schema{Type: "string", Description: "целое число для counter, число для gauge"}),
+					},
+					Responses: responses(contents{http.StatusOK: nil, http.StatusBadRequest: problem(), http.StatusServiceUnavailable: problem()}),
+				},
+			},
+			"/update/{type}/{name}/": {
+				"post": {
+					OperationID: "updateMetricByPathWithoutValue",
+					Summary:     "Добавление метрики без значения (всегда отклоняется)",
+					Parameters: []Parameter{
+						pathParameter("type", ref("MetricType")),
+						pathParameter("name", &Schema{Type: "string", MinLength: 1}),
+					},
+					Responses: responses(contents{http.StatusBadRequest: problem()}),
+				},
+			},
+			"/update/": {
+				"post": {
+					OperationID: "updateMetric",
+					Summary:     "Добавление метрики",
+					RequestBody: jsonBody(ref("Metric")),
+					Responses: responses(contents{
+						http.StatusOK:                 content(jsonContentType, ref("Metric")),
+						http.StatusBadRequest:         problem(),
+						http.StatusServiceUnavailable: problem(),
+					}),
+				},
+			},
+			"/updates/": {
+				"post": {
+					OperationID: "updateMetrics",
+					Summary:     "Пакетное добавление метрик",
+					Parameters: []Parameter{
+						{
+							Name:        "partial",
+							In:          "query",
+							Description: "true — каждая метрика проверяется и добавляется независимо, иначе пакет применяется атомарно",
+							Schema:      &Schema{Type: "string", Enum: []string{"true", "false"}},
+						},
+					},
+					RequestBody: jsonBody(&Schema{Type: "array", Items: ref("Metric")}),
+					Responses: responses(contents{
+						http.StatusOK:                 content(jsonContentType, ref("BatchResult")),
+						http.StatusBadRequest:         problem(),
+						http.StatusServiceUnavailable: problem(),
+					}),
+				},
+			},
+			"/value/{type}/{name}": {
+				"get": {
+					OperationID: "getMetricValue",
+					Summary:     "Значение метрики",
+					Parameters: []Parameter{
+						pathParameter("type", ref("MetricType")),
+						pathParameter("name", &Schema{Type: "string", MinLength: 1}),
+					},
+					Responses: responses(contents{
+						http.StatusOK:         content(textContentType, &Schema{Type: "string"}),
+						http.StatusBadRequest: problem(),
+						http.StatusNotFound:   problem(),
+					}),
+				},
+			},
+			"/value/": {
+				"post": {
+					OperationID: "getMetric",
+					Summary:     "Метрика по имени и типу",
+					RequestBody: jsonBody(ref("MetricID")),
+					Responses: responses(contents{
+						http.StatusOK:         content(jsonContentType, ref("Metric")),
+						http.StatusBadRequest: problem(),
+						http.StatusNotFound:   problem(),
+					}),
+				},
+			},
+			"/api/v2/metrics": {
+				"get": {
+					OperationID: "listMetrics",
+					Summary:     "Постраничный список метрик с фильтрами",
+					Parameters: []Parameter{
+						queryParameter("type", ref("MetricType")),
+						queryParameter("prefix", &Schema{Type: "string"}),
+						queryParameter("regex", &Schema{Type: "string", Format: "regex"}),
+						queryParameter("sort", &Schema{Type: "string", Enum: []string{"name", "-name", "type", "-type"}}),
+						queryParameter("limit", &Schema{Type: "integer", Minimum: float(1), Maximum: float(1000)}),
+						queryParameter("cursor", &Schema{Type: "string"}),
+					},
+					Responses: responses(contents{
+						http.StatusOK:          content(jsonContentType, ref("MetricsPage")),
+						http.StatusNotModified: nil,
+						http.StatusBadRequest:  problem(),
+					}),
+				},
+			},
+			"/v1/metrics": {
+				"post": {
+					OperationID: "exportOTLPMetrics",
+					Summary:     "Приём метрик OTLP/HTTP",
+					RequestBody: &RequestBody{
+						Required: true,
+						Content: map[string]MediaType{
+							"application/x-protobuf": {},
+							jsonContentType:          {},
+						},
+					},
+					Responses: responses(contents{
+						http.StatusOK:                   nil,
+						http.StatusBadRequest:           problem(),
+						http.StatusUnsupportedMediaType: problem(),
+						http.StatusServiceUnavailable:   problem(),
+					}),
+				},
+			},
+			"/api/v1/write": {
+				"post": {
+					OperationID: "prometheusRemoteWrite",
+					Summary:     "Приём метрик Prometheus remote write",
+					RequestBody: &RequestBody{
+						Required: true,
+						Content:  map[string]MediaType{"application/x-protobuf": {}},
+					},
+					Responses: responses(contents{http.StatusNoContent: nil, http.StatusBadRequest: problem(), http.StatusServiceUnavailable: problem()}),
+				},
+			},
+			"/api/v2/write": {
+				"post": {
+					OperationID: "influxWrite",
+					Summary:     "Приём метрик в формате InfluxDB line protocol",
+					Parameters: []Parameter{
+						queryParameter("precision", &Schema{Type: "string", Enum: []string{"ns", "n", "us", "u", "ms", "s"}}),
+					},
+					RequestBody: &RequestBody{
+						Required: true,
+						Content:  map[string]MediaType{textContentType: {Schema: &Schema{Type: "string"}}},
+					},
+					Responses: responses(contents{http.StatusNoContent: nil, http.StatusBadRequest: problem(), http.StatusServiceUnavailable: problem()}),
+				},
+			},
+		},
+		Components: Components{Schemas: schemas()},
+	}
+	addLimitResponses(doc)
+	addSecurity(doc)
+	return doc
+}
+
+// bearerScheme имя способа аутентификации по API-токенам.
+const bearerScheme = "bearerToken"
+
+// operationScopes области доступа API-токенов, необходимые для операций (см. routers.WithTokens).
+// Операции, отсутствующие в списке, доступны без токена.
+var operationScopes = map[string]auth.Scope{
+	"listMetricsText":                auth.ScopeRead,
+	"updateMetricByPath":             auth.ScopeWrite,
+	"updateMetricByPathWithoutValue": auth.ScopeWrite,
+	"updateMetric":                   auth.ScopeWrite,
+	"updateMetrics":                  auth.ScopeWrite,
+	"getMetricValue":                 auth.ScopeRead,
+	"getMetric":                      auth.ScopeRead,
+	"listMetrics":                    auth.ScopeRead,
+	"exportOTLPMetrics":              auth.ScopeWrite,
+	"prometheusRemoteWrite":          auth.ScopeWrite,
+	"influxWrite":                    auth.ScopeWrite,
+}
+
+// addSecurity добавляет операциям, требующим API-токен, требование аутентификации и ответы 401 и 403.
+func addSecurity(doc *Document) {
+	doc.Components.SecuritySchemes = map[string]SecurityScheme{
+		bearerScheme: {
+			Type:        "http",
+			Scheme:      "bearer",
+			Description: "API-токен; требуется, только если на сервере настроены токены. Область доступа операции указана в x-required-scope, токену admin доступны все операции.",
+		},
+	}
+	for _, item := range doc.Paths {
+		for _, operation := range item {
+			scope, ok := operationScopes[operation.OperationID]
+			if !ok {
+				continue
+			}
+			operation.Scope = string(scope)
+			operation.Security = []map[string][]string{{bearerScheme: {}}}
+			operation.Responses[strconv.Itoa(http.StatusUnauthorized)] = Response{Description: http.StatusText(http.StatusUnauthorized), Content: problem()}
+			operation.Responses[strconv.Itoa(http.StatusForbidden)] = Response{Description: http.StatusText(http.StatusForbidden), Content: problem()}
+		}
+	}
+}
+
+// addLimitResponses добавляет ко всем операциям ответы об ограничениях агентов (ratelimit):
+// 429 при превышении частоты запросов или квоты метрик и 413 для запросов с телом при превышении размера.
+func addLimitResponses(doc *Document) {
+	for _, item := range doc.Paths {
+		for method, operation := range item {
+			operation.Responses[strconv.Itoa(http.StatusTooManyRequests)] = Response{Description: http.StatusText(http.StatusTooManyRequests), Content: problem()}
+			if method == "post" {
+				operation.Responses[strconv.Itoa(http.StatusRequestEntityTooLarge)] = Response{Description: http.StatusText(http.StatusRequestEntityTooLarge), Content: problem()}
+			}
+		}
+	}
+}
+
+func schemas() map[string]*Schema {
+	name := &Schema{Type: "string", MinLength: 1}
+	return map[string]*Schema{
+		"MetricType": {Type: "string", Enum: []string{"counter", "gauge"}},
+		"Metric": {
+			OneOf: []*Schema{ref("Counter"), ref("Gauge")},
+		},
+		"Counter": {
+			Type:     "object",
+			Required: []string{"id", "type", "delta"},
+			Properties: map[string]*Schema{
+				"id":    name,
+				"type":  {Type: "string", Enum: []string{"counter"}},
+				"delta": {Type: "integer", Format: "int64"},
+			},
+			AdditionalProperties: boolean(false),
+		},
+		"Gauge": {
+			Type:     "object",
+			Required: []string{"id", "type", "value"},
+			Properties: map[string]*Schema{
+				"id":    name,
+				"type":  {Type: "string", Enum: []string{"gauge"}},
+				"value": {Type: "number", Format: "double"},
+			},
+			AdditionalProperties: boolean(false),
+		},
+		"MetricID": {
+			Type:     "object",
+			Required: []string{"id", "type"},
+			Properties: map[string]*Schema{
+				"id":   name,
+				"type": ref("MetricType"),
+			},
+		},
+		"BatchResult": {
+			Type:     "object",
+			Required: []string{"accepted"},
+			Properties: map[string]*Schema{
+				"accepted": {Type: "integer"},
+				"errors": {Type: "array", Items: &Schema{
+					Type:     "object",
+					Required: []string{"index", "error", "code"},
+					Properties: map[string]*Schema{
+						"index": {Type: "integer"},
+						"error": {Type: "string"},
+						"code":  {Type: "string"},
+					},
+				}},
+			},
+		},
+		"MetricsPage": {
+			Type:     "object",
+			Required: []string{"metrics"},
+			Properties: map[string]*Schema{
+				"next_cursor": {Type: "string"},
+				"metrics":     {Type: "array", Items: ref("Metric")},
+			},
+		},
+		"HealthReport": {
+			Type:     "object",
+			Required: []string{"status", "components"},
+			Properties: map[string]*Schema{
+				"status":     ref("HealthStatus"),
+				"error":      {Type: "string"},
+				"components": {Type: "object", Description: "состояние компонентов по имени: {\"status\": \"up|down\", \"error\": \"...\"}"},
+			},
+		},
+		"HealthStatus": {Type: "string", Enum: []string{"up", "down"}},
+		"Problem": {
+			Type:     "object",
+			Required: []string{"type", "title", "status", "code"},
+			Properties: map[string]*Schema{
+				"type":     {Type: "string"},
+				"title":    {Type: "string"},
+				"status":   {Type: "integer"},
+				"detail":   {Type: "string"},
+				"instance": {Type: "string"},
+				"code":     {Type: "string", Description: "стабильный код ошибки"},
+			},
+		},
+	}
+}
+
+func ref(name string) *Schema {
+	return &Schema{Ref: refPrefix + name}
+}
+
+func boolean(b bool) *bool {
+	return &b
+}
+
+func float(f float64) *float64 {
+	return &f
+}
+
+func pathParameter(name string, schema *Schema) Parameter {
+	return Parameter{Name: name, In: "path", Required: true, Schema: schema}
+}
+
+func queryParameter(name string, schema *Schema) Parameter {
+	return Parameter{Name: name, In: "query", Schema: schema}
+}
+
+func jsonBody(schema *Schema) *RequestBody {
+	return &RequestBody{Required: true, Content: content(jsonContentType, schema)}
+}
+
+func content(contentType string, schema *Schema) map[string]MediaType {
+	return map[string]MediaType{contentType: {Schema: schema}}
+}
+
+func problem() map[string]MediaType {
+	return content(problemContentType, ref("Problem"))
+}
+
+// contents содержимое ответов по HTTP-статусу, nil — ответ без тела.
+type contents map[int]map[string]MediaType
+
+func responses(c contents) map[string]Response {
+	result := make(map[string]Response, len(c))
+	for status, mediaTypes := range c {
+		result[strconv.Itoa(status)] = Response{Description: http.StatusText(status), Content: mediaTypes}
+	}
+	return result
+}
@@ -0,0 +1,234 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/SpaceSlow/execenv/internal/problems"
+)
+
+// ErrValidation возвращается, если значение не соответствует схеме.
+var ErrValidation = errors.New("request does not match OpenAPI specification")
+
+const jsonContentType = "application/json"
+
+// Validator возвращает middleware, проверяющую JSON-тела запросов на соответствие документу.
+// Запросы с несоответствующим телом отклоняются с ответом 400 validation_failed.
+// Запросы к операциям, отсутствующим в документе или не описывающим JSON-тело, передаются дальше без проверки.
+func (d *Document) Validator(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		operation, _ := d.Operation(r.Method, r.URL.Path)
+		if operation == nil || operation.RequestBody == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		mediaType, ok := operation.RequestBody.Content[jsonContentType]
+		if !ok || mediaType.Schema == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); contentType != "" && contentType != jsonContentType {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var body []byte
+		if r.Body != nil {
+			var err error
+			if body, err = io.ReadAll(r.Body); err != nil {
+				problems.Write(w, r, http.StatusBadRequest, problems.CodeBadRequest, err.Error())
+				return
+			}
+			r.Body.Close()
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		if err := d.validateBody(operation.RequestBody, body); err != nil {
+			problems.Write(w, r, http.StatusBadRequest, problems.CodeValidationFailed, err.Error())
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (d *Document) validateBody(requestBody *RequestBody, body []byte) error {
+	if len(bytes.TrimSpace(body)) == 0 {
+		if requestBody.Required {
+			return fmt.Errorf("%w: request body is required", ErrValidation)
+		}
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	return d.Validate(requestBody.Content[jsonContentType].Schema, value)
+}
+
+// Validate проверяет значение, полученное json.Decoder (в том числе с UseNumber), на соответствие схеме.
+func (d *Document) Validate(schema *Schema, value any) error {
+	if err := d.validate(schema, value, "body"); err != nil {
+		return fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	return nil
+}
+
+func (d *Document) validate(schema *Schema, value any, path string) error {
+	schema, err := d.resolve(schema)
+	if err != nil {
+		return err
+	}
+
+	if len(schema.OneOf) > 0 {
+		return d.validateOneOf(schema.OneOf, value, path)
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: must be an object", path)
+		}
+		return d.validateObject(schema, object, path)
+	case "array":
+		array, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("%s: must be an array", path)
+		}
+		for i, item := range array {
+			if err = d.validate(schema.Items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s: must be a string", path)
+		}
+		if len([]rune(s)) < schema.MinLength {
+			return fmt.Errorf("%s: length must be at least %d", path, schema.MinLength)
+		}
+		if len(schema.Enum) > 0 && !slices.Contains(schema.Enum, s) {
+			return fmt.Errorf("%s: must be one of: %s", path, strings.Join(schema.Enum, ", "))
+		}
+		return nil
+	case "integer", "number":
+		return validateNumber(schema, value, path)
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: must be a boolean", path)
+		}
+		return nil
+	default:
+		return nil
+	}
+}
+
+func (d *Document) validateObject(schema *Schema, object map[string]any, path string) error {
+	for _, name := range schema.Required {
+		if _, ok := object[name]; !ok {
+			return fmt.Errorf("%s.%s: is required", path, name)
+		}
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		property, ok := schema.Properties[name]
+		if !ok {
+			if schema.AdditionalProperties != nil && !*schema.AdditionalProperties {
+				return fmt.Errorf("%s.%s: unknown property", path, name)
+			}
+			continue
+		}
+		if err := d.validate(property, object[name], path+"."+name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Document) validateOneOf(schemas []*Schema, value any, path string) error {
+	var (
+		matched int
+		errs    []string
+	)
+	for _, schema := range schemas {
+		if err := d.validate(schema, value, path); err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		matched++
+	}
+	switch matched {
+	case 1:
+		return nil
+	case 0:
+		return fmt.Errorf("%s: must match exactly one schema (%s)", path, strings.Join(errs, "; "))
+	default:
+		return fmt.Errorf("%s: matches more than one schema", path)
+	}
+}
+
+func validateNumber(schema *Schema, value any, path string) error {
+	var n float64
+	switch v := value.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return fmt.Errorf("%s: must be a number", path)
+		}
+		if schema.Type == "integer" {
+			if _, err = v.Int64(); err != nil {
+				return fmt.Errorf("%s: must be an integer", path)
+			}
+		}
+		n = f
+	case float64:
+		if schema.Type == "integer" && v != math.Trunc(v) {
+			return fmt.Errorf("%s: must be an integer", path)
+		}
+		n = v
+	default:
+		return fmt.Errorf("%s: must be a number", path)
+	}
+
+	if schema.Minimum != nil && n < *schema.Minimum {
+		return fmt.Errorf("%s: must be at least %v", path, *schema.Minimum)
+	}
+	if schema.Maximum != nil && n > *schema.Maximum {
+		return fmt.Errorf("%s: must be at most %v", path, *schema.Maximum)
+	}
+	return nil
+}
+
+const refPrefix = "#/components/schemas/"
+
+func (d *Document) resolve(schema *Schema) (*Schema, error) {
+	for schema.Ref != "" {
+		name, ok := strings.CutPrefix(schema.Ref, refPrefix)
+		if !ok {
+			return nil, fmt.Errorf("unsupported reference %s", schema.Ref)
+		}
+		if schema, ok = d.Components.Schemas[name]; !ok {
+			return nil, fmt.Errorf("unknown schema %s", name)
+		}
+	}
+	return schema, nil
+}
@@ -0,0 +1,88 @@
+package openapi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocument_Operation(t *testing.T) {
+	doc := Spec()
+	tests := []struct {
+		method       string
+		path         string
+		wantTemplate string
+	}{
+		{method: http.MethodPost, path: "/update/gauge/RandomValue/1.5", wantTemplate: "/update/{type}/{name}/{value}"},
+		{method: http.MethodPost, path: "/update/gauge/RandomValue/", wantTemplate: "/update/{type}/{name}/"},
+		{method: http.MethodPost, path: "/update/", wantTemplate: "/update/"},
+		{method: http.MethodGet, path: "/value/counter/PollCount", wantTemplate: "/value/{type}/{name}"},
+		{method: http.MethodGet, path: "/update/", wantTemplate: ""},
+		{method: http.MethodPost, path: "/unknown", wantTemplate: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			operation, template := doc.Operation(tt.method, tt.path)
+			assert.Equal(t, tt.wantTemplate, template)
+			assert.Equal(t, tt.wantTemplate != "", operation != nil)
+		})
+	}
+}
+
+func TestDocument_Validator(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		contentType string
+		body        string
+		wantErr     string
+	}{
+		{name: "counter", path: "/update/", body: `{"id": "PollCount", "type": "counter", "delta": 5}`},
+		{name: "gauge", path: "/update/", body: `{"id": "RandomValue", "type": "gauge", "value": 1.5}`},
+		{name: "batch", path: "/updates/", body: `[{"id": "PollCount", "type": "counter", "delta": 5}, {"id": "RandomValue", "type": "gauge", "value": 1}]`},
+		{name: "metric id", path: "/value/", body: `{"id": "PollCount", "type": "counter"}`},
+		{name: "not described operation", path: "/unknown", body: `{`},
+		{name: "not json body", path: "/update/", contentType: "text/plain", body: `{`},
+		{name: "empty body", path: "/update/", wantErr: "request body is required"},
+		{name: "malformed json", path: "/update/", body: `{"id": `, wantErr: "unexpected EOF"},
+		{name: "counter without delta", path: "/update/", body: `{"id": "PollCount", "type": "counter", "value": 5}`, wantErr: "body.delta: is required"},
+		{name: "float delta", path: "/update/", body: `{"id": "PollCount", "type": "counter", "delta": 1.5}`, wantErr: "body.delta: must be an integer"},
+		{name: "unknown type", path: "/update/", body: `{"id": "PollCount", "type": "histogram", "value": 5}`, wantErr: "body.type: must be one of: gauge"},
+		{name: "empty name", path: "/update/", body: `{"id": "", "type": "gauge", "value": 5}`, wantErr: "body.id: length must be at least 1"},
+		{name: "unknown property", path: "/update/", body: `{"id": "RandomValue", "type": "gauge", "value": 5, "delta": 1}`, wantErr: "body.delta: unknown property"},
+		{name: "batch with incorrect metric", path: "/updates/", body: `[{"id": "PollCount", "type": "counter", "delta": 5}, {"id": "RandomValue"}]`, wantErr: "body[1].type: is required"},
+		{name: "batch is not array", path: "/updates/", body: `{"id": "PollCount", "type": "counter", "delta": 5}`, wantErr: "body: must be an array"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotBody string
+			handler := Spec().Validator(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body := new(strings.Builder)
+				_, err := io.Copy(body, r.Body)
+				require.NoError(t, err)
+				gotBody = body.String()
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+
+			if tt.wantErr == "" {
+				assert.Equal(t, http.StatusOK, res.Code)
+				assert.Equal(t, tt.body, gotBody)
+				return
+			}
+			assert.Equal(t, http.StatusBadRequest, res.Code)
+			assert.Contains(t, res.Body.String(), `"code":"validation_failed"`)
+			assert.Contains(t, res.Body.String(), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,314 @@
+// Package otlp реализует приём метрик OpenTelemetry (OTLP) и их преобразование в metrics.Metric.
+//
+// Преобразование точек данных:
+//   - Gauge — gauge;
+//   - монотонный Sum с delta-темпоральностью — counter со значением точки;
+//   - монотонный Sum с cumulative-темпоральностью — counter с приращением относительно предыдущей точки того же ряда
+//     (для первой точки ряда и после сброса счётчика приращением считается само значение);
+//   - немонотонный Sum с delta-темпоральностью — counter (приращение может быть отрицательным);
+//   - немонотонный Sum с cumulative-темпоральностью — gauge.
+//
+// Дробные значения не теряются при округлении counter: приращение cumulative-ряда вычисляется по округлённым
+// значениям, а неучтённая дробная часть delta-ряда добавляется к его следующей точке. Состояние ряда без точек
+// в течение seriesIdleTimeout удаляется, и следующая точка ряда считается первой.
+//
+// Точки Histogram, ExponentialHistogram и Summary, а также точки с именем метрики длиннее metrics.MaxNameLength
+// или с префиксом внутренних метрик сервера (selfmetrics.Namespace) не сохраняются и учитываются как отклонённые.
+// Имя метрики — имя метрики OTLP, при наличии атрибутов точки к нему добавляются атрибуты в виде {key=value,...}.
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	collectorpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+
+	"github.com/SpaceSlow/execenv/internal/metrics"
+	"github.com/SpaceSlow/execenv/internal/selfmetrics"
+	"github.com/SpaceSlow/execenv/internal/storages"
+)
+
+// seriesIdleTimeout время без точек, после которого состояние ряда удаляется.
+const seriesIdleTimeout = 10 * time.Minute
+
+// sumSeries состояние ряда Sum, сохраняемого как counter.
+type sumSeries struct {
+	updated time.Time
+	// startTime и value последняя точка cumulative-ряда
+	startTime uint64
+	value     float64
+	// remainder дробная часть delta-ряда, не записанная в хранилище
+	remainder float64
+}
+
+// seriesUpdate изменение состояния ряда запросом: prev — состояние до запроса (nil для нового ряда), next — после.
+type seriesUpdate struct {
+	prev *sumSeries
+	next *sumSeries
+}
+
+// Receiver сохраняет метрики OTLP в хранилище.
+type Receiver struct {
+	storage storages.MetricStorage
+	now     func() time.Time
+	// series состояние рядов, ключ — ресурс, scope, имя метрики и атрибуты точки
+	series    map[string]*sumSeries
+	lastSweep time.Time
+	mu        sync.Mutex
+}
+
+func NewReceiver(storage storages.MetricStorage) *Receiver {
+	return &Receiver{
+		storage: storage,
+		now:     time.Now,
+		series:  make(map[string]*sumSeries),
+	}
+}
+
+type timedMetric struct {
+	metric metrics.Metric
+	time   uint64
+}
+
+// Export сохраняет метрики запроса одним Batch и возвращает количество отклонённых точек данных.
+// Приращения вычисляются под блокировкой, а запись в хранилище выполняется без неё. При ошибке записи
+// восстанавливается состояние рядов, не изменённых с тех пор другими запросами.
+func (r *Receiver) Export(ctx context.Context, req *collectorpb.ExportMetricsServiceRequest) (int64, error) {
+	rejected, points, updates := r.convert(req)
+	if len(points) == 0 {
+		return rejected, nil
+	}
+
+	// для gauge сохраняется значение с наибольшей временной меткой
+	slices.SortStableFunc(points, func(a, b timedMetric) int {
+		switch {
+		case a.time < b.time:
+			return -1
+		case a.time > b.time:
+			return 1
+		default:
+			return 0
+		}
+	})
+	metricSlice := make([]metrics.Metric, 0, len(points))
+	for _, p := range points {
+		metricSlice = append(metricSlice, p.metric)
+	}
+	if err := r.storage.Batch(ctx, metricSlice); err != nil {
+		r.restore(updates)
+		return rejected, err
+	}
+	return rejected, nil
+}
+
+// convert преобразует точки запроса в метрики и обновляет состояние рядов.
+// Возвращает количество отклонённых точек, метрики и изменения состояния рядов.
+func (r *Receiver) convert(req *collectorpb.ExportMetricsServiceRequest) (int64, []timedMetric, map[string]seriesUpdate) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	r.sweep(now)
+
+	var (
+		rejected int64
+		points   []timedMetric
+		updates  = make(map[string]seriesUpdate)
+	)
+
+	for _, resourceMetrics := range req.GetResourceMetrics() {
+		resource := attributesKey(resourceMetrics.GetResource().GetAttributes())
+		for _, scopeMetrics := range resourceMetrics.GetScopeMetrics() {
+			scope := scopeMetrics.GetScope().GetName() + "@" + scopeMetrics.GetScope().GetVersion()
+			for _, metric := range scopeMetrics.GetMetrics() {
+				switch data := metric.GetData().(type) {
+				case *metricspb.Metric_Gauge:
+					for _, dp := range data.Gauge.GetDataPoints() {
+						name := metricName(metric.GetName(), dp.GetAttributes())
+						if !acceptedName(name) {
+							rejected++
+							continue
+						}
+						points = append(points, timedMetric{metric: metrics.NewGauge(name, numberValue(dp)), time: dp.GetTimeUnixNano()})
+					}
+				case *metricspb.Metric_Sum:
+					cumulative := data.Sum.GetAggregationTemporality() == metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE
+					for _, dp := range data.Sum.GetDataPoints() {
+						name := metricName(metric.GetName(), dp.GetAttributes())
+						if !acceptedName(name) {
+							rejected++
+							continue
+						}
+						value := numberValue(dp)
+						if cumulative && !data.Sum.GetIsMonotonic() {
+							points = append(points, timedMetric{metric: metrics.NewGauge(name, value), time: dp.GetTimeUnixNano()})
+							continue
+						}
+
+						series := r.update(updates, resource+"|"+scope+"|"+name, now)
+						var delta int64
+						if cumulative {
+							delta = series.cumulativeDelta(dp.GetStartTimeUnixNano(), value)
+						} else {
+							delta = series.delta(value)
+						}
+						points = append(points, timedMetric{metric: metrics.NewCounter(name, delta), time: dp.GetTimeUnixNano()})
+					}
+				default:
+					rejected += int64(dataPointCount(metric))
+				}
+			}
+		}
+	}
+	return rejected, points, updates
+}
+
+// update возвращает состояние ряда key, изменяемое запросом. При первом обращении в запросе
+// состояние копируется, чтобы его можно было восстановить (restore). Требует блокировки r.mu.
+func (r *Receiver) update(updates map[string]seriesUpdate, key string, now time.Time) *sumSeries {
+	if u, ok := updates[key]; ok {
+		return u.next
+	}
+	prev := r.series[key]
+	next := &sumSeries{}
+	if prev != nil {
+		*next = *prev
+	}
+	next.updated = now
+	r.series[key] = next
+	updates[key] = seriesUpdate{prev: prev, next: next}
+	return next
+}
+
+// restore восстанавливает состояние рядов, изменённых запросом, если другие запросы их с тех пор не изменяли.
+func (r *Receiver) restore(updates map[string]seriesUpdate) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, u := range updates {
+		switch {
+		case r.series[key] != u.next:
+		case u.prev == nil:
+			delete(r.series, key)
+		default:
+			r.series[key] = u.prev
+		}
+	}
+}
+
+// sweep удаляет состояние рядов без точек в течение seriesIdleTimeout. Требует блокировки r.mu.
+func (r *Receiver) sweep(now time.Time) {
+	if now.Sub(r.lastSweep) <= seriesIdleTimeout {
+		return
+	}
+	r.lastSweep = now
+	for key, series := range r.series {
+		if now.Sub(series.updated) > seriesIdleTimeout {
+			delete(r.series, key)
+		}
+	}
+}
+
+// cumulativeDelta возвращает приращение cumulative-ряда и запоминает точку. Приращение вычисляется
+// по округлённым значениям, поэтому сумма приращений равна округлённому последнему значению ряда.
+func (s *sumSeries) cumulativeDelta(startTime uint64, value float64) int64 {
+	prev := s.value
+	if s.startTime != startTime || value < prev {
+		prev = 0
+	}
+	s.startTime, s.value = startTime, value
+	return int64(math.Round(value) - math.Round(prev))
+}
+
+// delta возвращает округлённое значение точки delta-ряда с учётом остатка предыдущих точек и запоминает новый остаток.
+func (s *sumSeries) delta(value float64) int64 {
+	value += s.remainder
+	rounded := math.Round(value)
+	s.remainder = value - rounded
+	return int64(rounded)
+}
+
+func numberValue(dp *metricspb.NumberDataPoint) float64 {
+	switch value := dp.GetValue().(type) {
+	case *metricspb.NumberDataPoint_AsInt:
+		return float64(value.AsInt)
+	case *metricspb.NumberDataPoint_AsDouble:
+		return value.AsDouble
+	default:
+		return 0
+	}
+}
+
+func dataPointCount(metric *metricspb.Metric) int {
+	switch data := metric.GetData().(type) {
+	case *metricspb.Metric_Histogram:
+		return len(data.Histogram.GetDataPoints())
+	case *metricspb.Metric_ExponentialHistogram:
+		return len(data.ExponentialHistogram.GetDataPoints())
+	case *metricspb.Metric_Summary:
+		return len(data.Summary.GetDataPoints())
+	default:
+		return 0
+	}
+}
+
+func metricName(name string, attributes []*commonpb.KeyValue) string {
+	if len(attributes) == 0 {
+		return name
+	}
+	return name + "{" + attributesKey(attributes) + "}"
+}
+
+// attributesKey возвращает атрибуты в виде key=value,... в порядке возрастания ключей.
+func attributesKey(attributes []*commonpb.KeyValue) string {
+	pairs := make([]string, 0, len(attributes))
+	for _, attribute := range attributes {
+		pairs = append(pairs, attribute.GetKey()+"="+anyValueString(attribute.GetValue()))
+	}
+	slices.SortFunc(pairs, func(a, b string) int {
+		keyA, _, _ := strings.Cut(a, "=")
+		keyB, _, _ := strings.Cut(b, "=")
+		return strings.Compare(keyA, keyB)
+	})
+	return strings.Join(pairs, ",")
+}
+
+func anyValueString(value *commonpb.AnyValue) string {
+	switch v := value.GetValue().(type) {
+	case *commonpb.AnyValue_StringValue:
+		return v.StringValue
+	case *commonpb.AnyValue_BoolValue:
+		return fmt.Sprint(v.BoolValue)
+	case *commonpb.AnyValue_IntValue:
+		return fmt.Sprint(v.IntValue)
+	case *commonpb.AnyValue_DoubleValue:
+		return fmt.Sprint(v.DoubleValue)
+	default:
+		return value.String()
+	}
+}
+
+// acceptedName сообщает, может ли точка с именем метрики name быть сохранена.
+func acceptedName(name string) bool {
+	return len(name) <= metrics.MaxNameLength && !selfmetrics.IsReserved(name)
+}
+
+// ExportResponse возвращает ответ на запрос экспорта, при наличии отклонённых точек заполняется PartialSuccess.
+func ExportResponse(rejected int64) *collectorpb.ExportMetricsServiceResponse {
+	response := &collectorpb.ExportMetricsServiceResponse{}
+	if rejected > 0 {
+		response.PartialSuccess = &collectorpb.ExportMetricsPartialSuccess{
+			RejectedDataPoints: rejected,
+			ErrorMessage: fmt.Sprintf("histogram, exponential histogram and summary data points, metric names longer than %d bytes "+
+				"and metric names with prefix %q are not supported", metrics.MaxNameLength, selfmetrics.Namespace),
+		}
+	}
+	return response
+}
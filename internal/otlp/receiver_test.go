@@ -0,0 +1,244 @@
+package otlp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	collectorpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+
+	"github.com/SpaceSlow/execenv/internal/metrics"
+	"github.com/SpaceSlow/execenv/internal/storages"
+)
+
+func attribute(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}}}
+}
+
+func intPoint(startTime, time uint64, value int64, attributes ...*commonpb.KeyValue) *metricspb.NumberDataPoint {
+	return &metricspb.NumberDataPoint{
+		StartTimeUnixNano: startTime,
+		TimeUnixNano:      time,
+		Value:             &metricspb.NumberDataPoint_AsInt{AsInt: value},
+		Attributes:        attributes,
+	}
+}
+
+func doublePoint(time uint64, value float64, attributes ...*commonpb.KeyValue) *metricspb.NumberDataPoint {
+	return &metricspb.NumberDataPoint{
+		TimeUnixNano: time,
+		Value:        &metricspb.NumberDataPoint_AsDouble{AsDouble: value},
+		Attributes:   attributes,
+	}
+}
+
+func sum(name string, monotonic bool, temporality metricspb.AggregationTemporality, points ...*metricspb.NumberDataPoint) *metricspb.Metric {
+	return &metricspb.Metric{
+		Name: name,
+		Data: &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+			DataPoints:             points,
+			AggregationTemporality: temporality,
+			IsMonotonic:            monotonic,
+		}},
+	}
+}
+
+func gauge(name string, points ...*metricspb.NumberDataPoint) *metricspb.Metric {
+	return &metricspb.Metric{Name: name, Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{DataPoints: points}}}
+}
+
+func request(service string, metricSlice ...*metricspb.Metric) *collectorpb.ExportMetricsServiceRequest {
+	return &collectorpb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*metricspb.ResourceMetrics{{
+			Resource:     &resourcepb.Resource{Attributes: []*commonpb.KeyValue{attribute("service.name", service)}},
+			ScopeMetrics: []*metricspb.ScopeMetrics{{Metrics: metricSlice}},
+		}},
+	}
+}
+
+const (
+	cumulative = metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE
+	delta      = metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA
+)
+
+func TestReceiver_Export(t *testing.T) {
+	ctx := context.Background()
+	storage := storages.NewMemStorage()
+	receiver := NewReceiver(storage)
+
+	requests := []*collectorpb.ExportMetricsServiceRequest{
+		request("api",
+			sum("http.requests", true, cumulative, intPoint(1, 10, 5, attribute("code", "200"))),
+			sum("http.errors", true, delta, intPoint(1, 10, 2)),
+			sum("queue.size", false, cumulative, intPoint(1, 10, 7)),
+			sum("connections", false, delta, intPoint(1, 10, -1)),
+			gauge("cpu.usage", doublePoint(20, 0.7), doublePoint(10, 0.5)),
+			&metricspb.Metric{Name: "latency", Data: &metricspb.Metric_Histogram{Histogram: &metricspb.Histogram{
+				DataPoints: []*metricspb.HistogramDataPoint{{}, {}},
+			}}},
+		),
+		// второй экземпляр сервиса с тем же рядом не должен влиять на приращения первого
+		request("worker", sum("http.requests", true, cumulative, intPoint(1, 10, 100, attribute("code", "200")))),
+		// точки со слишком длинным именем и с префиксом внутренних метрик отклоняются
+		request("api",
+			gauge("cpu.usage", doublePoint(10, 0.9, attribute("host", strings.Repeat("a", metrics.MaxNameLength)))),
+			sum("http.requests", true, cumulative, intPoint(1, 10, 5, attribute("path", strings.Repeat("a", metrics.MaxNameLength)))),
+			gauge("_srv.http.dur.max", doublePoint(10, 0.9)),
+		),
+		request("api",
+			sum("http.requests", true, cumulative, intPoint(1, 20, 8, attribute("code", "200")), intPoint(1, 30, 12, attribute("code", "200"))),
+			sum("http.errors", true, delta, intPoint(10, 20, 1)),
+		),
+		// сброс счётчика: новое время начала
+		request("api", sum("http.requests", true, cumulative, intPoint(40, 50, 3, attribute("code", "200")))),
+	}
+	wantRejected := []int64{2, 0, 3, 0, 0}
+
+	for i, req := range requests {
+		rejected, err := receiver.Export(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, wantRejected[i], rejected, "request #%d", i)
+	}
+
+	list, err := storage.List(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []metrics.Metric{
+		metrics.NewCounter("http.requests{code=200}", 5+100+7+3),
+		metrics.NewCounter("http.errors", 3),
+		metrics.NewGauge("queue.size", 7),
+		metrics.NewCounter("connections", -1),
+		metrics.NewGauge("cpu.usage", 0.7),
+	}, list)
+}
+
+type failingStorage struct {
+	storages.MetricStorage
+}
+
+func (s failingStorage) Batch(_ context.Context, _ []metrics.Metric) error {
+	return errors.New("storage is unavailable")
+}
+
+func TestReceiver_ExportFailedBatch(t *testing.T) {
+	ctx := context.Background()
+	storage := storages.NewMemStorage()
+	receiver := NewReceiver(storage)
+
+	_, err := receiver.Export(ctx, request("api", sum("http.requests", true, cumulative, intPoint(1, 10, 5))))
+	require.NoError(t, err)
+
+	receiver.storage = failingStorage{storage}
+	_, err = receiver.Export(ctx, request("api", sum("http.requests", true, cumulative, intPoint(1, 20, 8))))
+	require.Error(t, err)
+
+	// после неудачной записи приращение считается от последней сохранённой точки
+	receiver.storage = storage
+	_, err = receiver.Export(ctx, request("api", sum("http.requests", true, cumulative, intPoint(1, 30, 9))))
+	require.NoError(t, err)
+
+	metric, err := storage.Get(ctx, metrics.Counter, "http.requests")
+	require.NoError(t, err)
+	assert.Equal(t, int64(9), metric.Delta)
+}
+
+func TestReceiver_ExportFractional(t *testing.T) {
+	ctx := context.Background()
+	storage := storages.NewMemStorage()
+	receiver := NewReceiver(storage)
+
+	for i := 1; i <= 5; i++ {
+		_, err := receiver.Export(ctx, request("api",
+			sum("cpu.time", true, cumulative, &metricspb.NumberDataPoint{
+				StartTimeUnixNano: 1,
+				TimeUnixNano:      uint64(i),
+				Value:             &metricspb.NumberDataPoint_AsDouble{AsDouble: 0.4 * float64(i)},
+			}),
+			sum("io.time", true, delta, doublePoint(uint64(i), 0.4)),
+		))
+		require.NoError(t, err)
+	}
+
+	// 5 точек по 0.4 дают 2
+	for _, name := range []string{"cpu.time", "io.time"} {
+		metric, err := storage.Get(ctx, metrics.Counter, name)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), metric.Delta, name)
+	}
+}
+
+func TestReceiver_ExportIdleSeries(t *testing.T) {
+	ctx := context.Background()
+	storage := storages.NewMemStorage()
+	receiver := NewReceiver(storage)
+	now := time.Unix(0, 0)
+	receiver.now = func() time.Time { return now }
+
+	_, err := receiver.Export(ctx, request("api", sum("http.requests", true, cumulative, intPoint(1, 10, 5))))
+	require.NoError(t, err)
+	require.Len(t, receiver.series, 1)
+
+	now = now.Add(2 * seriesIdleTimeout)
+	_, err = receiver.Export(ctx, request("api", sum("http.errors", true, cumulative, intPoint(1, 10, 1))))
+	require.NoError(t, err)
+	assert.Len(t, receiver.series, 1, "idle series must be removed")
+
+	// после удаления состояния точка ряда считается первой
+	_, err = receiver.Export(ctx, request("api", sum("http.requests", true, cumulative, intPoint(1, 20, 7))))
+	require.NoError(t, err)
+	metric, err := storage.Get(ctx, metrics.Counter, "http.requests")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5+7), metric.Delta)
+}
+
+// blockingStorage блокирует первую запись до закрытия release.
+type blockingStorage struct {
+	storages.MetricStorage
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (s *blockingStorage) Batch(ctx context.Context, metricSlice []metrics.Metric) error {
+	first := false
+	s.once.Do(func() { first = true })
+	if first {
+		close(s.started)
+		<-s.release
+	}
+	return s.MetricStorage.Batch(ctx, metricSlice)
+}
+
+func TestReceiver_ExportConcurrent(t *testing.T) {
+	ctx := context.Background()
+	storage := &blockingStorage{
+		MetricStorage: storages.NewMemStorage(),
+		started:       make(chan struct{}),
+		release:       make(chan struct{}),
+	}
+	receiver := NewReceiver(storage)
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := receiver.Export(ctx, request("api", sum("http.requests", true, cumulative, intPoint(1, 10, 5))))
+		errCh <- err
+	}()
+	<-storage.started
+
+	// запись в хранилище не должна блокировать обработку других запросов
+	_, err := receiver.Export(ctx, request("api", sum("http.requests", true, cumulative, intPoint(1, 20, 8))))
+	require.NoError(t, err)
+	close(storage.release)
+	require.NoError(t, <-errCh)
+
+	metric, err := storage.Get(ctx, metrics.Counter, "http.requests")
+	require.NoError(t, err)
+	assert.Equal(t, int64(8), metric.Delta)
+}
@@ -0,0 +1,140 @@
+// Package problems формирует ответы об ошибках в формате RFC 7807 (application/problem+json).
+//
+// Помимо стандартных полей тело ответа содержит поле code — стабильный код ошибки,
+// по которому клиенты различают причины отказа независимо от текста detail.
+package problems
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/SpaceSlow/execenv/internal/metrics"
+	"github.com/SpaceSlow/execenv/internal/storages"
+)
+
+// ContentType тип содержимого ответа об ошибке.
+const ContentType = "application/problem+json"
+
+// typePrefix префикс URI типа проблемы, к нему добавляется код ошибки.
+const typePrefix = "urn:execenv:problem:"
+
+// Code стабильный код ошибки.
+type Code string
+
+const (
+	CodeBadRequest           Code = "bad_request"
+	CodeUnknownMetricType    Code = "unknown_metric_type"
+	CodeIncorrectMetricValue Code = "incorrect_metric_value"
+	CodeEmptyMetricName      Code = "empty_metric_name"
+	CodeMetricNameTooLong    Code = "metric_name_too_long"
+	CodeReservedMetricName   Code = "reserved_metric_name"
+	CodeMetricNotFound       Code = "metric_not_found"
+	CodeUnsupportedMediaType Code = "unsupported_media_type"
+	CodeValidationFailed     Code = "validation_failed"
+	CodeInvalidSignature     Code = "invalid_signature"
+	CodeDecryptionFailed     Code = "decryption_failed"
+	CodeUntrustedSubnet      Code = "untrusted_subnet"
+	CodeUnauthorized         Code = "unauthorized"
+	CodeForbidden            Code = "forbidden"
+	CodeRateLimited          Code = "rate_limited"
+	CodeQuotaExceeded        Code = "quota_exceeded"
+	CodeRequestTooLarge      Code = "request_too_large"
+	CodeStorageUnavailable   Code = "storage_unavailable"
+	CodeInternal             Code = "internal_error"
+)
+
+var titles = map[Code]string{
+	CodeBadRequest:           "Bad request",
+	CodeUnknownMetricType:    "Unknown metric type",
+	CodeIncorrectMetricValue: "Incorrect metric value",
+	CodeEmptyMetricName:      "Empty metric name",
+	CodeMetricNameTooLong:    "Metric name too long",
+	CodeReservedMetricName:   "Reserved metric name",
+	CodeMetricNotFound:       "Metric not found",
+	CodeUnsupportedMediaType: "Unsupported media type",
+	CodeValidationFailed:     "Request validation failed",
+	CodeInvalidSignature:     "Invalid signature",
+	CodeDecryptionFailed:     "Decryption failed",
+	CodeUntrustedSubnet:      "Untrusted subnet",
+	CodeUnauthorized:         "Unauthorized",
+	CodeForbidden:            "Forbidden",
+	CodeRateLimited:          "Too many requests",
+	CodeQuotaExceeded:        "Quota exceeded",
+	CodeRequestTooLarge:      "Request too large",
+	CodeStorageUnavailable:   "Storage unavailable",
+	CodeInternal:             "Internal server error",
+}
+
+// Problem тело ответа об ошибке.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     Code   `json:"code"`
+	Status   int    `json:"status"`
+}
+
+// New возвращает проблему с кодом code и HTTP-статусом status.
+func New(status int, code Code, detail string) Problem {
+	return Problem{
+		Type:   typePrefix + string(code),
+		Title:  titles[code],
+		Detail: detail,
+		Code:   code,
+		Status: status,
+	}
+}
+
+// FromError возвращает проблему, соответствующую ошибке хранилища или метрики:
+//   - ошибки метрик — 400 с кодом причины;
+//   - storages.ErrMetricNotFound — 404;
+//   - прочие ошибки (недоступность хранилища, отмена или истечение контекста) — 503 storage_unavailable.
+func FromError(err error) Problem {
+	switch {
+	case errors.Is(err, metrics.ErrUnknownMetricType):
+		return New(http.StatusBadRequest, CodeUnknownMetricType, err.Error())
+	case errors.Is(err, metrics.ErrIncorrectMetricValue):
+		return New(http.StatusBadRequest, CodeIncorrectMetricValue, err.Error())
+	case errors.Is(err, metrics.ErrEmptyMetricName):
+		return New(http.StatusBadRequest, CodeEmptyMetricName, err.Error())
+	case errors.Is(err, metrics.ErrMetricNameTooLong):
+		return New(http.StatusBadRequest, CodeMetricNameTooLong, err.Error())
+	case errors.Is(err, metrics.ErrReservedMetricName):
+		return New(http.StatusBadRequest, CodeReservedMetricName, err.Error())
+	case errors.Is(err, metrics.ErrIncorrectMetricTypeOrValue):
+		return New(http.StatusBadRequest, CodeBadRequest, err.Error())
+	case errors.Is(err, storages.ErrMetricNotFound):
+		return New(http.StatusNotFound, CodeMetricNotFound, err.Error())
+	default:
+		return New(http.StatusServiceUnavailable, CodeStorageUnavailable, err.Error())
+	}
+}
+
+// Write отправляет проблему в ответ на запрос req.
+func (p Problem) Write(res http.ResponseWriter, req *http.Request) {
+	if p.Instance == "" && req != nil {
+		p.Instance = req.URL.Path
+	}
+	body, err := json.Marshal(p)
+	if err != nil {
+		res.WriteHeader(p.Status)
+		return
+	}
+
+	res.Header().Set("Content-Type", ContentType)
+	res.Header().Set("X-Content-Type-Options", "nosniff")
+	res.WriteHeader(p.Status)
+	res.Write(body)
+}
+
+// Write отправляет проблему с кодом code и HTTP-статусом status.
+func Write(res http.ResponseWriter, req *http.Request, status int, code Code, detail string) {
+	New(status, code, detail).Write(res, req)
+}
+
+// Internal отправляет проблему 500 internal_error. Текст ошибки клиенту не передаётся.
+func Internal(res http.ResponseWriter, req *http.Request) {
+	Write(res, req, http.StatusInternalServerError, CodeInternal, "")
+}
@@ -0,0 +1,62 @@
+package problems
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SpaceSlow/execenv/internal/metrics"
+	"github.com/SpaceSlow/execenv/internal/storages"
+)
+
+func TestFromError(t *testing.T) {
+	tests := []struct {
+		err        error
+		name       string
+		wantCode   Code
+		wantStatus int
+	}{
+		{name: "unknown metric type", err: metrics.ErrUnknownMetricType, wantStatus: http.StatusBadRequest, wantCode: CodeUnknownMetricType},
+		{name: "incorrect metric value", err: metrics.ErrIncorrectMetricValue, wantStatus: http.StatusBadRequest, wantCode: CodeIncorrectMetricValue},
+		{name: "wrapped metric error", err: fmt.Errorf("metric #1: %w", metrics.ErrUnknownMetricType), wantStatus: http.StatusBadRequest, wantCode: CodeUnknownMetricType},
+		{name: "general metric error", err: metrics.ErrIncorrectMetricTypeOrValue, wantStatus: http.StatusBadRequest, wantCode: CodeBadRequest},
+		{name: "metric name too long", err: metrics.ErrMetricNameTooLong, wantStatus: http.StatusBadRequest, wantCode: CodeMetricNameTooLong},
+		{name: "reserved metric name", err: metrics.ErrReservedMetricName, wantStatus: http.StatusBadRequest, wantCode: CodeReservedMetricName},
+		{name: "metric not found", err: storages.ErrMetricNotFound, wantStatus: http.StatusNotFound, wantCode: CodeMetricNotFound},
+		{name: "storage error", err: errors.New("connection refused"), wantStatus: http.StatusServiceUnavailable, wantCode: CodeStorageUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problem := FromError(tt.err)
+			assert.Equal(t, tt.wantStatus, problem.Status)
+			assert.Equal(t, tt.wantCode, problem.Code)
+			assert.Equal(t, tt.err.Error(), problem.Detail)
+		})
+	}
+}
+
+func TestProblem_Write(t *testing.T) {
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/update/gauge/metric/none", nil)
+	FromError(metrics.ErrIncorrectMetricValue).Write(res, req)
+
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+	assert.Equal(t, ContentType, res.Header().Get("Content-Type"))
+
+	var problem Problem
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &problem))
+	assert.Equal(t, Problem{
+		Type:     "urn:execenv:problem:incorrect_metric_value",
+		Title:    "Incorrect metric value",
+		Detail:   "incorrect metric value",
+		Instance: "/update/gauge/metric/none",
+		Code:     CodeIncorrectMetricValue,
+		Status:   http.StatusBadRequest,
+	}, problem)
+}
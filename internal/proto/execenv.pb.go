@@ -240,6 +240,7 @@ type BatchAddMetricsRequest struct {
 	unknownFields protoimpl.UnknownFields
 
 	Metrics []*Metric `protobuf:"bytes,1,rep,name=metrics,proto3" json:"metrics,omitempty"`
+	Partial bool      `protobuf:"varint,2,opt,name=partial,proto3" json:"partial,omitempty"`
 }
 
 func (x *BatchAddMetricsRequest) Reset() {
@@ -281,18 +282,82 @@ func (x *BatchAddMetricsRequest) GetMetrics() []*Metric {
 	return nil
 }
 
+func (x *BatchAddMetricsRequest) GetPartial() bool {
+	if x != nil {
+		return x.Partial
+	}
+	return false
+}
+
+type MetricError struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Index int32  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Error string `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (x *MetricError) Reset() {
+	*x = MetricError{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_execenv_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MetricError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MetricError) ProtoMessage() {}
+
+func (x *MetricError) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_execenv_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MetricError.ProtoReflect.Descriptor instead.
+func (*MetricError) Descriptor() ([]byte, []int) {
+	return file_proto_execenv_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *MetricError) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *MetricError) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
 type BatchAddMetricsResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Error string `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
+	Error    string         `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
+	Accepted int64          `protobuf:"varint,2,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	Errors   []*MetricError `protobuf:"bytes,3,rep,name=errors,proto3" json:"errors,omitempty"`
 }
 
 func (x *BatchAddMetricsResponse) Reset() {
 	*x = BatchAddMetricsResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_execenv_proto_msgTypes[4]
+		mi := &file_proto_execenv_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -305,7 +370,7 @@ func (x *BatchAddMetricsResponse) String() string {
 func (*BatchAddMetricsResponse) ProtoMessage() {}
 
 func (x *BatchAddMetricsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_execenv_proto_msgTypes[4]
+	mi := &file_proto_execenv_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -318,7 +383,7 @@ func (x *BatchAddMetricsResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BatchAddMetricsResponse.ProtoReflect.Descriptor instead.
 func (*BatchAddMetricsResponse) Descriptor() ([]byte, []int) {
-	return file_proto_execenv_proto_rawDescGZIP(), []int{4}
+	return file_proto_execenv_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *BatchAddMetricsResponse) GetError() string {
@@ -328,6 +393,20 @@ func (x *BatchAddMetricsResponse) GetError() string {
 	return ""
 }
 
+func (x *BatchAddMetricsResponse) GetAccepted() int64 {
+	if x != nil {
+		return x.Accepted
+	}
+	return 0
+}
+
+func (x *BatchAddMetricsResponse) GetErrors() []*MetricError {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
 type GetMetricRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -340,7 +419,7 @@ type GetMetricRequest struct {
 func (x *GetMetricRequest) Reset() {
 	*x = GetMetricRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_execenv_proto_msgTypes[5]
+		mi := &file_proto_execenv_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -353,7 +432,7 @@ func (x *GetMetricRequest) String() string {
 func (*GetMetricRequest) ProtoMessage() {}
 
 func (x *GetMetricRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_execenv_proto_msgTypes[5]
+	mi := &file_proto_execenv_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -366,7 +445,7 @@ func (x *GetMetricRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetMetricRequest.ProtoReflect.Descriptor instead.
 func (*GetMetricRequest) Descriptor() ([]byte, []int) {
-	return file_proto_execenv_proto_rawDescGZIP(), []int{5}
+	return file_proto_execenv_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *GetMetricRequest) GetId() string {
@@ -395,7 +474,7 @@ type GetMetricResponse struct {
 func (x *GetMetricResponse) Reset() {
 	*x = GetMetricResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_execenv_proto_msgTypes[6]
+		mi := &file_proto_execenv_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -408,7 +487,7 @@ func (x *GetMetricResponse) String() string {
 func (*GetMetricResponse) ProtoMessage() {}
 
 func (x *GetMetricResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_execenv_proto_msgTypes[6]
+	mi := &file_proto_execenv_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -421,7 +500,7 @@ func (x *GetMetricResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetMetricResponse.ProtoReflect.Descriptor instead.
 func (*GetMetricResponse) Descriptor() ([]byte, []int) {
-	return file_proto_execenv_proto_rawDescGZIP(), []int{6}
+	return file_proto_execenv_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *GetMetricResponse) GetMetric() *Metric {
@@ -447,7 +526,7 @@ type ListMetricsRequest struct {
 func (x *ListMetricsRequest) Reset() {
 	*x = ListMetricsRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_execenv_proto_msgTypes[7]
+		mi := &file_proto_execenv_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -460,7 +539,7 @@ func (x *ListMetricsRequest) String() string {
 func (*ListMetricsRequest) ProtoMessage() {}
 
 func (x *ListMetricsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_execenv_proto_msgTypes[7]
+	mi := &file_proto_execenv_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -473,7 +552,7 @@ func (x *ListMetricsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListMetricsRequest.ProtoReflect.Descriptor instead.
 func (*ListMetricsRequest) Descriptor() ([]byte, []int) {
-	return file_proto_execenv_proto_rawDescGZIP(), []int{7}
+	return file_proto_execenv_proto_rawDescGZIP(), []int{8}
 }
 
 type ListMetricsResponse struct {
@@ -488,7 +567,7 @@ type ListMetricsResponse struct {
 func (x *ListMetricsResponse) Reset() {
 	*x = ListMetricsResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_proto_execenv_proto_msgTypes[8]
+		mi := &file_proto_execenv_proto_msgTypes[9]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -501,7 +580,7 @@ func (x *ListMetricsResponse) String() string {
 func (*ListMetricsResponse) ProtoMessage() {}
 
 func (x *ListMetricsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_execenv_proto_msgTypes[8]
+	mi := &file_proto_execenv_proto_msgTypes[9]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -514,7 +593,7 @@ func (x *ListMetricsResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListMetricsResponse.ProtoReflect.Descriptor instead.
 func (*ListMetricsResponse) Descriptor() ([]byte, []int) {
-	return file_proto_execenv_proto_rawDescGZIP(), []int{8}
+	return file_proto_execenv_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *ListMetricsResponse) GetMetrics() []*Metric {
@@ -549,57 +628,67 @@ var file_proto_execenv_proto_rawDesc = []byte{
 	0x06, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x22, 0x29, 0x0a, 0x11, 0x41, 0x64, 0x64, 0x4d, 0x65,
 	0x74, 0x72, 0x69, 0x63, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x14, 0x0a, 0x05,
 	0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72,
-	0x6f, 0x72, 0x22, 0x43, 0x0a, 0x16, 0x42, 0x61, 0x74, 0x63, 0x68, 0x41, 0x64, 0x64, 0x4d, 0x65,
+	0x6f, 0x72, 0x22, 0x5d, 0x0a, 0x16, 0x42, 0x61, 0x74, 0x63, 0x68, 0x41, 0x64, 0x64, 0x4d, 0x65,
 	0x74, 0x72, 0x69, 0x63, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x29, 0x0a, 0x07,
 	0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e,
 	0x65, 0x78, 0x65, 0x63, 0x65, 0x6e, 0x76, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x52, 0x07,
-	0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x22, 0x2f, 0x0a, 0x17, 0x42, 0x61, 0x74, 0x63, 0x68,
-	0x41, 0x64, 0x64, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
-	0x73, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x48, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x4d,
-	0x65, 0x74, 0x72, 0x69, 0x63, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02,
-	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x24, 0x0a, 0x05,
-	0x6d, 0x54, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0e, 0x2e, 0x65, 0x78,
-	0x65, 0x63, 0x65, 0x6e, 0x76, 0x2e, 0x4d, 0x54, 0x79, 0x70, 0x65, 0x52, 0x05, 0x6d, 0x54, 0x79,
-	0x70, 0x65, 0x22, 0x52, 0x0a, 0x11, 0x47, 0x65, 0x74, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x52,
-	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x27, 0x0a, 0x06, 0x6d, 0x65, 0x74, 0x72, 0x69,
-	0x63, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x65, 0x78, 0x65, 0x63, 0x65, 0x6e,
-	0x76, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x52, 0x06, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63,
-	0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x14, 0x0a, 0x12, 0x4c, 0x69, 0x73, 0x74, 0x4d, 0x65,
-	0x74, 0x72, 0x69, 0x63, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x56, 0x0a, 0x13,
-	0x4c, 0x69, 0x73, 0x74, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f,
-	0x6e, 0x73, 0x65, 0x12, 0x29, 0x0a, 0x07, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x18, 0x01,
-	0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x65, 0x78, 0x65, 0x63, 0x65, 0x6e, 0x76, 0x2e, 0x4d,
-	0x65, 0x74, 0x72, 0x69, 0x63, 0x52, 0x07, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x12, 0x14,
-	0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65,
-	0x72, 0x72, 0x6f, 0x72, 0x2a, 0x30, 0x0a, 0x05, 0x4d, 0x54, 0x79, 0x70, 0x65, 0x12, 0x0f, 0x0a,
-	0x0b, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0b,
-	0x0a, 0x07, 0x43, 0x4f, 0x55, 0x4e, 0x54, 0x45, 0x52, 0x10, 0x01, 0x12, 0x09, 0x0a, 0x05, 0x47,
-	0x41, 0x55, 0x47, 0x45, 0x10, 0x02, 0x32, 0xb7, 0x02, 0x0a, 0x0d, 0x4d, 0x65, 0x74, 0x72, 0x69,
-	0x63, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x42, 0x0a, 0x09, 0x41, 0x64, 0x64, 0x4d,
-	0x65, 0x74, 0x72, 0x69, 0x63, 0x12, 0x19, 0x2e, 0x65, 0x78, 0x65, 0x63, 0x65, 0x6e, 0x76, 0x2e,
-	0x41, 0x64, 0x64, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x1a, 0x1a, 0x2e, 0x65, 0x78, 0x65, 0x63, 0x65, 0x6e, 0x76, 0x2e, 0x41, 0x64, 0x64, 0x4d, 0x65,
-	0x74, 0x72, 0x69, 0x63, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x54, 0x0a, 0x0f,
-	0x42, 0x61, 0x74, 0x63, 0x68, 0x41, 0x64, 0x64, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x12,
-	0x1f, 0x2e, 0x65, 0x78, 0x65, 0x63, 0x65, 0x6e, 0x76, 0x2e, 0x42, 0x61, 0x74, 0x63, 0x68, 0x41,
-	0x64, 0x64, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x1a, 0x20, 0x2e, 0x65, 0x78, 0x65, 0x63, 0x65, 0x6e, 0x76, 0x2e, 0x42, 0x61, 0x74, 0x63, 0x68,
-	0x41, 0x64, 0x64, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
-	0x73, 0x65, 0x12, 0x42, 0x0a, 0x09, 0x47, 0x65, 0x74, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x12,
-	0x19, 0x2e, 0x65, 0x78, 0x65, 0x63, 0x65, 0x6e, 0x76, 0x2e, 0x47, 0x65, 0x74, 0x4d, 0x65, 0x74,
-	0x72, 0x69, 0x63, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1a, 0x2e, 0x65, 0x78, 0x65,
-	0x63, 0x65, 0x6e, 0x76, 0x2e, 0x47, 0x65, 0x74, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x52, 0x65,
-	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x48, 0x0a, 0x0b, 0x4c, 0x69, 0x73, 0x74, 0x4d, 0x65,
-	0x74, 0x72, 0x69, 0x63, 0x73, 0x12, 0x1b, 0x2e, 0x65, 0x78, 0x65, 0x63, 0x65, 0x6e, 0x76, 0x2e,
-	0x4c, 0x69, 0x73, 0x74, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x65, 0x78, 0x65, 0x63, 0x65, 0x6e, 0x76, 0x2e, 0x4c, 0x69, 0x73,
-	0x74, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
-	0x42, 0x2d, 0x5a, 0x2b, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x53,
-	0x70, 0x61, 0x63, 0x65, 0x53, 0x6c, 0x6f, 0x77, 0x2f, 0x65, 0x78, 0x65, 0x63, 0x65, 0x6e, 0x76,
-	0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
-	0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x61, 0x72, 0x74, 0x69,
+	0x61, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x70, 0x61, 0x72, 0x74, 0x69, 0x61,
+	0x6c, 0x22, 0x39, 0x0a, 0x0b, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x45, 0x72, 0x72, 0x6f, 0x72,
+	0x12, 0x14, 0x0a, 0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x79, 0x0a, 0x17,
+	0x42, 0x61, 0x74, 0x63, 0x68, 0x41, 0x64, 0x64, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x12, 0x1a, 0x0a,
+	0x08, 0x61, 0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x08, 0x61, 0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x12, 0x2c, 0x0a, 0x06, 0x65, 0x72, 0x72,
+	0x6f, 0x72, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x65, 0x78, 0x65, 0x63,
+	0x65, 0x6e, 0x76, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x52,
+	0x06, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x22, 0x48, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x4d, 0x65,
+	0x74, 0x72, 0x69, 0x63, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x24, 0x0a, 0x05, 0x6d,
+	0x54, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0e, 0x2e, 0x65, 0x78, 0x65,
+	0x63, 0x65, 0x6e, 0x76, 0x2e, 0x4d, 0x54, 0x79, 0x70, 0x65, 0x52, 0x05, 0x6d, 0x54, 0x79, 0x70,
+	0x65, 0x22, 0x52, 0x0a, 0x11, 0x47, 0x65, 0x74, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x27, 0x0a, 0x06, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x65, 0x78, 0x65, 0x63, 0x65, 0x6e, 0x76,
+	0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x52, 0x06, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x12,
+	0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x14, 0x0a, 0x12, 0x4c, 0x69, 0x73, 0x74, 0x4d, 0x65, 0x74,
+	0x72, 0x69, 0x63, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x56, 0x0a, 0x13, 0x4c,
+	0x69, 0x73, 0x74, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x29, 0x0a, 0x07, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x18, 0x01, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x65, 0x78, 0x65, 0x63, 0x65, 0x6e, 0x76, 0x2e, 0x4d, 0x65,
+	0x74, 0x72, 0x69, 0x63, 0x52, 0x07, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x12, 0x14, 0x0a,
+	0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72,
+	0x72, 0x6f, 0x72, 0x2a, 0x30, 0x0a, 0x05, 0x4d, 0x54, 0x79, 0x70, 0x65, 0x12, 0x0f, 0x0a, 0x0b,
+	0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0b, 0x0a,
+	0x07, 0x43, 0x4f, 0x55, 0x4e, 0x54, 0x45, 0x52, 0x10, 0x01, 0x12, 0x09, 0x0a, 0x05, 0x47, 0x41,
+	0x55, 0x47, 0x45, 0x10, 0x02, 0x32, 0xb7, 0x02, 0x0a, 0x0d, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63,
+	0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x42, 0x0a, 0x09, 0x41, 0x64, 0x64, 0x4d, 0x65,
+	0x74, 0x72, 0x69, 0x63, 0x12, 0x19, 0x2e, 0x65, 0x78, 0x65, 0x63, 0x65, 0x6e, 0x76, 0x2e, 0x41,
+	0x64, 0x64, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x1a, 0x2e, 0x65, 0x78, 0x65, 0x63, 0x65, 0x6e, 0x76, 0x2e, 0x41, 0x64, 0x64, 0x4d, 0x65, 0x74,
+	0x72, 0x69, 0x63, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x54, 0x0a, 0x0f, 0x42,
+	0x61, 0x74, 0x63, 0x68, 0x41, 0x64, 0x64, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x12, 0x1f,
+	0x2e, 0x65, 0x78, 0x65, 0x63, 0x65, 0x6e, 0x76, 0x2e, 0x42, 0x61, 0x74, 0x63, 0x68, 0x41, 0x64,
+	0x64, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x20, 0x2e, 0x65, 0x78, 0x65, 0x63, 0x65, 0x6e, 0x76, 0x2e, 0x42, 0x61, 0x74, 0x63, 0x68, 0x41,
+	0x64, 0x64, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x42, 0x0a, 0x09, 0x47, 0x65, 0x74, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x12, 0x19,
+	0x2e, 0x65, 0x78, 0x65, 0x63, 0x65, 0x6e, 0x76, 0x2e, 0x47, 0x65, 0x74, 0x4d, 0x65, 0x74, 0x72,
+	0x69, 0x63, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1a, 0x2e, 0x65, 0x78, 0x65, 0x63,
+	0x65, 0x6e, 0x76, 0x2e, 0x47, 0x65, 0x74, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x48, 0x0a, 0x0b, 0x4c, 0x69, 0x73, 0x74, 0x4d, 0x65, 0x74,
+	0x72, 0x69, 0x63, 0x73, 0x12, 0x1b, 0x2e, 0x65, 0x78, 0x65, 0x63, 0x65, 0x6e, 0x76, 0x2e, 0x4c,
+	0x69, 0x73, 0x74, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x1c, 0x2e, 0x65, 0x78, 0x65, 0x63, 0x65, 0x6e, 0x76, 0x2e, 0x4c, 0x69, 0x73, 0x74,
+	0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42,
+	0x2d, 0x5a, 0x2b, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x53, 0x70,
+	0x61, 0x63, 0x65, 0x53, 0x6c, 0x6f, 0x77, 0x2f, 0x65, 0x78, 0x65, 0x63, 0x65, 0x6e, 0x76, 0x2f,
+	0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -615,39 +704,41 @@ func file_proto_execenv_proto_rawDescGZIP() []byte {
 }
 
 var file_proto_execenv_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_proto_execenv_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_proto_execenv_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
 var file_proto_execenv_proto_goTypes = []any{
 	(MType)(0),                      // 0: execenv.MType
 	(*Metric)(nil),                  // 1: execenv.Metric
 	(*AddMetricRequest)(nil),        // 2: execenv.AddMetricRequest
 	(*AddMetricResponse)(nil),       // 3: execenv.AddMetricResponse
 	(*BatchAddMetricsRequest)(nil),  // 4: execenv.BatchAddMetricsRequest
-	(*BatchAddMetricsResponse)(nil), // 5: execenv.BatchAddMetricsResponse
-	(*GetMetricRequest)(nil),        // 6: execenv.GetMetricRequest
-	(*GetMetricResponse)(nil),       // 7: execenv.GetMetricResponse
-	(*ListMetricsRequest)(nil),      // 8: execenv.ListMetricsRequest
-	(*ListMetricsResponse)(nil),     // 9: execenv.ListMetricsResponse
+	(*MetricError)(nil),             // 5: execenv.MetricError
+	(*BatchAddMetricsResponse)(nil), // 6: execenv.BatchAddMetricsResponse
+	(*GetMetricRequest)(nil),        // 7: execenv.GetMetricRequest
+	(*GetMetricResponse)(nil),       // 8: execenv.GetMetricResponse
+	(*ListMetricsRequest)(nil),      // 9: execenv.ListMetricsRequest
+	(*ListMetricsResponse)(nil),     // 10: execenv.ListMetricsResponse
 }
 var file_proto_execenv_proto_depIdxs = []int32{
 	0,  // 0: execenv.Metric.mType:type_name -> execenv.MType
 	1,  // 1: execenv.AddMetricRequest.metric:type_name -> execenv.Metric
 	1,  // 2: execenv.BatchAddMetricsRequest.metrics:type_name -> execenv.Metric
-	0,  // 3: execenv.GetMetricRequest.mType:type_name -> execenv.MType
-	1,  // 4: execenv.GetMetricResponse.metric:type_name -> execenv.Metric
-	1,  // 5: execenv.ListMetricsResponse.metrics:type_name -> execenv.Metric
-	2,  // 6: execenv.MetricService.AddMetric:input_type -> execenv.AddMetricRequest
-	4,  // 7: execenv.MetricService.BatchAddMetrics:input_type -> execenv.BatchAddMetricsRequest
-	6,  // 8: execenv.MetricService.GetMetric:input_type -> execenv.GetMetricRequest
-	8,  // 9: execenv.MetricService.ListMetrics:input_type -> execenv.ListMetricsRequest
-	3,  // 10: execenv.MetricService.AddMetric:output_type -> execenv.AddMetricResponse
-	5,  // 11: execenv.MetricService.BatchAddMetrics:output_type -> execenv.BatchAddMetricsResponse
-	7,  // 12: execenv.MetricService.GetMetric:output_type -> execenv.GetMetricResponse
-	9,  // 13: execenv.MetricService.ListMetrics:output_type -> execenv.ListMetricsResponse
-	10, // [10:14] is the sub-list for method output_type
-	6,  // [6:10] is the sub-list for method input_type
-	6,  // [6:6] is the sub-list for extension type_name
-	6,  // [6:6] is the sub-list for extension extendee
-	0,  // [0:6] is the sub-list for field type_name
+	5,  // 3: execenv.BatchAddMetricsResponse.errors:type_name -> execenv.MetricError
+	0,  // 4: execenv.GetMetricRequest.mType:type_name -> execenv.MType
+	1,  // 5: execenv.GetMetricResponse.metric:type_name -> execenv.Metric
+	1,  // 6: execenv.ListMetricsResponse.metrics:type_name -> execenv.Metric
+	2,  // 7: execenv.MetricService.AddMetric:input_type -> execenv.AddMetricRequest
+	4,  // 8: execenv.MetricService.BatchAddMetrics:input_type -> execenv.BatchAddMetricsRequest
+	7,  // 9: execenv.MetricService.GetMetric:input_type -> execenv.GetMetricRequest
+	9,  // 10: execenv.MetricService.ListMetrics:input_type -> execenv.ListMetricsRequest
+	3,  // 11: execenv.MetricService.AddMetric:output_type -> execenv.AddMetricResponse
+	6,  // 12: execenv.MetricService.BatchAddMetrics:output_type -> execenv.BatchAddMetricsResponse
+	8,  // 13: execenv.MetricService.GetMetric:output_type -> execenv.GetMetricResponse
+	10, // 14: execenv.MetricService.ListMetrics:output_type -> execenv.ListMetricsResponse
+	11, // [11:15] is the sub-list for method output_type
+	7,  // [7:11] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
 }
 
 func init() { file_proto_execenv_proto_init() }
@@ -705,7 +796,7 @@ func file_proto_execenv_proto_init() {
 			}
 		}
 		file_proto_execenv_proto_msgTypes[4].Exporter = func(v any, i int) any {
-			switch v := v.(*BatchAddMetricsResponse); i {
+			switch v := v.(*MetricError); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -717,7 +808,7 @@ func file_proto_execenv_proto_init() {
 			}
 		}
 		file_proto_execenv_proto_msgTypes[5].Exporter = func(v any, i int) any {
-			switch v := v.(*GetMetricRequest); i {
+			switch v := v.(*BatchAddMetricsResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -729,7 +820,7 @@ func file_proto_execenv_proto_init() {
 			}
 		}
 		file_proto_execenv_proto_msgTypes[6].Exporter = func(v any, i int) any {
-			switch v := v.(*GetMetricResponse); i {
+			switch v := v.(*GetMetricRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -741,7 +832,7 @@ func file_proto_execenv_proto_init() {
 			}
 		}
 		file_proto_execenv_proto_msgTypes[7].Exporter = func(v any, i int) any {
-			switch v := v.(*ListMetricsRequest); i {
+			switch v := v.(*GetMetricResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -753,6 +844,18 @@ func file_proto_execenv_proto_init() {
 			}
 		}
 		file_proto_execenv_proto_msgTypes[8].Exporter = func(v any, i int) any {
+			switch v := v.(*ListMetricsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_execenv_proto_msgTypes[9].Exporter = func(v any, i int) any {
 			switch v := v.(*ListMetricsResponse); i {
 			case 0:
 				return &v.state
@@ -771,7 +874,7 @@ func file_proto_execenv_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_proto_execenv_proto_rawDesc,
 			NumEnums:      1,
-			NumMessages:   9,
+			NumMessages:   10,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -10,12 +10,12 @@ func ConvertFromProto(m *Metric) (*metrics.Metric, error) {
 	switch m.MType {
 	case MType_COUNTER:
 		metric.Type = metrics.Counter
-		metric.Value = m.Delta
+		metric.Delta = m.Delta
 	case MType_GAUGE:
 		metric.Type = metrics.Gauge
 		metric.Value = m.Value
 	default:
-		return nil, metrics.ErrIncorrectMetricTypeOrValue
+		return nil, metrics.ErrUnknownMetricType
 	}
 	return metric, nil
 }
@@ -28,20 +28,12 @@ func ConvertToProto(m *metrics.Metric) (*Metric, error) {
 	switch m.Type {
 	case metrics.Counter:
 		metric.MType = MType_COUNTER
-		delta, ok := m.Value.(int64)
-		if !ok {
-			return nil, metrics.ErrIncorrectMetricTypeOrValue
-		}
-		metric.Delta = delta
+		metric.Delta = m.Delta
 	case metrics.Gauge:
 		metric.MType = MType_GAUGE
-		value, ok := m.Value.(float64)
-		if !ok {
-			return nil, metrics.ErrIncorrectMetricTypeOrValue
-		}
-		metric.Value = value
+		metric.Value = m.Value
 	default:
-		return nil, metrics.ErrIncorrectMetricTypeOrValue
+		return nil, metrics.ErrUnknownMetricType
 	}
 	return metric, nil
 }
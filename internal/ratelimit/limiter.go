@@ -0,0 +1,171 @@
+// Package ratelimit ограничивает нагрузку, создаваемую отдельными агентами:
+// частоту запросов (token bucket), количество метрик в пакете и количество различных метрик (серий) агента.
+//
+// Агент определяется по клиентскому сертификату (при mTLS), а при его отсутствии — по адресу клиента.
+// Заголовки (метаданные) X-Forwarded-For и X-Real-IP учитываются только для запросов через доверенные прокси.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/SpaceSlow/execenv/internal/metrics"
+)
+
+var (
+	// ErrBatchTooLarge пакет содержит больше метрик, чем Limits.MaxBatchSize.
+	ErrBatchTooLarge = errors.New("too many metrics in batch")
+	// ErrSeriesQuotaExceeded агент превысил квоту различных метрик Limits.MaxSeries.
+	ErrSeriesQuotaExceeded = errors.New("distinct metrics quota exceeded")
+)
+
+// agentIdleTimeout время без запросов, после которого состояние агента (в том числе учтённые серии) сбрасывается.
+const agentIdleTimeout = time.Hour
+
+// Limits ограничения для одного агента, нулевое значение отключает ограничение.
+type Limits struct {
+	// Rate допустимое количество запросов в секунду
+	Rate float64
+	// Burst допустимое количество запросов сверх Rate подряд, по умолчанию — Rate, но не меньше 1
+	Burst int
+	// MaxRequestSize максимальный размер тела запроса в байтах
+	MaxRequestSize int64
+	// MaxBatchSize максимальное количество метрик в одном запросе
+	MaxBatchSize int
+	// MaxSeries максимальное количество различных метрик (имя и тип) агента
+	MaxSeries int
+}
+
+type seriesKey struct {
+	name       string
+	metricType metrics.MetricType
+}
+
+type agentState struct {
+	updated time.Time
+	series  map[seriesKey]struct{}
+	tokens  float64
+}
+
+// Limiter хранит состояние агентов и проверяет ограничения Limits.
+type Limiter struct {
+	now       func() time.Time
+	agents    map[string]*agentState
+	lastSweep time.Time
+	limits    Limits
+	burst     float64
+	mu        sync.Mutex
+}
+
+func NewLimiter(limits Limits) *Limiter {
+	burst := float64(limits.Burst)
+	if burst <= 0 {
+		burst = math.Max(1, math.Ceil(limits.Rate))
+	}
+	return &Limiter{
+		now:    time.Now,
+		agents: make(map[string]*agentState),
+		limits: limits,
+		burst:  burst,
+	}
+}
+
+// Limits возвращает ограничения Limiter.
+func (l *Limiter) Limits() Limits {
+	return l.limits
+}
+
+// Allow расходует токен агента и сообщает, допустим ли запрос.
+// Если запрос недопустим, возвращает время, через которое появится следующий токен.
+func (l *Limiter) Allow(agent string) (bool, time.Duration) {
+	if l.limits.Rate <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	state, now := l.agent(agent)
+	state.tokens = math.Min(l.burst, state.tokens+now.Sub(state.updated).Seconds()*l.limits.Rate)
+	state.updated = now
+	if state.tokens >= 1 {
+		state.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - state.tokens) / l.limits.Rate * float64(time.Second))
+	return false, wait
+}
+
+// CheckBatch проверяет размер пакета и квоту различных метрик агента и учитывает новые метрики пакета.
+// При превышении квоты пакет не учитывается.
+func (l *Limiter) CheckBatch(agent string, metricSlice []metrics.Metric) error {
+	if l.limits.MaxBatchSize > 0 && len(metricSlice) > l.limits.MaxBatchSize {
+		return ErrBatchTooLarge
+	}
+	if l.limits.MaxSeries <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	state, _ := l.agent(agent)
+	added := make(map[seriesKey]struct{})
+	for i := range metricSlice {
+		key := seriesKey{name: metricSlice[i].Name, metricType: metricSlice[i].Type}
+		if _, ok := state.series[key]; !ok {
+			added[key] = struct{}{}
+		}
+	}
+	if len(state.series)+len(added) > l.limits.MaxSeries {
+		return ErrSeriesQuotaExceeded
+	}
+	for key := range added {
+		state.series[key] = struct{}{}
+	}
+	return nil
+}
+
+// agent возвращает состояние агента, создавая его при необходимости, и удаляет состояния неактивных агентов.
+// Требует блокировки l.mu.
+func (l *Limiter) agent(agent string) (*agentState, time.Time) {
+	now := l.now()
+	if now.Sub(l.lastSweep) > agentIdleTimeout {
+		for name, state := range l.agents {
+			if now.Sub(state.updated) > agentIdleTimeout {
+				delete(l.agents, name)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	state, ok := l.agents[agent]
+	if !ok {
+		state = &agentState{
+			updated: now,
+			series:  make(map[seriesKey]struct{}),
+			tokens:  l.burst,
+		}
+		l.agents[agent] = state
+	}
+	if l.limits.Rate <= 0 {
+		state.updated = now
+	}
+	return state, now
+}
+
+type agentKey struct{}
+
+// WithAgent возвращает контекст запроса агента agent.
+func WithAgent(ctx context.Context, agent string) context.Context {
+	return context.WithValue(ctx, agentKey{}, agent)
+}
+
+// AgentFromContext возвращает агента, от которого получен запрос, или false, если запрос получен не от агента.
+func AgentFromContext(ctx context.Context) (string, bool) {
+	agent, ok := ctx.Value(agentKey{}).(string)
+	return agent, ok
+}
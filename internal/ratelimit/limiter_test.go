@@ -0,0 +1,123 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SpaceSlow/execenv/internal/metrics"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newTestLimiter(limits Limits) (*Limiter, *fakeClock) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	l := NewLimiter(limits)
+	l.now = clock.Now
+	return l, clock
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	l, clock := newTestLimiter(Limits{Rate: 2, Burst: 3})
+
+	for i := 0; i < 3; i++ {
+		ok, _ := l.Allow("10.0.0.1")
+		require.True(t, ok, "request #%d within burst", i)
+	}
+	ok, retryAfter := l.Allow("10.0.0.1")
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+	ok, _ = l.Allow("10.0.0.2")
+	assert.True(t, ok, "agents are limited independently")
+
+	clock.now = clock.now.Add(500 * time.Millisecond)
+	ok, _ = l.Allow("10.0.0.1")
+	assert.True(t, ok, "token refilled")
+	ok, _ = l.Allow("10.0.0.1")
+	assert.False(t, ok)
+}
+
+func TestLimiter_AllowUnlimited(t *testing.T) {
+	l, _ := newTestLimiter(Limits{})
+	for i := 0; i < 100; i++ {
+		ok, _ := l.Allow("10.0.0.1")
+		require.True(t, ok)
+	}
+}
+
+func TestLimiter_CheckBatch(t *testing.T) {
+	batch := func(names ...string) []metrics.Metric {
+		metricSlice := make([]metrics.Metric, 0, len(names))
+		for _, name := range names {
+			metricSlice = append(metricSlice, metrics.NewCounter(name, 1))
+		}
+		return metricSlice
+	}
+
+	tests := []struct {
+		limits  Limits
+		name    string
+		batches [][]metrics.Metric
+		wantErr []error
+	}{
+		{
+			name:    "unlimited",
+			batches: [][]metrics.Metric{batch("a", "b", "c", "d")},
+			wantErr: []error{nil},
+		},
+		{
+			name:    "batch too large",
+			limits:  Limits{MaxBatchSize: 2},
+			batches: [][]metrics.Metric{batch("a", "b"), batch("a", "b", "c")},
+			wantErr: []error{nil, ErrBatchTooLarge},
+		},
+		{
+			name:    "known series do not count twice",
+			limits:  Limits{MaxSeries: 2},
+			batches: [][]metrics.Metric{batch("a", "b"), batch("a", "b", "a")},
+			wantErr: []error{nil, nil},
+		},
+		{
+			name:    "series quota exceeded",
+			limits:  Limits{MaxSeries: 2},
+			batches: [][]metrics.Metric{batch("a"), batch("b", "c"), batch("b")},
+			wantErr: []error{nil, ErrSeriesQuotaExceeded, nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, _ := newTestLimiter(tt.limits)
+			for i := range tt.batches {
+				assert.ErrorIs(t, l.CheckBatch("10.0.0.1", tt.batches[i]), tt.wantErr[i], "batch #%d", i)
+			}
+		})
+	}
+}
+
+func TestLimiter_ForgetsIdleAgents(t *testing.T) {
+	l, clock := newTestLimiter(Limits{MaxSeries: 1})
+	require.NoError(t, l.CheckBatch("10.0.0.1", []metrics.Metric{metrics.NewGauge("a", 1)}))
+	require.ErrorIs(t, l.CheckBatch("10.0.0.1", []metrics.Metric{metrics.NewGauge("b", 1)}), ErrSeriesQuotaExceeded)
+
+	clock.now = clock.now.Add(2 * agentIdleTimeout)
+	assert.NoError(t, l.CheckBatch("10.0.0.1", []metrics.Metric{metrics.NewGauge("b", 1)}))
+}
+
+func TestAgentFromContext(t *testing.T) {
+	_, ok := AgentFromContext(context.Background())
+	assert.False(t, ok)
+
+	agent, ok := AgentFromContext(WithAgent(context.Background(), "10.0.0.1"))
+	assert.True(t, ok)
+	assert.Equal(t, "10.0.0.1", agent)
+}
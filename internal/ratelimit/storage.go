@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/SpaceSlow/execenv/internal/metrics"
+	"github.com/SpaceSlow/execenv/internal/storages"
+)
+
+var (
+	_ storages.MetricStorage    = (*QuotaStorage)(nil)
+	_ storages.ICheckConnection = (*QuotaStorage)(nil)
+)
+
+// QuotaStorage проверяет ограничения агента (Limiter.CheckBatch) перед добавлением метрик.
+// Запросы, в контексте которых не указан агент (WithAgent), не ограничиваются.
+// Пакет, превышающий допустимый размер, отклоняется целиком (storages.ErrBatchRejected).
+type QuotaStorage struct {
+	storage storages.MetricStorage
+	limiter *Limiter
+}
+
+func NewQuotaStorage(storage storages.MetricStorage, limiter *Limiter) *QuotaStorage {
+	return &QuotaStorage{
+		storage: storage,
+		limiter: limiter,
+	}
+}
+
+func (s QuotaStorage) Add(ctx context.Context, metric *metrics.Metric) (*metrics.Metric, error) {
+	if err := s.check(ctx, []metrics.Metric{*metric}); err != nil {
+		return nil, err
+	}
+	return s.storage.Add(ctx, metric)
+}
+
+func (s QuotaStorage) Batch(ctx context.Context, metricSlice []metrics.Metric) error {
+	err := s.check(ctx, metricSlice)
+	if errors.Is(err, ErrBatchTooLarge) {
+		// добавление метрик пакета по одной обошло бы ограничение размера пакета
+		return fmt.Errorf("%w: %w", storages.ErrBatchRejected, err)
+	}
+	if err != nil {
+		return err
+	}
+	return s.storage.Batch(ctx, metricSlice)
+}
+
+func (s QuotaStorage) Get(ctx context.Context, metricType metrics.MetricType, name string) (*metrics.Metric, error) {
+	return s.storage.Get(ctx, metricType, name)
+}
+
+func (s QuotaStorage) List(ctx context.Context) ([]metrics.Metric, error) {
+	return s.storage.List(ctx)
+}
+
+func (s QuotaStorage) ListFiltered(ctx context.Context, filter storages.ListFilter) ([]metrics.Metric, error) {
+	return s.storage.ListFiltered(ctx, filter)
+}
+
+func (s QuotaStorage) Close(ctx context.Context) error {
+	return s.storage.Close(ctx)
+}
+
+// CheckConnection проверяет соединение с исходным хранилищем (см. storages.CheckConnection).
+func (s QuotaStorage) CheckConnection(ctx context.Context) bool {
+	return storages.CheckConnection(ctx, s.storage) == nil
+}
+
+func (s QuotaStorage) check(ctx context.Context, metricSlice []metrics.Metric) error {
+	agent, ok := AgentFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return s.limiter.CheckBatch(agent, metricSlice)
+}
@@ -0,0 +1,38 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SpaceSlow/execenv/internal/metrics"
+	"github.com/SpaceSlow/execenv/internal/storages"
+)
+
+func TestQuotaStorage(t *testing.T) {
+	s := NewQuotaStorage(storages.NewMemStorage(), NewLimiter(Limits{MaxBatchSize: 2, MaxSeries: 2}))
+	agentCtx := WithAgent(context.Background(), "10.0.0.1")
+
+	require.NoError(t, s.Batch(agentCtx, []metrics.Metric{metrics.NewCounter("a", 1), metrics.NewCounter("b", 1)}))
+	_, err := s.Add(agentCtx, &metrics.Metric{Type: metrics.Counter, Name: "c", Delta: 1})
+	assert.ErrorIs(t, err, ErrSeriesQuotaExceeded)
+	_, err = s.Add(agentCtx, &metrics.Metric{Type: metrics.Counter, Name: "a", Delta: 1})
+	assert.NoError(t, err)
+
+	err = s.Batch(agentCtx, []metrics.Metric{metrics.NewCounter("a", 1), metrics.NewCounter("b", 1), metrics.NewCounter("a", 1)})
+	assert.ErrorIs(t, err, ErrBatchTooLarge)
+
+	accepted, itemErrors, err := storages.BatchEach(agentCtx, s, []metrics.Metric{metrics.NewCounter("a", 1), metrics.NewCounter("b", 1), metrics.NewCounter("c", 1)})
+	assert.ErrorIs(t, err, ErrBatchTooLarge, "batch size limit must not be bypassed by adding metrics one by one")
+	assert.Zero(t, accepted)
+	assert.Empty(t, itemErrors)
+
+	require.NoError(t, s.Batch(context.Background(), []metrics.Metric{metrics.NewCounter("c", 1), metrics.NewCounter("d", 1), metrics.NewCounter("e", 1)}),
+		"requests without agent are not limited")
+
+	metric, err := s.Get(context.Background(), metrics.Counter, "a")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), metric.Delta)
+}
@@ -0,0 +1,213 @@
+// Package remotewrite реализует приём метрик по протоколу Prometheus remote write 1.0.
+//
+// Сэмплы рядов, относящихся к счётчикам (по метаданным запроса, а при их отсутствии — по суффиксам
+// _total, _count, _sum и _bucket), сохраняются как counter с приращением относительно предыдущего сэмпла ряда.
+// Для первого сэмпла ряда и после сброса счётчика приращением считается само значение. Приращения вычисляются
+// по округлённым значениям, чтобы дробные части не терялись. Состояние ряда без сэмплов в течение
+// seriesIdleTimeout удаляется, и следующий сэмпл ряда считается первым.
+// Остальные ряды сохраняются как gauge. Сэмплы NaN (в том числе stale-маркеры) пропускаются.
+//
+// Имя метрики — значение метки __name__, остальные метки добавляются к нему в виде {key=value,...}.
+// Запрос с именем метрики длиннее metrics.MaxNameLength отклоняется целиком (ErrIncorrectRequest).
+package remotewrite
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SpaceSlow/execenv/internal/metrics"
+	"github.com/SpaceSlow/execenv/internal/storages"
+)
+
+const nameLabel = "__name__"
+
+var counterSuffixes = []string{"_total", "_count", "_sum", "_bucket"}
+
+// seriesIdleTimeout время без сэмплов, после которого последний сэмпл счётчика забывается.
+const seriesIdleTimeout = 10 * time.Minute
+
+type lastSample struct {
+	updated   time.Time
+	value     float64
+	timestamp int64
+}
+
+// Receiver сохраняет метрики remote write в хранилище.
+type Receiver struct {
+	storage storages.MetricStorage
+	now     func() time.Time
+	// series последние сэмплы счётчиков, ключ — имя метрики с метками
+	series map[string]lastSample
+	// families типы семейств метрик: Prometheus отправляет метаданные периодически, отдельно от сэмплов
+	families  map[string]MetricType
+	lastSweep time.Time
+	mu        sync.Mutex
+}
+
+func NewReceiver(storage storages.MetricStorage) *Receiver {
+	return &Receiver{
+		storage:  storage,
+		now:      time.Now,
+		series:   make(map[string]lastSample),
+		families: make(map[string]MetricType),
+	}
+}
+
+type timedMetric struct {
+	metric    metrics.Metric
+	timestamp int64
+}
+
+// Write сохраняет сэмплы запроса одним Batch.
+// Состояние счётчиков обновляется только после успешной записи в хранилище.
+func (r *Receiver) Write(ctx context.Context, req *WriteRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	r.sweep(now)
+
+	for _, md := range req.Metadata {
+		r.families[md.FamilyName] = md.Type
+	}
+
+	var (
+		points  []timedMetric
+		updated = make(map[string]lastSample)
+	)
+	for _, ts := range req.Timeseries {
+		familyName, name := seriesName(ts.Labels)
+		if familyName == "" {
+			return ErrIncorrectRequest
+		}
+		if len(name) > metrics.MaxNameLength {
+			return fmt.Errorf("%w: %w: %s", ErrIncorrectRequest, metrics.ErrMetricNameTooLong, name)
+		}
+		counter := r.isCounter(familyName)
+
+		samples := slices.Clone(ts.Samples)
+		slices.SortStableFunc(samples, func(a, b Sample) int {
+			return cmp.Compare(a.Timestamp, b.Timestamp)
+		})
+		for _, sample := range samples {
+			if math.IsNaN(sample.Value) {
+				continue
+			}
+			if !counter {
+				points = append(points, timedMetric{metric: metrics.NewGauge(name, sample.Value), timestamp: sample.Timestamp})
+				continue
+			}
+			delta, ok := r.counterDelta(updated, name, sample, now)
+			if ok {
+				points = append(points, timedMetric{metric: metrics.NewCounter(name, delta), timestamp: sample.Timestamp})
+			}
+		}
+	}
+
+	if len(points) == 0 {
+		return nil
+	}
+
+	// для gauge сохраняется значение с наибольшей временной меткой
+	slices.SortStableFunc(points, func(a, b timedMetric) int {
+		return cmp.Compare(a.timestamp, b.timestamp)
+	})
+	metricSlice := make([]metrics.Metric, 0, len(points))
+	for _, p := range points {
+		metricSlice = append(metricSlice, p.metric)
+	}
+	if err := r.storage.Batch(ctx, metricSlice); err != nil {
+		return err
+	}
+
+	for key, sample := range updated {
+		r.series[key] = sample
+	}
+	return nil
+}
+
+// counterDelta возвращает приращение счётчика и запоминает сэмпл в updated.
+// Приращение вычисляется по округлённым значениям, поэтому сумма приращений равна округлённому последнему значению.
+// Сэмплы не новее последнего сохранённого (повторная отправка) пропускаются. Требует блокировки r.mu.
+func (r *Receiver) counterDelta(updated map[string]lastSample, key string, sample Sample, now time.Time) (int64, bool) {
+	prev, ok := updated[key]
+	if !ok {
+		prev, ok = r.series[key]
+	}
+	if ok && sample.Timestamp <= prev.timestamp {
+		return 0, false
+	}
+	updated[key] = lastSample{updated: now, value: sample.Value, timestamp: sample.Timestamp}
+
+	if !ok || sample.Value < prev.value {
+		return int64(math.Round(sample.Value)), true
+	}
+	return int64(math.Round(sample.Value) - math.Round(prev.value)), true
+}
+
+// sweep удаляет последние сэмплы счётчиков без новых сэмплов в течение seriesIdleTimeout. Требует блокировки r.mu.
+func (r *Receiver) sweep(now time.Time) {
+	if now.Sub(r.lastSweep) <= seriesIdleTimeout {
+		return
+	}
+	r.lastSweep = now
+	for key, sample := range r.series {
+		if now.Sub(sample.updated) > seriesIdleTimeout {
+			delete(r.series, key)
+		}
+	}
+}
+
+// isCounter определяет, относится ли ряд к счётчику. Требует блокировки r.mu.
+func (r *Receiver) isCounter(familyName string) bool {
+	for _, suffix := range append([]string{""}, counterSuffixes...) {
+		base, found := strings.CutSuffix(familyName, suffix)
+		if !found {
+			continue
+		}
+		switch r.families[base] {
+		case CounterType:
+			return suffix == "" || suffix == "_total"
+		case HistogramType, SummaryType:
+			return suffix == "_count" || suffix == "_sum" || suffix == "_bucket"
+		case GaugeType, GaugeHistogramType:
+			return false
+		}
+	}
+
+	for _, suffix := range counterSuffixes {
+		if strings.HasSuffix(familyName, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// seriesName возвращает значение метки __name__ и имя метрики с остальными метками.
+func seriesName(labels []Label) (string, string) {
+	var familyName string
+	pairs := make([]string, 0, len(labels))
+	for _, label := range labels {
+		if label.Name == nameLabel {
+			familyName = label.Value
+			continue
+		}
+		pairs = append(pairs, label.Name+"="+label.Value)
+	}
+	if len(pairs) == 0 {
+		return familyName, familyName
+	}
+
+	slices.SortFunc(pairs, func(a, b string) int {
+		keyA, _, _ := strings.Cut(a, "=")
+		keyB, _, _ := strings.Cut(b, "=")
+		return strings.Compare(keyA, keyB)
+	})
+	return familyName, familyName + "{" + strings.Join(pairs, ",") + "}"
+}
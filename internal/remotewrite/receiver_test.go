@@ -0,0 +1,143 @@
+package remotewrite
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SpaceSlow/execenv/internal/metrics"
+	"github.com/SpaceSlow/execenv/internal/storages"
+)
+
+func series(name string, samples []Sample, labels ...Label) TimeSeries {
+	return TimeSeries{Labels: append([]Label{{Name: nameLabel, Value: name}}, labels...), Samples: samples}
+}
+
+func TestDecodeWriteRequest(t *testing.T) {
+	req := &WriteRequest{
+		Timeseries: []TimeSeries{
+			series("http_requests_total", []Sample{{Value: 1, Timestamp: 1000}, {Value: 2, Timestamp: 2000}}, Label{Name: "code", Value: "200"}),
+			series("temperature", []Sample{{Value: -1.5, Timestamp: 1000}}),
+		},
+		Metadata: []Metadata{{FamilyName: "http_requests", Type: CounterType}},
+	}
+
+	decoded, err := DecodeWriteRequest(req.Encode(), 0)
+	require.NoError(t, err)
+	assert.Equal(t, req, decoded)
+
+	_, err = DecodeWriteRequest([]byte("not snappy"), 0)
+	assert.ErrorIs(t, err, ErrIncorrectRequest)
+
+	// тело, сжатое до нескольких байт, не распаковывается, если после распаковки превышает ограничение
+	bomb := snappy.Encode(nil, make([]byte, 1<<20))
+	_, err = DecodeWriteRequest(bomb, 1<<10)
+	assert.ErrorIs(t, err, ErrRequestTooLarge)
+}
+
+func TestReceiver_Write(t *testing.T) {
+	ctx := context.Background()
+	storage := storages.NewMemStorage()
+	receiver := NewReceiver(storage)
+
+	code := Label{Name: "code", Value: "200"}
+	requests := []*WriteRequest{
+		{
+			Timeseries: []TimeSeries{
+				series("http_requests_total", []Sample{{Value: 5, Timestamp: 1000}}, code),
+				series("temperature", []Sample{{Value: 20, Timestamp: 2000}, {Value: 10, Timestamp: 1000}}),
+				series("jobs", []Sample{{Value: 3, Timestamp: 1000}}),
+				series("latency_seconds_count", []Sample{{Value: 4, Timestamp: 1000}}),
+				series("queue_size_total", []Sample{{Value: 7, Timestamp: 1000}}),
+			},
+			Metadata: []Metadata{
+				{FamilyName: "jobs", Type: CounterType},
+				{FamilyName: "queue_size_total", Type: GaugeType},
+			},
+		},
+		{
+			Timeseries: []TimeSeries{
+				// повторная отправка сэмпла, сэмплы не по порядку, метаданные отправлены только в первом запросе
+				series("http_requests_total", []Sample{{Value: 12, Timestamp: 3000}, {Value: 5, Timestamp: 1000}, {Value: 8, Timestamp: 2000}}, code),
+				series("temperature", []Sample{{Value: math.NaN(), Timestamp: 3000}}),
+				series("jobs", []Sample{{Value: 4, Timestamp: 2000}}),
+			},
+		},
+		{
+			// сброс счётчика
+			Timeseries: []TimeSeries{series("http_requests_total", []Sample{{Value: 3, Timestamp: 4000}}, code)},
+		},
+	}
+	for _, req := range requests {
+		require.NoError(t, receiver.Write(ctx, req))
+	}
+
+	list, err := storage.List(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []metrics.Metric{
+		metrics.NewCounter("http_requests_total{code=200}", 12+3),
+		metrics.NewGauge("temperature", 20),
+		metrics.NewCounter("jobs", 4),
+		metrics.NewCounter("latency_seconds_count", 4),
+		metrics.NewGauge("queue_size_total", 7),
+	}, list)
+
+	err = receiver.Write(ctx, &WriteRequest{Timeseries: []TimeSeries{{Labels: []Label{code}, Samples: []Sample{{Value: 1}}}}})
+	assert.ErrorIs(t, err, ErrIncorrectRequest)
+
+	long := Label{Name: "path", Value: strings.Repeat("a", metrics.MaxNameLength)}
+	err = receiver.Write(ctx, &WriteRequest{Timeseries: []TimeSeries{series("http_requests_total", []Sample{{Value: 1, Timestamp: 5000}}, long)}})
+	assert.ErrorIs(t, err, ErrIncorrectRequest)
+	assert.ErrorIs(t, err, metrics.ErrMetricNameTooLong)
+}
+
+func TestReceiver_WriteFractional(t *testing.T) {
+	ctx := context.Background()
+	storage := storages.NewMemStorage()
+	receiver := NewReceiver(storage)
+
+	for i := 1; i <= 5; i++ {
+		req := &WriteRequest{Timeseries: []TimeSeries{
+			series("cpu_seconds_total", []Sample{{Value: 0.4 * float64(i), Timestamp: int64(i)}}),
+		}}
+		require.NoError(t, receiver.Write(ctx, req))
+	}
+
+	// 5 сэмплов с приращением 0.4 дают 2
+	metric, err := storage.Get(ctx, metrics.Counter, "cpu_seconds_total")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), metric.Delta)
+}
+
+func TestReceiver_WriteIdleSeries(t *testing.T) {
+	ctx := context.Background()
+	storage := storages.NewMemStorage()
+	receiver := NewReceiver(storage)
+	now := time.Unix(0, 0)
+	receiver.now = func() time.Time { return now }
+
+	write := func(name string, value float64, timestamp int64) {
+		t.Helper()
+		req := &WriteRequest{Timeseries: []TimeSeries{series(name, []Sample{{Value: value, Timestamp: timestamp}})}}
+		require.NoError(t, receiver.Write(ctx, req))
+	}
+
+	write("http_requests_total", 5, 1000)
+	require.Len(t, receiver.series, 1)
+
+	now = now.Add(2 * seriesIdleTimeout)
+	write("http_errors_total", 1, 1000)
+	assert.Len(t, receiver.series, 1, "idle series must be removed")
+
+	// после удаления состояния сэмпл ряда считается первым
+	write("http_requests_total", 7, 2000)
+	metric, err := storage.Get(ctx, metrics.Counter, "http_requests_total")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5+7), metric.Delta)
+}
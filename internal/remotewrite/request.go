@@ -0,0 +1,218 @@
+package remotewrite
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/golang/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+var (
+	ErrIncorrectRequest = errors.New("incorrect remote write request")
+	// ErrRequestTooLarge распакованное тело запроса больше допустимого размера.
+	ErrRequestTooLarge = errors.New("decoded remote write request is too large")
+)
+
+// MetricType тип семейства метрик из метаданных запроса (значения совпадают с prometheus.MetricMetadata.MetricType).
+type MetricType int32
+
+const (
+	UnknownType MetricType = iota
+	CounterType
+	GaugeType
+	HistogramType
+	GaugeHistogramType
+	SummaryType
+)
+
+type Label struct {
+	Name  string
+	Value string
+}
+
+type Sample struct {
+	Value     float64
+	Timestamp int64
+}
+
+type TimeSeries struct {
+	Labels  []Label
+	Samples []Sample
+}
+
+type Metadata struct {
+	FamilyName string
+	Type       MetricType
+}
+
+// WriteRequest поддерживаемое подмножество prometheus.WriteRequest (remote write 1.0).
+// Exemplars и native histograms пропускаются.
+type WriteRequest struct {
+	Timeseries []TimeSeries
+	Metadata   []Metadata
+}
+
+// DecodeWriteRequest распаковывает (snappy, block format) и разбирает тело запроса remote write.
+// Если maxSize > 0, тело, размер которого после распаковки больше maxSize, не распаковывается (ErrRequestTooLarge):
+// snappy.Decode выделяет память под размер, указанный в заголовке сжатых данных.
+func DecodeWriteRequest(compressed []byte, maxSize int64) (*WriteRequest, error) {
+	decodedLen, err := snappy.DecodedLen(compressed)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrIncorrectRequest, err)
+	}
+	if maxSize > 0 && int64(decodedLen) > maxSize {
+		return nil, fmt.Errorf("%w: %d bytes exceeds %d bytes", ErrRequestTooLarge, decodedLen, maxSize)
+	}
+
+	data, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrIncorrectRequest, err)
+	}
+
+	var req WriteRequest
+	err = decodeMessage(data, func(num protowire.Number, typ protowire.Type, b []byte) error {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			ts, err := decodeTimeSeries(b)
+			if err != nil {
+				return err
+			}
+			req.Timeseries = append(req.Timeseries, ts)
+		case num == 3 && typ == protowire.BytesType:
+			md, err := decodeMetadata(b)
+			if err != nil {
+				return err
+			}
+			req.Metadata = append(req.Metadata, md)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrIncorrectRequest, err)
+	}
+	return &req, nil
+}
+
+func decodeTimeSeries(data []byte) (TimeSeries, error) {
+	var ts TimeSeries
+	err := decodeMessage(data, func(num protowire.Number, typ protowire.Type, b []byte) error {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			var label Label
+			err := decodeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) error {
+				switch {
+				case num == 1 && typ == protowire.BytesType:
+					label.Name = string(b)
+				case num == 2 && typ == protowire.BytesType:
+					label.Value = string(b)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			ts.Labels = append(ts.Labels, label)
+		case num == 2 && typ == protowire.BytesType:
+			var sample Sample
+			err := decodeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) error {
+				switch {
+				case num == 1 && typ == protowire.Fixed64Type:
+					v, _ := protowire.ConsumeFixed64(b)
+					sample.Value = math.Float64frombits(v)
+				case num == 2 && typ == protowire.VarintType:
+					v, _ := protowire.ConsumeVarint(b)
+					sample.Timestamp = int64(v)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			ts.Samples = append(ts.Samples, sample)
+		}
+		return nil
+	})
+	return ts, err
+}
+
+func decodeMetadata(data []byte) (Metadata, error) {
+	var md Metadata
+	err := decodeMessage(data, func(num protowire.Number, typ protowire.Type, b []byte) error {
+		switch {
+		case num == 1 && typ == protowire.VarintType:
+			v, _ := protowire.ConsumeVarint(b)
+			md.Type = MetricType(v)
+		case num == 2 && typ == protowire.BytesType:
+			md.FamilyName = string(b)
+		}
+		return nil
+	})
+	return md, err
+}
+
+// decodeMessage вызывает field для каждого поля сообщения.
+// Для полей типа bytes передаётся содержимое поля, для остальных — закодированное значение.
+func decodeMessage(data []byte, field func(num protowire.Number, typ protowire.Type, b []byte) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		m := protowire.ConsumeFieldValue(num, typ, data)
+		if m < 0 {
+			return protowire.ParseError(m)
+		}
+		value := data[:m]
+		if typ == protowire.BytesType {
+			value, _ = protowire.ConsumeBytes(value)
+		}
+		data = data[m:]
+
+		if err := field(num, typ, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Encode кодирует запрос в формат remote write (protobuf, сжатый snappy).
+func (r *WriteRequest) Encode() []byte {
+	var data []byte
+	for _, ts := range r.Timeseries {
+		var series []byte
+		for _, label := range ts.Labels {
+			var l []byte
+			l = protowire.AppendTag(l, 1, protowire.BytesType)
+			l = protowire.AppendString(l, label.Name)
+			l = protowire.AppendTag(l, 2, protowire.BytesType)
+			l = protowire.AppendString(l, label.Value)
+			series = protowire.AppendTag(series, 1, protowire.BytesType)
+			series = protowire.AppendBytes(series, l)
+		}
+		for _, sample := range ts.Samples {
+			var s []byte
+			s = protowire.AppendTag(s, 1, protowire.Fixed64Type)
+			s = protowire.AppendFixed64(s, math.Float64bits(sample.Value))
+			s = protowire.AppendTag(s, 2, protowire.VarintType)
+			s = protowire.AppendVarint(s, uint64(sample.Timestamp))
+			series = protowire.AppendTag(series, 2, protowire.BytesType)
+			series = protowire.AppendBytes(series, s)
+		}
+		data = protowire.AppendTag(data, 1, protowire.BytesType)
+		data = protowire.AppendBytes(data, series)
+	}
+	for _, md := range r.Metadata {
+		var m []byte
+		m = protowire.AppendTag(m, 1, protowire.VarintType)
+		m = protowire.AppendVarint(m, uint64(md.Type))
+		m = protowire.AppendTag(m, 2, protowire.BytesType)
+		m = protowire.AppendString(m, md.FamilyName)
+		data = protowire.AppendTag(data, 3, protowire.BytesType)
+		data = protowire.AppendBytes(data, m)
+	}
+	return snappy.Encode(nil, data)
+}
@@ -1,30 +1,82 @@
 package routers
 
 import (
+	"context"
+	"net/http"
+
 	"github.com/go-chi/chi/v5"
 
+	"github.com/SpaceSlow/execenv/internal/auth"
+	"github.com/SpaceSlow/execenv/internal/dashboard"
 	"github.com/SpaceSlow/execenv/internal/handlers"
+	"github.com/SpaceSlow/execenv/internal/health"
+	"github.com/SpaceSlow/execenv/internal/influx"
+	"github.com/SpaceSlow/execenv/internal/middlewares"
+	"github.com/SpaceSlow/execenv/internal/openapi"
+	"github.com/SpaceSlow/execenv/internal/otlp"
+	"github.com/SpaceSlow/execenv/internal/remotewrite"
 	"github.com/SpaceSlow/execenv/internal/storages"
 )
 
-func MetricRouter(storage storages.MetricStorage) chi.Router {
+// MetricRouter возвращает роутер HTTP API сервера метрик, описание API — openapi.Spec.
+func MetricRouter(storage storages.MetricStorage, opts ...Option) chi.Router {
+	var options routerOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if options.healthRegistry == nil {
+		options.healthRegistry = health.NewRegistry()
+		options.healthRegistry.Register("storage", health.CheckerFunc(func(ctx context.Context) error {
+			return storages.CheckConnection(ctx, storage)
+		}))
+	}
+
+	spec := openapi.Spec()
 	r := chi.NewRouter()
+	if options.validateRequests {
+		r.Use(spec.Validator)
+	}
+
+	read := middlewares.WithAuthorization(options.tokens, auth.ScopeRead)
+	write := middlewares.WithAuthorization(options.tokens, auth.ScopeWrite)
 
 	r.Route("/", func(r chi.Router) {
-		r.Get("/", handlers.MetricHandler{MetricStorage: storage}.List)
+		r.Get("/", listMetrics(read(http.HandlerFunc(handlers.MetricHandler{MetricStorage: storage}.List))))
 		r.Get("/ping", handlers.NewCheckConnectionHandler(storage).Ping)
+		r.Get("/healthz", handlers.HealthHandler{Registry: options.healthRegistry}.Healthz)
+		r.Get("/readyz", handlers.HealthHandler{Registry: options.healthRegistry}.Readyz)
+		r.Method(http.MethodGet, "/openapi.json", spec)
+		r.Get("/dashboard/{file}", dashboard.Handler)
 
 		r.Route("/update/", func(r chi.Router) {
+			r.Use(write)
 			r.Post("/{type}/{name}/{value}", handlers.MetricHandler{MetricStorage: storage}.Post)
 			r.Post("/{type}/{name}/", handlers.BadRequestHandlerFunc)
 			r.Post("/", handlers.JSONMetricHandler{MetricStorage: storage}.Post)
 		})
-		r.Post("/updates/", handlers.JSONMetricHandler{MetricStorage: storage}.BatchPost)
+		r.With(write).Post("/updates/", handlers.JSONMetricHandler{MetricStorage: storage}.BatchPost)
 		r.Route("/value/", func(r chi.Router) {
+			r.Use(read)
 			r.Get("/{type}/{name}", handlers.MetricHandler{MetricStorage: storage}.Get)
 			r.Post("/", handlers.JSONMetricHandler{MetricStorage: storage}.Get)
 		})
+		r.With(read).Get("/api/v2/metrics", handlers.APIv2Handler{MetricStorage: storage}.List)
+		r.With(write).Post("/v1/metrics", handlers.OTLPHandler{Receiver: otlp.NewReceiver(storage)}.Post)
+		r.With(write).Post("/api/v1/write", handlers.RemoteWriteHandler{Receiver: remotewrite.NewReceiver(storage), MaxRequestSize: options.maxRequestSize}.Post)
+		r.With(write).Post("/api/v2/write", handlers.InfluxHandler{Receiver: influx.NewReceiver(storage, options.influxRules)}.Write)
 	})
 
 	return r
 }
+
+// listMetrics отдаёт веб-панель без проверки токена: данные панель получает через /api/v2/metrics.
+func listMetrics(list http.Handler) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		if dashboard.AcceptsHTML(req) {
+			dashboard.ServeIndex(res, req)
+			return
+		}
+		list.ServeHTTP(res, req)
+	}
+}
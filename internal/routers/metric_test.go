@@ -1,16 +1,31 @@
 package routers
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	collectorpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 
+	"github.com/SpaceSlow/execenv/internal/auth"
+	"github.com/SpaceSlow/execenv/internal/health"
+	"github.com/SpaceSlow/execenv/internal/influx"
 	"github.com/SpaceSlow/execenv/internal/metrics"
+	"github.com/SpaceSlow/execenv/internal/openapi"
+	"github.com/SpaceSlow/execenv/internal/problems"
+	"github.com/SpaceSlow/execenv/internal/remotewrite"
 	"github.com/SpaceSlow/execenv/internal/storages"
 )
 
@@ -37,7 +52,7 @@ func newMemStorageWithMetrics(metrics []metrics.Metric) *storages.MemStorage {
 	storage := storages.NewMemStorage()
 
 	for _, metric := range metrics {
-		storage.Add(&metric)
+		storage.Add(context.Background(), &metric)
 	}
 
 	return storage
@@ -188,7 +203,7 @@ func TestMetricRouter(t *testing.T) {
 					{
 						Type:  metrics.Counter,
 						Name:  "PollCount",
-						Value: int64(15),
+						Delta: 15,
 					},
 				}),
 				path: "/value/counter/PollCount",
@@ -244,7 +259,7 @@ func TestMetricRouter(t *testing.T) {
 					{
 						Type:  metrics.Counter,
 						Name:  "PollCount",
-						Value: int64(10),
+						Delta: 10,
 					},
 				}),
 				path: "/",
@@ -262,7 +277,7 @@ func TestMetricRouter(t *testing.T) {
 					{
 						Type:  metrics.Counter,
 						Name:  "PollCount",
-						Value: int64(10),
+						Delta: 10,
 					},
 				}),
 				path: "/update/",
@@ -329,7 +344,7 @@ func TestMetricRouter(t *testing.T) {
 					{
 						Type:  metrics.Counter,
 						Name:  "PollCount",
-						Value: int64(10),
+						Delta: 10,
 					},
 				}),
 				path: "/value/",
@@ -373,3 +388,568 @@ func TestMetricRouter(t *testing.T) {
 		})
 	}
 }
+
+func TestMetricRouter_BatchPost(t *testing.T) {
+	body := `[
+		{"id": "PollCount", "type": "counter", "delta": 2},
+		{"id": "RandomValue", "type": "gauge"},
+		{"id": "NewGauge", "type": "gauge", "value": 1.5},
+		{"id": "Unknown", "type": "histogram", "value": 1}
+	]`
+	initial := []metrics.Metric{metrics.NewCounter("PollCount", 5)}
+
+	tests := []struct {
+		name        string
+		query       string
+		wantStatus  int
+		wantBody    string
+		wantMetrics []metrics.Metric
+	}{
+		{
+			name:        "atomic batch with incorrect metrics",
+			wantStatus:  http.StatusBadRequest,
+			wantMetrics: initial,
+		},
+		{
+			name:       "partial batch with incorrect metrics",
+			query:      "?partial=true",
+			wantStatus: http.StatusOK,
+			wantBody: `{"accepted": 2, "errors": [
+				{"index": 1, "error": "incorrect metric value", "code": "incorrect_metric_value"},
+				{"index": 3, "error": "unknown metric type", "code": "unknown_metric_type"}
+			]}`,
+			wantMetrics: []metrics.Metric{
+				metrics.NewCounter("PollCount", 7),
+				metrics.NewGauge("NewGauge", 1.5),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := newMemStorageWithMetrics(initial)
+			ts := httptest.NewServer(MetricRouter(storage))
+			defer ts.Close()
+
+			res, err := ts.Client().Post(ts.URL+"/updates/"+tt.query, "application/json", strings.NewReader(body))
+			require.NoError(t, err)
+			resBody, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, string(resBody))
+			}
+
+			list, err := storage.List(context.Background())
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tt.wantMetrics, list)
+		})
+	}
+}
+
+func TestMetricRouter_Problems(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantCode   problems.Code
+		wantStatus int
+	}{
+		{name: "unknown metric type", method: http.MethodPost, path: "/update/unknown_type/metric/42", wantStatus: http.StatusBadRequest, wantCode: problems.CodeUnknownMetricType},
+		{name: "incorrect metric value", method: http.MethodPost, path: "/update/counter/metric/4.2", wantStatus: http.StatusBadRequest, wantCode: problems.CodeIncorrectMetricValue},
+		{name: "metric not found", method: http.MethodGet, path: "/value/gauge/unknown", wantStatus: http.StatusNotFound, wantCode: problems.CodeMetricNotFound},
+		{name: "json without value", method: http.MethodPost, path: "/update/", body: `{"id": "metric", "type": "gauge"}`, wantStatus: http.StatusBadRequest, wantCode: problems.CodeIncorrectMetricValue},
+		{name: "malformed json", method: http.MethodPost, path: "/updates/", body: `[{`, wantStatus: http.StatusBadRequest, wantCode: problems.CodeBadRequest},
+		{name: "batch with unknown type", method: http.MethodPost, path: "/updates/", body: `[{"id": "metric", "type": "histogram", "value": 1}]`, wantStatus: http.StatusBadRequest, wantCode: problems.CodeUnknownMetricType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(MetricRouter(storages.NewMemStorage()))
+			defer ts.Close()
+
+			req, err := http.NewRequest(tt.method, ts.URL+tt.path, strings.NewReader(tt.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			res, err := ts.Client().Do(req)
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			assert.Equal(t, problems.ContentType, res.Header.Get("Content-Type"))
+			var problem problems.Problem
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&problem))
+			assert.Equal(t, tt.wantCode, problem.Code)
+			assert.Equal(t, tt.wantStatus, problem.Status)
+			assert.Equal(t, tt.path, problem.Instance)
+		})
+	}
+}
+
+func TestMetricRouter_OpenAPI(t *testing.T) {
+	router := MetricRouter(storages.NewMemStorage())
+	spec := openapi.Spec()
+
+	routes := make(map[string]bool)
+	err := chi.Walk(router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		routes[strings.ToLower(method)+" "+route] = true
+		return nil
+	})
+	require.NoError(t, err)
+
+	documented := make(map[string]bool)
+	for path, item := range spec.Paths {
+		for method := range item {
+			documented[method+" "+path] = true
+		}
+	}
+	assert.Equal(t, routes, documented, "routes of MetricRouter and openapi.Spec must be in sync")
+
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+	res, err := ts.Client().Get(ts.URL + "/openapi.json")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+
+	var doc openapi.Document
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&doc))
+	assert.Equal(t, openapi.Version, doc.OpenAPI)
+	assert.Equal(t, spec.Paths, doc.Paths)
+}
+
+func TestMetricRouter_Auth(t *testing.T) {
+	newToken := func(name, token string, scopes ...auth.Scope) auth.Token {
+		return auth.Token{Name: name, Hash: auth.HashToken(token), Scopes: scopes}
+	}
+	tokens := auth.Tokens{
+		newToken("reader", "read-secret", auth.ScopeRead),
+		newToken("writer", "write-secret", auth.ScopeWrite),
+		newToken("root", "admin-secret", auth.ScopeAdmin),
+	}
+	ts := httptest.NewServer(MetricRouter(storages.NewMemStorage(), WithTokens(tokens)))
+	defer ts.Close()
+
+	pathReplacer := strings.NewReplacer("{type}", "counter", "{name}", "PollCount", "{value}", "1", "{file}", "dashboard.js")
+	do := func(method, path, token string) int {
+		req, err := http.NewRequest(strings.ToUpper(method), ts.URL+pathReplacer.Replace(path), strings.NewReader("[]"))
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", auth.BearerAuthorization(token))
+		}
+		res, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		return res.StatusCode
+	}
+
+	// области доступа операций берутся из описания API, что проверяет и их соответствие роутеру
+	for path, item := range openapi.Spec().Paths {
+		for method, operation := range item {
+			t.Run(method+" "+path, func(t *testing.T) {
+				if operation.Scope == "" {
+					assert.NotEqual(t, http.StatusUnauthorized, do(method, path, ""), "public operation")
+					return
+				}
+
+				assert.Equal(t, http.StatusUnauthorized, do(method, path, ""))
+				assert.Equal(t, http.StatusUnauthorized, do(method, path, "unknown"))
+
+				allowed, denied := "read-secret", "write-secret"
+				if operation.Scope == string(auth.ScopeWrite) {
+					allowed, denied = denied, allowed
+				}
+				assert.NotContains(t, []int{http.StatusUnauthorized, http.StatusForbidden}, do(method, path, allowed))
+				assert.Equal(t, http.StatusForbidden, do(method, path, denied))
+				assert.NotContains(t, []int{http.StatusUnauthorized, http.StatusForbidden}, do(method, path, "admin-secret"))
+			})
+		}
+	}
+
+	t.Run("dashboard is public", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, ts.URL+"/", nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept", "text/html")
+		res, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+}
+
+func TestMetricRouter_RequestValidation(t *testing.T) {
+	body := `{"id": "RandomValue", "type": "gauge", "value": 1.5, "delta": 1}`
+	tests := []struct {
+		name       string
+		enabled    bool
+		wantStatus int
+	}{
+		{name: "validation disabled", enabled: false, wantStatus: http.StatusOK},
+		{name: "validation enabled", enabled: true, wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(MetricRouter(storages.NewMemStorage(), WithRequestValidation(tt.enabled)))
+			defer ts.Close()
+
+			res, err := ts.Client().Post(ts.URL+"/update/", "application/json", strings.NewReader(body))
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestMetricRouter_Dashboard(t *testing.T) {
+	ts := httptest.NewServer(MetricRouter(newMemStorageWithMetrics([]metrics.Metric{metrics.NewCounter("PollCount", 10)})))
+	defer ts.Close()
+
+	tests := []struct {
+		name            string
+		path            string
+		accept          string
+		wantContentType string
+		wantBody        string
+	}{
+		{name: "browser gets dashboard", path: "/", accept: "text/html,*/*;q=0.8", wantContentType: "text/html; charset=utf-8", wantBody: "<!DOCTYPE html>"},
+		{name: "other clients get text list", path: "/", wantContentType: "text/plain; charset=utf-8", wantBody: "PollCount = 10 (counter)\n"},
+		{name: "dashboard script", path: "/dashboard/dashboard.js", wantContentType: "text/javascript; charset=utf-8", wantBody: "/api/v2/metrics"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, ts.URL+tt.path, nil)
+			require.NoError(t, err)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			res, err := ts.Client().Do(req)
+			require.NoError(t, err)
+			body, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			assert.Equal(t, tt.wantContentType, res.Header.Get("Content-Type"))
+			assert.Contains(t, string(body), tt.wantBody)
+		})
+	}
+}
+
+func TestMetricRouter_Health(t *testing.T) {
+	memFileStorage, err := storages.NewMemFileStorage(context.Background(), "", 0, false)
+	require.NoError(t, err)
+
+	persistence := health.NewState(nil)
+	registry := health.NewRegistry()
+	registry.Register("storage", health.CheckerFunc(func(ctx context.Context) error {
+		return storages.CheckConnection(ctx, memFileStorage)
+	}))
+	registry.Register("persistence", persistence)
+
+	ts := httptest.NewServer(MetricRouter(memFileStorage, WithHealthRegistry(registry)))
+	defer ts.Close()
+
+	get := func(path string) (int, health.Report) {
+		res, err := ts.Client().Get(ts.URL + path)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		var report health.Report
+		if res.Header.Get("Content-Type") == "application/json" {
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&report))
+		}
+		return res.StatusCode, report
+	}
+
+	status, _ := get("/ping")
+	assert.Equal(t, http.StatusOK, status, "storage without connection check")
+
+	status, report := get("/healthz")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, health.Report{
+		Components: map[string]health.ComponentReport{
+			"storage":     {Status: health.StatusUp},
+			"persistence": {Status: health.StatusUp},
+		},
+		Status: health.StatusUp,
+	}, report)
+	status, _ = get("/readyz")
+	assert.Equal(t, http.StatusOK, status)
+
+	persistence.Set(errors.New("disk full"))
+	status, report = get("/healthz")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, health.ComponentReport{Status: health.StatusDown, Error: "disk full"}, report.Components["persistence"])
+	status, _ = get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+
+	persistence.Set(nil)
+	registry.StartDraining()
+	status, _ = get("/healthz")
+	assert.Equal(t, http.StatusOK, status)
+	status, report = get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, health.ErrDraining.Error(), report.Error)
+}
+
+func TestMetricRouter_InfluxWrite(t *testing.T) {
+	var rules influx.Rules
+	require.NoError(t, rules.Set("net.bytes_*=counter"))
+
+	tests := []struct {
+		name        string
+		query       string
+		body        string
+		wantStatus  int
+		wantMetrics []metrics.Metric
+	}{
+		{
+			name:  "points with tags and timestamps",
+			query: "?org=org&bucket=bucket&precision=s",
+			body: "cpu,host=a usage_idle=90.5,usage_user=9.5 1700000002\n" +
+				"cpu,host=a usage_idle=80 1700000001\n" +
+				"net,host=a bytes_recv=100i,status=\"ok\" 1700000001\n",
+			wantStatus: http.StatusNoContent,
+			wantMetrics: []metrics.Metric{
+				metrics.NewGauge("cpu.usage_idle{host=a}", 90.5),
+				metrics.NewGauge("cpu.usage_user{host=a}", 9.5),
+				metrics.NewCounter("net.bytes_recv{host=a}", 100),
+			},
+		},
+		{
+			name:       "incorrect precision",
+			query:      "?precision=h",
+			body:       "cpu usage_idle=90.5",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "incorrect line",
+			body:       "cpu usage_idle=90.5\ncpu",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := storages.NewMemStorage()
+			ts := httptest.NewServer(MetricRouter(storage, WithInfluxRules(rules)))
+			defer ts.Close()
+
+			res, err := ts.Client().Post(ts.URL+"/api/v2/write"+tt.query, "text/plain; charset=utf-8", strings.NewReader(tt.body))
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+
+			list, err := storage.List(context.Background())
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tt.wantMetrics, list)
+		})
+	}
+}
+
+func TestMetricRouter_OTLPMetrics(t *testing.T) {
+	exportReq := &collectorpb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*metricspb.ResourceMetrics{{
+			ScopeMetrics: []*metricspb.ScopeMetrics{{
+				Metrics: []*metricspb.Metric{{
+					Name: "cpu.usage",
+					Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{DataPoints: []*metricspb.NumberDataPoint{
+						{Value: &metricspb.NumberDataPoint_AsDouble{AsDouble: 0.5}},
+					}}},
+				}},
+			}},
+		}},
+	}
+	protobufBody, err := proto.Marshal(exportReq)
+	require.NoError(t, err)
+	jsonBody, err := protojson.Marshal(exportReq)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		wantStatus  int
+		wantMetrics []metrics.Metric
+	}{
+		{
+			name:        "protobuf request",
+			contentType: "application/x-protobuf",
+			body:        protobufBody,
+			wantStatus:  http.StatusOK,
+			wantMetrics: []metrics.Metric{metrics.NewGauge("cpu.usage", 0.5)},
+		},
+		{
+			name:        "json request",
+			contentType: "application/json",
+			body:        jsonBody,
+			wantStatus:  http.StatusOK,
+			wantMetrics: []metrics.Metric{metrics.NewGauge("cpu.usage", 0.5)},
+		},
+		{
+			name:        "incorrect protobuf request",
+			contentType: "application/x-protobuf",
+			body:        []byte{0xff, 0xff},
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "unsupported content type",
+			contentType: "text/plain",
+			body:        jsonBody,
+			wantStatus:  http.StatusUnsupportedMediaType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := storages.NewMemStorage()
+			ts := httptest.NewServer(MetricRouter(storage))
+			defer ts.Close()
+
+			res, err := ts.Client().Post(ts.URL+"/v1/metrics", tt.contentType, bytes.NewReader(tt.body))
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, tt.contentType, res.Header.Get("Content-Type"))
+			}
+
+			list, err := storage.List(context.Background())
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tt.wantMetrics, list)
+		})
+	}
+}
+
+func TestMetricRouter_RemoteWrite(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        []byte
+		wantStatus  int
+		wantMetrics []metrics.Metric
+	}{
+		{
+			name: "correct request",
+			body: (&remotewrite.WriteRequest{Timeseries: []remotewrite.TimeSeries{{
+				Labels:  []remotewrite.Label{{Name: "__name__", Value: "up"}, {Name: "job", Value: "node"}},
+				Samples: []remotewrite.Sample{{Value: 1, Timestamp: 1000}},
+			}}}).Encode(),
+			wantStatus:  http.StatusNoContent,
+			wantMetrics: []metrics.Metric{metrics.NewGauge("up{job=node}", 1)},
+		},
+		{
+			name: "series without name",
+			body: (&remotewrite.WriteRequest{Timeseries: []remotewrite.TimeSeries{{
+				Labels:  []remotewrite.Label{{Name: "job", Value: "node"}},
+				Samples: []remotewrite.Sample{{Value: 1, Timestamp: 1000}},
+			}}}).Encode(),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "not compressed request",
+			body:       []byte("incorrect"),
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := storages.NewMemStorage()
+			ts := httptest.NewServer(MetricRouter(storage))
+			defer ts.Close()
+
+			res, err := ts.Client().Post(ts.URL+"/api/v1/write", "application/x-protobuf", bytes.NewReader(tt.body))
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+
+			list, err := storage.List(context.Background())
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tt.wantMetrics, list)
+		})
+	}
+}
+
+func TestMetricRouter_APIv2Metrics(t *testing.T) {
+	storage := newMemStorageWithMetrics([]metrics.Metric{
+		metrics.NewCounter("PollCount", 5),
+		metrics.NewGauge("RandomValue", 1.5),
+		metrics.NewGauge("HeapAlloc", 2),
+		metrics.NewCounter("HeapObjects", 3),
+	})
+	ts := httptest.NewServer(MetricRouter(storage))
+	defer ts.Close()
+
+	get := func(t *testing.T, query string, header http.Header) (*http.Response, string) {
+		req, err := http.NewRequest(http.MethodGet, ts.URL+"/api/v2/metrics"+query, nil)
+		require.NoError(t, err)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		res, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		return res, string(body)
+	}
+
+	t.Run("filters", func(t *testing.T) {
+		res, body := get(t, "?type=gauge&sort=-name", nil)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.JSONEq(t, `{"metrics": [
+			{"id": "RandomValue", "type": "gauge", "value": 1.5},
+			{"id": "HeapAlloc", "type": "gauge", "value": 2}
+		]}`, body)
+
+		_, body = get(t, "?prefix=Heap&regex=Objects$", nil)
+		assert.JSONEq(t, `{"metrics": [{"id": "HeapObjects", "type": "counter", "delta": 3}]}`, body)
+
+		_, body = get(t, "?prefix=Unknown", nil)
+		assert.JSONEq(t, `{"metrics": []}`, body)
+	})
+
+	t.Run("pagination", func(t *testing.T) {
+		var (
+			names  []string
+			cursor string
+		)
+		for i := 0; i < 3; i++ {
+			res, body := get(t, "?limit=3&cursor="+cursor, nil)
+			require.Equal(t, http.StatusOK, res.StatusCode)
+
+			var page struct {
+				NextCursor string            `json:"next_cursor"`
+				Metrics    []json.RawMessage `json:"metrics"`
+			}
+			require.NoError(t, json.Unmarshal([]byte(body), &page))
+			for _, raw := range page.Metrics {
+				var metric metrics.Metric
+				require.NoError(t, json.Unmarshal(raw, &metric))
+				names = append(names, metric.Name)
+			}
+			if cursor = page.NextCursor; cursor == "" {
+				break
+			}
+		}
+		assert.Equal(t, []string{"HeapAlloc", "HeapObjects", "PollCount", "RandomValue"}, names)
+	})
+
+	t.Run("etag", func(t *testing.T) {
+		res, _ := get(t, "", nil)
+		etag := res.Header.Get("ETag")
+		require.NotEmpty(t, etag)
+
+		res, body := get(t, "", http.Header{"If-None-Match": {etag}})
+		assert.Equal(t, http.StatusNotModified, res.StatusCode)
+		assert.Empty(t, body)
+
+		res, _ = get(t, "?type=counter", http.Header{"If-None-Match": {etag}})
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	t.Run("incorrect parameters", func(t *testing.T) {
+		for _, query := range []string{"?type=unknown", "?regex=(", "?sort=value", "?limit=0", "?limit=1001", "?cursor=incorrect"} {
+			res, _ := get(t, query, nil)
+			assert.Equal(t, http.StatusBadRequest, res.StatusCode, query)
+		}
+	})
+}
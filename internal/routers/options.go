@@ -0,0 +1,55 @@
+package routers
+
+import (
+	"github.com/SpaceSlow/execenv/internal/auth"
+	"github.com/SpaceSlow/execenv/internal/health"
+	"github.com/SpaceSlow/execenv/internal/influx"
+)
+
+type routerOptions struct {
+	healthRegistry   *health.Registry
+	tokens           auth.Tokens
+	influxRules      influx.Rules
+	maxRequestSize   int64
+	validateRequests bool
+}
+
+// Option дополнительная настройка MetricRouter.
+type Option func(o *routerOptions)
+
+// WithInfluxRules задаёт правила преобразования полей InfluxDB line protocol в метрики.
+func WithInfluxRules(rules influx.Rules) Option {
+	return func(o *routerOptions) {
+		o.influxRules = rules
+	}
+}
+
+// WithRequestValidation включает проверку JSON-тел запросов на соответствие описанию API (openapi.Spec).
+func WithRequestValidation(enabled bool) Option {
+	return func(o *routerOptions) {
+		o.validateRequests = enabled
+	}
+}
+
+// WithHealthRegistry задаёт реестр состояния компонентов для /healthz и /readyz.
+// По умолчанию реестр содержит только проверку соединения с хранилищем.
+func WithHealthRegistry(registry *health.Registry) Option {
+	return func(o *routerOptions) {
+		o.healthRegistry = registry
+	}
+}
+
+// WithTokens включает аутентификацию запросов по API-токенам: чтение метрик требует области доступа read,
+// запись — write. Проверки состояния, описание API и файлы веб-панели доступны без токена.
+func WithTokens(tokens auth.Tokens) Option {
+	return func(o *routerOptions) {
+		o.tokens = tokens
+	}
+}
+
+// WithMaxRequestSize ограничивает размер распакованного тела запросов remote write (сжатых snappy).
+func WithMaxRequestSize(size int64) Option {
+	return func(o *routerOptions) {
+		o.maxRequestSize = size
+	}
+}
@@ -0,0 +1,78 @@
+package selfmetrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/SpaceSlow/execenv/internal/logger"
+	"github.com/SpaceSlow/execenv/internal/metrics"
+)
+
+// Batcher хранилище, в которое записываются внутренние метрики (реализуется storages.MetricStorage).
+type Batcher interface {
+	Batch(ctx context.Context, metrics []metrics.Metric) error
+}
+
+// Flusher периодически записывает накопленные внутренние метрики в хранилище.
+type Flusher struct {
+	recorder  *Recorder
+	storage   Batcher
+	done      chan struct{}
+	stopped   chan struct{}
+	interval  time.Duration
+	closeOnce sync.Once
+}
+
+func NewFlusher(recorder *Recorder, storage Batcher, interval time.Duration) *Flusher {
+	return &Flusher{
+		recorder: recorder,
+		storage:  storage,
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+		interval: interval,
+	}
+}
+
+// Run периодически сбрасывает метрики и блокируется до вызова Shutdown.
+func (f *Flusher) Run() error {
+	defer close(f.stopped)
+
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			f.flush()
+		case <-f.done:
+			f.flush()
+			return nil
+		}
+	}
+}
+
+// Shutdown останавливает Flusher и дожидается последнего сброса метрик в хранилище.
+func (f *Flusher) Shutdown(ctx context.Context) error {
+	f.closeOnce.Do(func() {
+		close(f.done)
+	})
+
+	select {
+	case <-f.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (f *Flusher) flush() {
+	metricSlice := f.recorder.Flush()
+	if len(metricSlice) == 0 {
+		return
+	}
+	if err := f.storage.Batch(context.Background(), metricSlice); err != nil {
+		logger.Log.Error("failed to store self metrics", zap.Error(err))
+	}
+}
@@ -0,0 +1,137 @@
+// Package selfmetrics собирает внутренние метрики сервера: частоту и длительность запросов, размеры пакетов,
+// задержки хранилища и длительность сохранения метрик в файл.
+//
+// Значения накапливаются в течение интервала сброса и записываются в хранилище как обычные метрики
+// с префиксом Namespace. Так как MetricStorage не поддерживает гистограммы, наблюдения (Observe)
+// разворачиваются при сбросе так же, как таймеры StatsD:
+//   - <name>.count — counter с количеством наблюдений за интервал;
+//   - <name>.min, <name>.max, <name>.mean — gauge с минимальным, максимальным и средним значением за интервал.
+package selfmetrics
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SpaceSlow/execenv/internal/metrics"
+)
+
+// Namespace префикс имён внутренних метрик, отделяющий их от метрик агентов.
+// Метрики агентов с этим префиксом отклоняются (IsReserved).
+const Namespace = "_srv."
+
+// Суффиксы метрик, в которые разворачивается наблюдение при сбросе.
+const (
+	countSuffix = ".count"
+	minSuffix   = ".min"
+	maxSuffix   = ".max"
+	meanSuffix  = ".mean"
+)
+
+type observation struct {
+	count int64
+	sum   float64
+	min   float64
+	max   float64
+}
+
+// IsReserved сообщает, относится ли имя name к внутренним метрикам сервера.
+func IsReserved(name string) bool {
+	return strings.HasPrefix(name, Namespace)
+}
+
+// Recorder накапливает внутренние метрики в течение интервала сброса.
+// Методы nil Recorder ничего не учитывают, что позволяет отключить сбор внутренних метрик.
+type Recorder struct {
+	counters     map[string]int64
+	gauges       map[string]float64
+	observations map[string]*observation
+	mu           sync.Mutex
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{
+		counters:     make(map[string]int64),
+		gauges:       make(map[string]float64),
+		observations: make(map[string]*observation),
+	}
+}
+
+// Add увеличивает counter name на delta.
+func (r *Recorder) Add(name string, delta int64) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.counters[name] += delta
+	r.mu.Unlock()
+}
+
+// Set устанавливает значение gauge name.
+func (r *Recorder) Set(name string, value float64) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.gauges[name] = value
+	r.mu.Unlock()
+}
+
+// Observe добавляет наблюдение value (например, размер пакета) к метрике name.
+func (r *Recorder) Observe(name string, value float64) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	o, ok := r.observations[name]
+	if !ok {
+		o = &observation{min: value, max: value}
+		r.observations[name] = o
+	}
+	o.count++
+	o.sum += value
+	o.min = math.Min(o.min, value)
+	o.max = math.Max(o.max, value)
+}
+
+// ObserveDuration добавляет наблюдение длительности (в секундах) к метрике name.
+func (r *Recorder) ObserveDuration(name string, d time.Duration) {
+	r.Observe(name, d.Seconds())
+}
+
+// Since добавляет к метрике name наблюдение длительности, прошедшей с момента start.
+func (r *Recorder) Since(name string, start time.Time) {
+	r.ObserveDuration(name, time.Since(start))
+}
+
+// Flush возвращает метрики, накопленные за интервал, и начинает новый интервал.
+// Counter содержат приращения за интервал, gauge — последние значения.
+func (r *Recorder) Flush() []metrics.Metric {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	metricSlice := make([]metrics.Metric, 0, len(r.counters)+len(r.gauges)+4*len(r.observations))
+	for name, delta := range r.counters {
+		metricSlice = append(metricSlice, metrics.NewCounter(Namespace+name, delta))
+	}
+	for name, value := range r.gauges {
+		metricSlice = append(metricSlice, metrics.NewGauge(Namespace+name, value))
+	}
+	for name, o := range r.observations {
+		metricSlice = append(metricSlice,
+			metrics.NewCounter(Namespace+name+countSuffix, o.count),
+			metrics.NewGauge(Namespace+name+minSuffix, o.min),
+			metrics.NewGauge(Namespace+name+maxSuffix, o.max),
+			metrics.NewGauge(Namespace+name+meanSuffix, o.sum/float64(o.count)),
+		)
+	}
+
+	clear(r.counters)
+	clear(r.gauges)
+	clear(r.observations)
+
+	return metricSlice
+}
@@ -0,0 +1,114 @@
+package selfmetrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SpaceSlow/execenv/internal/metrics"
+)
+
+func TestRecorder_Flush(t *testing.T) {
+	tests := []struct {
+		record func(r *Recorder)
+		name   string
+		want   []metrics.Metric
+	}{
+		{
+			name:   "nothing recorded",
+			record: func(*Recorder) {},
+			want:   []metrics.Metric{},
+		},
+		{
+			name: "counters are summed",
+			record: func(r *Recorder) {
+				r.Add("http.req.2xx", 1)
+				r.Add("http.req.2xx", 2)
+				r.Add("http.req.4xx", 1)
+			},
+			want: []metrics.Metric{
+				metrics.NewCounter("_srv.http.req.2xx", 3),
+				metrics.NewCounter("_srv.http.req.4xx", 1),
+			},
+		},
+		{
+			name: "last gauge value",
+			record: func(r *Recorder) {
+				r.Set("snapshot.bytes", 100)
+				r.Set("snapshot.bytes", 200)
+			},
+			want: []metrics.Metric{metrics.NewGauge("_srv.snapshot.bytes", 200)},
+		},
+		{
+			name: "observations",
+			record: func(r *Recorder) {
+				r.Observe("batch.size", 4)
+				r.Observe("batch.size", 1)
+				r.ObserveDuration("http.dur", 1500*time.Millisecond)
+			},
+			want: []metrics.Metric{
+				metrics.NewCounter("_srv.batch.size.count", 2),
+				metrics.NewGauge("_srv.batch.size.min", 1),
+				metrics.NewGauge("_srv.batch.size.max", 4),
+				metrics.NewGauge("_srv.batch.size.mean", 2.5),
+				metrics.NewCounter("_srv.http.dur.count", 1),
+				metrics.NewGauge("_srv.http.dur.min", 1.5),
+				metrics.NewGauge("_srv.http.dur.max", 1.5),
+				metrics.NewGauge("_srv.http.dur.mean", 1.5),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRecorder()
+			tt.record(r)
+
+			assert.ElementsMatch(t, tt.want, r.Flush())
+			assert.Empty(t, r.Flush(), "values must be reset after flush")
+		})
+	}
+}
+
+func TestRecorder_nil(t *testing.T) {
+	var r *Recorder
+
+	assert.NotPanics(t, func() {
+		r.Add("http.req.2xx", 1)
+		r.Set("snapshot.bytes", 100)
+		r.Since("snapshot.dur", time.Now())
+	})
+}
+
+func TestIsReserved(t *testing.T) {
+	assert.True(t, IsReserved("_srv.http.req.2xx"))
+	assert.False(t, IsReserved("http.req.2xx"))
+	assert.False(t, IsReserved("_srv"))
+}
+
+type mockBatcher struct {
+	batches [][]metrics.Metric
+}
+
+func (b *mockBatcher) Batch(_ context.Context, metricSlice []metrics.Metric) error {
+	b.batches = append(b.batches, metricSlice)
+	return nil
+}
+
+func TestFlusher_ShutdownFlushes(t *testing.T) {
+	r := NewRecorder()
+	storage := &mockBatcher{}
+	f := NewFlusher(r, storage, time.Hour)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- f.Run()
+	}()
+	r.Add("grpc.req.ok", 1)
+
+	require.NoError(t, f.Shutdown(context.Background()))
+	require.NoError(t, <-errCh)
+	assert.Equal(t, [][]metrics.Metric{{metrics.NewCounter("_srv.grpc.req.ok", 1)}}, storage.batches)
+}
@@ -2,17 +2,27 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"log"
 	"net"
+	"slices"
 
+	collectorpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
+	"github.com/SpaceSlow/execenv/internal/auth"
 	"github.com/SpaceSlow/execenv/internal/interceptors"
 	"github.com/SpaceSlow/execenv/internal/metrics"
+	"github.com/SpaceSlow/execenv/internal/otlp"
 	pb "github.com/SpaceSlow/execenv/internal/proto"
+	"github.com/SpaceSlow/execenv/internal/ratelimit"
+	"github.com/SpaceSlow/execenv/internal/selfmetrics"
 	"github.com/SpaceSlow/execenv/internal/storages"
+	"github.com/SpaceSlow/execenv/internal/subnet"
 )
 
 var _ ShutdownRunner = (*grpcStrategy)(nil)
@@ -22,18 +32,43 @@ type grpcStrategy struct {
 	listener *net.Listener
 }
 
-func newGrpcStrategy(address string, storage storages.MetricStorage) *grpcStrategy {
+// otlpExportFullMethodName полное имя метода экспорта метрик OTLP/gRPC.
+const otlpExportFullMethodName = "/opentelemetry.proto.collector.metrics.v1.MetricsService/Export"
+
+// methodScopes области доступа методов gRPC (см. interceptors.AuthUnaryInterceptor).
+var methodScopes = map[string]auth.Scope{
+	pb.MetricService_AddMetric_FullMethodName:       auth.ScopeWrite,
+	pb.MetricService_BatchAddMetrics_FullMethodName: auth.ScopeWrite,
+	pb.MetricService_GetMetric_FullMethodName:       auth.ScopeRead,
+	pb.MetricService_ListMetrics_FullMethodName:     auth.ScopeRead,
+	otlpExportFullMethodName:                        auth.ScopeWrite,
+}
+
+// newGrpcStrategy создаёт gRPC-сервер, при tlsConfig != nil — с TLS. Запросы учитываются во внутренних метриках
+// сервера (recorder), при recorder == nil — не учитываются.
+func newGrpcStrategy(address string, storage storages.MetricStorage, limiter *ratelimit.Limiter, filter func() subnet.Filter, tokens auth.Tokens, recorder *selfmetrics.Recorder, tlsConfig *tls.Config) *grpcStrategy {
 	listen, err := net.Listen("tcp", address)
 	if err != nil {
 		log.Fatal(err) // ?
 	}
-	s := grpc.NewServer(
+	opts := []grpc.ServerOption{
+		// проверка доверенной подсети выполняется до учёта в ограничениях агента, как и в HTTP
 		grpc.ChainUnaryInterceptor(
-			interceptors.LogUnaryInterceptor,
-			interceptors.WithCheckingTrustedSubnetUnaryInterceptor,
+			interceptors.LogUnaryInterceptor(recorder),
+			interceptors.WithCheckingTrustedSubnetUnaryInterceptor(filter),
+			interceptors.RateLimitUnaryInterceptor(limiter, filter),
+			interceptors.AuthUnaryInterceptor(tokens, methodScopes),
 		),
-	)
+	}
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	if maxSize := limiter.Limits().MaxRequestSize; maxSize > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(int(maxSize)))
+	}
+	s := grpc.NewServer(opts...)
 	pb.RegisterMetricServiceServer(s, &MetricServiceServer{storage: storage})
+	collectorpb.RegisterMetricsServiceServer(s, &OTLPMetricsServer{receiver: otlp.NewReceiver(storage)})
 
 	runner := &grpcStrategy{
 		srv:      s,
@@ -52,6 +87,11 @@ func (s grpcStrategy) Shutdown(_ context.Context) error {
 	return nil
 }
 
+// isLimitError сообщает, превышены ли ограничения агента (ratelimit): такие ошибки возвращаются как ResourceExhausted.
+func isLimitError(err error) bool {
+	return errors.Is(err, ratelimit.ErrBatchTooLarge) || errors.Is(err, ratelimit.ErrSeriesQuotaExceeded)
+}
+
 // MetricServiceServer поддерживает все необходимые методы сервера.
 type MetricServiceServer struct {
 	pb.UnimplementedMetricServiceServer
@@ -68,7 +108,10 @@ func (s *MetricServiceServer) AddMetric(ctx context.Context, in *pb.AddMetricReq
 		response.Error = err.Error()
 		return &response, nil
 	}
-	_, err = s.storage.Add(metric)
+	_, err = s.storage.Add(ctx, metric)
+	if isLimitError(err) {
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	}
 	if err != nil {
 		response.Error = err.Error()
 	}
@@ -78,6 +121,10 @@ func (s *MetricServiceServer) AddMetric(ctx context.Context, in *pb.AddMetricReq
 
 // BatchAddMetrics реализует интерфейс добавления нескольких метрик.
 func (s *MetricServiceServer) BatchAddMetrics(ctx context.Context, in *pb.BatchAddMetricsRequest) (*pb.BatchAddMetricsResponse, error) {
+	if in.Partial {
+		return s.partialBatchAddMetrics(ctx, in)
+	}
+
 	var response pb.BatchAddMetricsResponse
 
 	metricSlice := make([]metrics.Metric, 0, len(in.Metrics))
@@ -91,10 +138,49 @@ func (s *MetricServiceServer) BatchAddMetrics(ctx context.Context, in *pb.BatchA
 		metricSlice = append(metricSlice, *m)
 	}
 
-	err := s.storage.Batch(metricSlice)
+	err := s.storage.Batch(ctx, metricSlice)
+	if isLimitError(err) {
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	}
+	if err != nil {
+		response.Error = err.Error()
+	}
+
+	return &response, nil
+}
+
+// partialBatchAddMetrics добавляет метрики независимо друг от друга и возвращает количество добавленных метрик
+// и ошибки отклонённых метрик по их индексу в запросе.
+func (s *MetricServiceServer) partialBatchAddMetrics(ctx context.Context, in *pb.BatchAddMetricsRequest) (*pb.BatchAddMetricsResponse, error) {
+	var response pb.BatchAddMetricsResponse
+
+	metricSlice := make([]metrics.Metric, 0, len(in.Metrics))
+	indexes := make([]int, 0, len(in.Metrics))
+	for i, metric := range in.Metrics {
+		m, err := pb.ConvertFromProto(metric)
+		if err != nil {
+			response.Errors = append(response.Errors, &pb.MetricError{Index: int32(i), Error: err.Error()})
+			continue
+		}
+		metricSlice = append(metricSlice, *m)
+		indexes = append(indexes, i)
+	}
+
+	accepted, itemErrors, err := storages.BatchEach(ctx, s.storage, metricSlice)
+	if isLimitError(err) {
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	}
 	if err != nil {
 		response.Error = err.Error()
+		return &response, nil
+	}
+	response.Accepted = int64(accepted)
+	for _, itemErr := range itemErrors {
+		response.Errors = append(response.Errors, &pb.MetricError{Index: int32(indexes[itemErr.Index]), Error: itemErr.Err.Error()})
 	}
+	slices.SortFunc(response.Errors, func(a, b *pb.MetricError) int {
+		return int(a.Index - b.Index)
+	})
 
 	return &response, nil
 }
@@ -104,23 +190,25 @@ func (s *MetricServiceServer) GetMetric(ctx context.Context, in *pb.GetMetricReq
 	var (
 		response pb.GetMetricResponse
 		metric   *metrics.Metric
-		ok       bool
+		err      error
 	)
 
 	switch in.MType {
 	case pb.MType_COUNTER:
-		metric, ok = s.storage.Get(metrics.Counter, in.Id)
+		metric, err = s.storage.Get(ctx, metrics.Counter, in.Id)
 	case pb.MType_GAUGE:
-		metric, ok = s.storage.Get(metrics.Gauge, in.Id)
+		metric, err = s.storage.Get(ctx, metrics.Gauge, in.Id)
 	default:
-		metric, ok = nil, false
+		metric, err = nil, storages.ErrMetricNotFound
 	}
 
-	if !ok {
+	if errors.Is(err, storages.ErrMetricNotFound) {
 		response.Error = status.Error(codes.NotFound, "").Error()
 		return &response, nil
+	} else if err != nil {
+		response.Error = err.Error()
+		return &response, nil
 	}
-	var err error
 	response.Metric, err = pb.ConvertToProto(metric)
 	if err != nil {
 		response.Error = err.Error()
@@ -133,7 +221,11 @@ func (s *MetricServiceServer) GetMetric(ctx context.Context, in *pb.GetMetricReq
 func (s *MetricServiceServer) ListMetrics(ctx context.Context, in *pb.ListMetricsRequest) (*pb.ListMetricsResponse, error) {
 	var response pb.ListMetricsResponse
 
-	metricSlice := s.storage.List()
+	metricSlice, err := s.storage.List(ctx)
+	if err != nil {
+		response.Error = err.Error()
+		return &response, nil
+	}
 	response.Metrics = make([]*pb.Metric, 0, len(metricSlice))
 	for _, metric := range metricSlice {
 		m, _ := pb.ConvertToProto(&metric)
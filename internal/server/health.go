@@ -0,0 +1,47 @@
+package server
+
+import (
+	"context"
+
+	"github.com/SpaceSlow/execenv/internal/health"
+	"github.com/SpaceSlow/execenv/internal/storages"
+)
+
+var _ ShutdownRunner = (*healthRunner)(nil)
+
+// healthRunner сообщает в реестр состояния, запущен ли ShutdownRunner.
+type healthRunner struct {
+	ShutdownRunner
+	state *health.State
+}
+
+func withHealth(registry *health.Registry, name string, runner ShutdownRunner) *healthRunner {
+	state := health.NewState(health.ErrStarting)
+	registry.Register(name, state)
+	return &healthRunner{
+		ShutdownRunner: runner,
+		state:          state,
+	}
+}
+
+func (r *healthRunner) Run() error {
+	r.state.Set(nil)
+	err := r.ShutdownRunner.Run()
+	if err != nil {
+		r.state.Set(err)
+		return err
+	}
+	r.state.Set(health.ErrStopped)
+	return nil
+}
+
+// registerStorageHealth добавляет в реестр проверку соединения с хранилищем и,
+// если хранилище её поддерживает, проверку сохранения метрик в файл.
+func registerStorageHealth(registry *health.Registry, storage storages.MetricStorage) {
+	registry.Register("storage", health.CheckerFunc(func(ctx context.Context) error {
+		return storages.CheckConnection(ctx, storage)
+	}))
+	if checker, ok := storage.(health.Checker); ok {
+		registry.Register("persistence", checker)
+	}
+}
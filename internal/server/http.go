@@ -2,27 +2,50 @@ package server
 
 import (
 	"context"
+	"crypto/rsa"
+	"crypto/tls"
 	"errors"
 	"net/http"
 
 	"github.com/SpaceSlow/execenv/internal/middlewares"
+	"github.com/SpaceSlow/execenv/internal/ratelimit"
 	"github.com/SpaceSlow/execenv/internal/routers"
+	"github.com/SpaceSlow/execenv/internal/selfmetrics"
 	"github.com/SpaceSlow/execenv/internal/storages"
+	"github.com/SpaceSlow/execenv/internal/subnet"
 )
 
 var _ ShutdownRunner = (*httpStrategy)(nil)
 
+// httpMiddlewareConfig параметры middleware HTTP-сервера.
+type httpMiddlewareConfig struct {
+	// key возвращает текущий ключ подписи запросов и ответов
+	key func() string
+	// filter возвращает текущие ограничения доступа по адресу клиента
+	filter     func() subnet.Filter
+	privateKey *rsa.PrivateKey
+	limiter    *ratelimit.Limiter
+	// recorder учитывает запросы во внутренних метриках сервера, nil — сбор внутренних метрик отключён
+	recorder *selfmetrics.Recorder
+}
+
 type httpStrategy struct {
-	srv     *http.Server
-	storage storages.MetricStorage
+	srv              *http.Server
+	storage          storages.MetricStorage
+	middlewareConfig httpMiddlewareConfig
+	routerOptions    []routers.Option
 }
 
-func newHTTPStrategy(address string, storage storages.MetricStorage) *httpStrategy {
+// newHTTPStrategy создаёт HTTP-сервер, при tlsConfig != nil — HTTPS-сервер.
+func newHTTPStrategy(address string, storage storages.MetricStorage, middlewareConfig httpMiddlewareConfig, tlsConfig *tls.Config, routerOptions ...routers.Option) *httpStrategy {
 	runner := &httpStrategy{
 		srv: &http.Server{
-			Addr: address,
+			Addr:      address,
+			TLSConfig: tlsConfig,
 		},
-		storage: storage,
+		storage:          storage,
+		middlewareConfig: middlewareConfig,
+		routerOptions:    routerOptions,
 	}
 	runner.setRouters()
 
@@ -31,7 +54,13 @@ func newHTTPStrategy(address string, storage storages.MetricStorage) *httpStrate
 
 func (s httpStrategy) Run() error {
 	var err error
-	if err = s.srv.ListenAndServe(); err != nil && errors.Is(err, http.ErrServerClosed) {
+	if s.srv.TLSConfig != nil {
+		// сертификат сервера уже загружен в TLSConfig
+		err = s.srv.ListenAndServeTLS("", "")
+	} else {
+		err = s.srv.ListenAndServe()
+	}
+	if err != nil && errors.Is(err, http.ErrServerClosed) {
 		return nil
 	}
 	return err
@@ -42,15 +71,18 @@ func (s httpStrategy) Shutdown(ctx context.Context) error {
 }
 
 func (s httpStrategy) setRouters() {
+	// последний middleware выполняется первым: запросы не из доверенных подсетей отклоняются
+	// до учёта в ограничениях агента и чтения тела запроса
 	middlewareHandlers := []func(next http.Handler) http.Handler{
-		middlewares.WithSigning,
-		middlewares.WithCompressing,
-		middlewares.WithDecryption,
-		middlewares.WithCheckingTrustedSubnet,
-		middlewares.WithLogging,
+		middlewares.WithSigning(s.middlewareConfig.key),
+		middlewares.WithCompressing(s.middlewareConfig.limiter.Limits().MaxRequestSize),
+		middlewares.WithDecryption(s.middlewareConfig.privateKey),
+		middlewares.WithRateLimiting(s.middlewareConfig.limiter, s.middlewareConfig.filter),
+		middlewares.WithCheckingTrustedSubnet(s.middlewareConfig.filter),
+		middlewares.WithLogging(s.middlewareConfig.recorder),
 	}
 
-	mux := routers.MetricRouter(s.storage).(http.Handler)
+	mux := routers.MetricRouter(s.storage, s.routerOptions...).(http.Handler)
 	for _, middleware := range middlewareHandlers {
 		mux = middleware(mux)
 	}
@@ -0,0 +1,30 @@
+package server
+
+import (
+	"context"
+
+	collectorpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/SpaceSlow/execenv/internal/otlp"
+)
+
+// OTLPMetricsServer принимает метрики по протоколу OTLP/gRPC.
+type OTLPMetricsServer struct {
+	collectorpb.UnimplementedMetricsServiceServer
+
+	receiver *otlp.Receiver
+}
+
+// Export реализует интерфейс экспорта метрик OTLP.
+func (s *OTLPMetricsServer) Export(ctx context.Context, in *collectorpb.ExportMetricsServiceRequest) (*collectorpb.ExportMetricsServiceResponse, error) {
+	rejected, err := s.receiver.Export(ctx, in)
+	if isLimitError(err) {
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return otlp.ExportResponse(rejected), nil
+}
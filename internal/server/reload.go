@@ -0,0 +1,111 @@
+package server
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/SpaceSlow/execenv/internal/config"
+	"github.com/SpaceSlow/execenv/internal/logger"
+)
+
+var _ ShutdownRunner = (*configReloader)(nil)
+
+// configWatchInterval период проверки времени изменения файла конфигурации.
+const configWatchInterval = 5 * time.Second
+
+// configReloader перечитывает конфигурацию сервера по сигналу SIGHUP и при изменении файла конфигурации,
+// записывает изменения в журнал и передаёт новую конфигурацию в apply.
+type configReloader struct {
+	reload    func() (*config.ServerConfig, []config.Change, error)
+	apply     func(cfg *config.ServerConfig)
+	done      chan struct{}
+	path      string
+	interval  time.Duration
+	closeOnce sync.Once
+}
+
+func newConfigReloader(path string, reload func() (*config.ServerConfig, []config.Change, error), apply func(cfg *config.ServerConfig)) *configReloader {
+	return &configReloader{
+		reload:   reload,
+		apply:    apply,
+		done:     make(chan struct{}),
+		path:     path,
+		interval: configWatchInterval,
+	}
+}
+
+func (r *configReloader) Run() error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	modTime := r.modTime()
+	for {
+		select {
+		case <-hup:
+			r.reloadConfig("signal")
+		case <-ticker.C:
+			if r.path == "" {
+				continue
+			}
+			// файл могли перезаписать не атомарно, поэтому конфигурация перечитывается при любом изменении времени
+			if t := r.modTime(); !t.Equal(modTime) {
+				modTime = t
+				r.reloadConfig("file")
+			}
+		case <-r.done:
+			return nil
+		}
+	}
+}
+
+func (r *configReloader) Shutdown(_ context.Context) error {
+	r.closeOnce.Do(func() {
+		close(r.done)
+	})
+	return nil
+}
+
+func (r *configReloader) modTime() time.Time {
+	if r.path == "" {
+		return time.Time{}
+	}
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+func (r *configReloader) reloadConfig(trigger string) {
+	cfg, changes, err := r.reload()
+	if err != nil {
+		logger.Log.Error("failed to reload config", zap.String("trigger", trigger), zap.Error(err))
+		return
+	}
+
+	for _, change := range changes {
+		fields := []zap.Field{
+			zap.String("field", change.Field),
+			zap.String("old", change.Old),
+			zap.String("new", change.New),
+		}
+		if change.Applied {
+			logger.Log.Info("config changed", fields...)
+		} else {
+			logger.Log.Warn("config change requires restart", fields...)
+		}
+	}
+	logger.Log.Info("config reloaded", zap.String("trigger", trigger), zap.Int("changes", len(changes)))
+
+	r.apply(cfg)
+}
@@ -2,27 +2,48 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"os/signal"
+	"sync"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/SpaceSlow/execenv/internal/config"
+	"github.com/SpaceSlow/execenv/internal/graphite"
+	"github.com/SpaceSlow/execenv/internal/health"
 	"github.com/SpaceSlow/execenv/internal/logger"
+	"github.com/SpaceSlow/execenv/internal/ratelimit"
+	"github.com/SpaceSlow/execenv/internal/routers"
+	"github.com/SpaceSlow/execenv/internal/selfmetrics"
+	"github.com/SpaceSlow/execenv/internal/statsd"
 	"github.com/SpaceSlow/execenv/internal/storages"
+	"github.com/SpaceSlow/execenv/internal/subnet"
 )
 
 type Server struct {
 	ctx context.Context
 
 	storage        storages.MetricStorage
+	fileStorage    *storages.MemFileStorage
+	selfMetrics    *selfmetrics.Flusher
+	recorder       *selfmetrics.Recorder
+	subnetFilter   atomic.Pointer[subnet.Filter]
+	currentConfig  atomic.Pointer[config.ServerConfig]
+	limiter        *ratelimit.Limiter
 	config         *config.ServerConfig
+	health         *health.Registry
 	serverStrategy ShutdownRunner
+	listeners      []ShutdownRunner
 }
 
-func NewServer() (*Server, error) {
+// NewServer создаёт сервер с конфигурацией cfg. Параметры, не требующие перезапуска, могут изменяться
+// при перечитывании конфигурации (config.ServerConfig.Reload).
+func NewServer(cfg *config.ServerConfig) (*Server, error) {
 	var (
 		srv Server
 		err error
@@ -32,18 +53,33 @@ func NewServer() (*Server, error) {
 		return nil, err
 	}
 	srv.ctx = context.Background()
+	srv.health = health.NewRegistry()
 
-	srv.config, err = config.GetServerConfig()
-	if err != nil {
+	srv.config = cfg
+	srv.currentConfig.Store(cfg)
+	if err = logger.SetLevel(srv.config.LogLevel); err != nil {
 		return nil, err
 	}
+	filter := srv.config.SubnetFilter()
+	srv.subnetFilter.Store(&filter)
+	if srv.config.SelfMetricsInterval.Duration > 0 {
+		srv.recorder = selfmetrics.NewRecorder()
+	}
 
 	err = srv.setStorage()
 	if err != nil {
 		return nil, err
 	}
+	registerStorageHealth(srv.health, srv.storage)
+	srv.instrumentStorage()
+	srv.setLimiter()
 
-	srv.setStrategy()
+	tlsConfig, err := srv.config.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	srv.setStrategy(tlsConfig)
+	srv.setListeners()
 
 	return &srv, nil
 }
@@ -56,19 +92,26 @@ func (s *Server) Run() error {
 	s.ctx = ctx
 
 	context.AfterFunc(ctx, func() {
-		timeoutCtx, cancelCtx := context.WithTimeout(context.Background(), s.config.TimeoutShutdown)
+		timeoutCtx, cancelCtx := context.WithTimeout(context.Background(), s.config.TimeoutShutdown.Duration)
 		defer cancelCtx()
 
 		<-timeoutCtx.Done()
 		logger.Log.Fatal("failed to gracefully shutdown the service")
 	})
 
+	// хранилище закрывается только после остановки приёмников, чтобы они успели сбросить накопленные метрики
+	var listenersStopped sync.WaitGroup
+	listenersStopped.Add(len(s.listeners))
+
 	g.Go(func() error {
 		defer logger.Log.Info("closed storage")
 
 		<-ctx.Done()
+		listenersStopped.Wait()
 
-		return s.storage.Close()
+		closeTimeoutCtx, cancelCloseTimeoutCtx := context.WithTimeout(context.Background(), s.config.TimeoutShutdown.Duration)
+		defer cancelCloseTimeoutCtx()
+		return s.storage.Close(closeTimeoutCtx)
 	})
 
 	g.Go(func() (err error) {
@@ -86,11 +129,29 @@ func (s *Server) Run() error {
 
 		<-ctx.Done()
 
-		shutdownTimeoutCtx, cancelShutdownTimeoutCtx := context.WithTimeout(context.Background(), s.config.TimeoutShutdown)
+		// до остановки сервер сообщает о неготовности, чтобы балансировщик успел перестать направлять на него запросы
+		s.health.StartDraining()
+		time.Sleep(s.config.ShutdownDrain.Duration)
+
+		shutdownTimeoutCtx, cancelShutdownTimeoutCtx := context.WithTimeout(context.Background(), s.config.TimeoutShutdown.Duration)
 		defer cancelShutdownTimeoutCtx()
 		return s.serverStrategy.Shutdown(shutdownTimeoutCtx)
 	})
 
+	for _, listener := range s.listeners {
+		listener := listener
+		g.Go(listener.Run)
+		g.Go(func() error {
+			defer listenersStopped.Done()
+
+			<-ctx.Done()
+
+			shutdownTimeoutCtx, cancelShutdownTimeoutCtx := context.WithTimeout(context.Background(), s.config.TimeoutShutdown.Duration)
+			defer cancelShutdownTimeoutCtx()
+			return listener.Shutdown(shutdownTimeoutCtx)
+		})
+	}
+
 	if err := g.Wait(); err != nil {
 		logger.Log.Error(fmt.Sprintf("%s", err))
 	}
@@ -101,20 +162,108 @@ func (s *Server) Run() error {
 func (s *Server) setStorage() error {
 	var err error
 
-	if s.config.DatabaseDSN != "" {
-		s.storage, err = storages.NewDBStorage(s.ctx, s.config.DatabaseDSN, s.config.Delays)
+	switch {
+	case storages.IsSQLiteDSN(s.config.DatabaseDSN):
+		s.storage, err = storages.NewSQLiteStorage(s.ctx, s.config.DatabaseDSN)
+		logger.Log.Info("using storage SQLite", zap.String("DSN", s.config.DatabaseDSN))
+	case s.config.DatabaseDSN != "":
+		s.storage, err = storages.NewDBStorage(s.ctx, s.config.DatabaseDSN, s.config.Delays.Values())
 		logger.Log.Info("using storage DB", zap.String("DSN", s.config.DatabaseDSN))
-	} else {
-		s.storage, err = storages.NewMemFileStorage(s.ctx, s.config.StoragePath, s.config.StoreInterval.Duration, s.config.NeededRestore)
+	default:
+		s.fileStorage, err = storages.NewMemFileStorage(s.ctx, s.config.StoragePath, s.config.StoreInterval.Duration, s.config.NeededRestore,
+			storages.WithSnapshotRecorder(s.recorder))
+		s.storage = s.fileStorage
 	}
 
 	return err
 }
 
-func (s *Server) setStrategy() {
+// instrumentStorage включает сбор внутренних метрик хранилища. Внутренние метрики записываются
+// в хранилище напрямую, чтобы их запись не учитывалась в них самих; остальные пути записи
+// отклоняют метрики с префиксом внутренних (storages.ReservedNameStorage).
+func (s *Server) instrumentStorage() {
+	if s.recorder != nil {
+		s.selfMetrics = selfmetrics.NewFlusher(s.recorder, s.storage, s.config.SelfMetricsInterval.Duration)
+		s.storage = storages.NewInstrumentedStorage(s.storage, s.recorder)
+	}
+	s.storage = storages.NewReservedNameStorage(s.storage)
+}
+
+// setLimiter настраивает ограничения запросов агентов. Квоты метрик проверяются хранилищем,
+// используемым серверами HTTP и gRPC; приёмники StatsD и Graphite не ограничиваются.
+func (s *Server) setLimiter() {
+	s.limiter = ratelimit.NewLimiter(ratelimit.Limits{
+		Rate:           s.config.AgentRateLimit,
+		Burst:          s.config.AgentBurst,
+		MaxRequestSize: s.config.MaxRequestSize,
+		MaxBatchSize:   s.config.MaxBatchSize,
+		MaxSeries:      s.config.MaxSeriesPerAgent,
+	})
+}
+
+func (s *Server) setStrategy(tlsConfig *tls.Config) {
+	storage := ratelimit.NewQuotaStorage(s.storage, s.limiter)
 	if s.config.StartedGRPCServer {
-		s.serverStrategy = newGrpcStrategy(s.config.ServerAddr.String(), s.storage)
+		s.serverStrategy = withHealth(s.health, "grpc", newGrpcStrategy(s.config.ServerAddr.String(), storage, s.limiter, s.currentSubnetFilter, s.config.APITokens, s.recorder, tlsConfig))
 		return
 	}
-	s.serverStrategy = newHTTPStrategy(s.config.ServerAddr.String(), s.storage)
+	s.serverStrategy = withHealth(s.health, "http", newHTTPStrategy(
+		s.config.ServerAddr.String(),
+		storage,
+		httpMiddlewareConfig{
+			key:        s.currentKey,
+			filter:     s.currentSubnetFilter,
+			privateKey: s.config.PrivateKey(),
+			limiter:    s.limiter,
+			recorder:   s.recorder,
+		},
+		tlsConfig,
+		routers.WithInfluxRules(s.config.InfluxRules),
+		routers.WithRequestValidation(s.config.ValidateRequests),
+		routers.WithHealthRegistry(s.health),
+		routers.WithTokens(s.config.APITokens),
+		routers.WithMaxRequestSize(s.config.MaxRequestSize),
+	))
+}
+
+// currentKey возвращает ключ подписи из текущей конфигурации.
+func (s *Server) currentKey() string {
+	return s.currentConfig.Load().Key
+}
+
+// reloadConfig перечитывает текущую конфигурацию.
+func (s *Server) reloadConfig() (*config.ServerConfig, []config.Change, error) {
+	return s.currentConfig.Load().Reload()
+}
+
+// currentSubnetFilter возвращает ограничения доступа по адресу клиента из текущей конфигурации.
+func (s *Server) currentSubnetFilter() subnet.Filter {
+	return *s.subnetFilter.Load()
+}
+
+// applyConfig применяет параметры перечитанной конфигурации, не требующие перезапуска сервера.
+func (s *Server) applyConfig(cfg *config.ServerConfig) {
+	s.currentConfig.Store(cfg)
+	if err := logger.SetLevel(cfg.LogLevel); err != nil {
+		logger.Log.Error("failed to set log level", zap.Error(err))
+	}
+	filter := cfg.SubnetFilter()
+	s.subnetFilter.Store(&filter)
+	if s.fileStorage != nil {
+		s.fileStorage.SetStoreInterval(cfg.StoreInterval.Duration)
+	}
+}
+
+// setListeners настраивает дополнительные приёмники метрик, работающие параллельно с основным сервером.
+func (s *Server) setListeners() {
+	if s.config.StatsdAddr.String() != "" {
+		s.listeners = append(s.listeners, withHealth(s.health, "statsd", statsd.NewListener(s.config.StatsdAddr.String(), s.config.StatsdTCP, s.config.StatsdFlushInterval.Duration, s.storage)))
+	}
+	if s.config.GraphiteAddr.String() != "" {
+		s.listeners = append(s.listeners, withHealth(s.health, "graphite", graphite.NewListener(s.config.GraphiteAddr.String(), s.config.GraphiteTemplates, s.storage)))
+	}
+	if s.selfMetrics != nil {
+		s.listeners = append(s.listeners, s.selfMetrics)
+	}
+	s.listeners = append(s.listeners, newConfigReloader(s.config.ConfigFilePath, s.reloadConfig, s.applyConfig))
 }
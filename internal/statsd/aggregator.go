@@ -0,0 +1,188 @@
+package statsd
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/SpaceSlow/execenv/internal/logger"
+	"github.com/SpaceSlow/execenv/internal/metrics"
+	"github.com/SpaceSlow/execenv/internal/storages"
+)
+
+// Суффиксы метрик, в которые разворачивается таймер при сбросе: MetricStorage не поддерживает гистограммы.
+const (
+	timerCountSuffix = ".count"
+	timerMinSuffix   = ".min"
+	timerMaxSuffix   = ".max"
+	timerMeanSuffix  = ".mean"
+)
+
+// seriesIdleTimeout время без значений, после которого остаток счётчика удаляется.
+const seriesIdleTimeout = 10 * time.Minute
+
+type timer struct {
+	// count количество измерений с учётом частоты выборки, received — фактически полученных
+	count    float64
+	received int
+	sum      float64
+	min      float64
+	max      float64
+}
+
+type gauge struct {
+	value float64
+	// relative значение задано только относительными изменениями и прибавляется к значению gauge в хранилище
+	relative bool
+}
+
+// remainder дробная часть счётчика, не записанная при предыдущих сбросах.
+type remainder struct {
+	updated time.Time
+	value   float64
+}
+
+// aggregator накапливает значения в течение интервала сброса.
+type aggregator struct {
+	now      func() time.Time
+	counters map[string]float64
+	timers   map[string]*timer
+	sets     map[string]map[string]struct{}
+	// gauges gauge, изменённые в текущем интервале
+	gauges map[string]*gauge
+	// remainders дробные части счётчиков, не записанные при предыдущих сбросах
+	remainders map[string]*remainder
+	lastSweep  time.Time
+	mu         sync.Mutex
+}
+
+func newAggregator() *aggregator {
+	return &aggregator{
+		now:        time.Now,
+		counters:   make(map[string]float64),
+		timers:     make(map[string]*timer),
+		sets:       make(map[string]map[string]struct{}),
+		gauges:     make(map[string]*gauge),
+		remainders: make(map[string]*remainder),
+	}
+}
+
+func (a *aggregator) add(s sample) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	switch s.typ {
+	case counterSample:
+		a.counters[s.name] += s.value / s.rate
+	case gaugeSample:
+		g, ok := a.gauges[s.name]
+		if !ok {
+			g = &gauge{relative: true}
+			a.gauges[s.name] = g
+		}
+		if s.relative {
+			g.value += s.value
+		} else {
+			g.value = s.value
+			g.relative = false
+		}
+	case timerSample:
+		t, ok := a.timers[s.name]
+		if !ok {
+			t = &timer{min: s.value, max: s.value}
+			a.timers[s.name] = t
+		}
+		t.count += 1 / s.rate
+		t.received++
+		t.sum += s.value
+		t.min = math.Min(t.min, s.value)
+		t.max = math.Max(t.max, s.value)
+	case setSample:
+		set, ok := a.sets[s.name]
+		if !ok {
+			set = make(map[string]struct{})
+			a.sets[s.name] = set
+		}
+		set[s.set] = struct{}{}
+	}
+}
+
+// flush возвращает метрики, накопленные за интервал, и начинает новый интервал.
+// Относительные изменения gauge, не заданного в интервале абсолютным значением, прибавляются к его значению в storage.
+// Остатки счётчиков без значений в течение seriesIdleTimeout удаляются.
+func (a *aggregator) flush(ctx context.Context, storage storages.MetricStorage) []metrics.Metric {
+	a.mu.Lock()
+	now := a.now()
+	a.sweep(now)
+	metricSlice := make([]metrics.Metric, 0, len(a.counters)+len(a.gauges)+4*len(a.timers)+len(a.sets))
+	for name, value := range a.counters {
+		metricSlice = append(metricSlice, metrics.NewCounter(name, a.round(name, value, now)))
+	}
+	for name, t := range a.timers {
+		metricSlice = append(metricSlice,
+			metrics.NewCounter(name+timerCountSuffix, a.round(name+timerCountSuffix, t.count, now)),
+			metrics.NewGauge(name+timerMinSuffix, t.min),
+			metrics.NewGauge(name+timerMaxSuffix, t.max),
+			metrics.NewGauge(name+timerMeanSuffix, t.sum/float64(t.received)),
+		)
+	}
+	for name, set := range a.sets {
+		metricSlice = append(metricSlice, metrics.NewGauge(name, float64(len(set))))
+	}
+	gauges := a.gauges
+
+	clear(a.counters)
+	clear(a.timers)
+	clear(a.sets)
+	a.gauges = make(map[string]*gauge)
+	a.mu.Unlock()
+
+	// значения gauge из хранилища запрашиваются без блокировки, чтобы не задерживать приём метрик
+	for name, g := range gauges {
+		value := g.value
+		if g.relative {
+			stored, err := storage.Get(ctx, metrics.Gauge, name)
+			switch {
+			case err == nil:
+				value += stored.Value
+			case !errors.Is(err, storages.ErrMetricNotFound):
+				logger.Log.Error("skipped relative statsd gauge", zap.String("name", name), zap.Error(err))
+				continue
+			}
+		}
+		metricSlice = append(metricSlice, metrics.NewGauge(name, value))
+	}
+	return metricSlice
+}
+
+// round округляет значение счётчика name с учётом остатка предыдущих сбросов и сохраняет новый остаток,
+// чтобы дробные значения и значения с частотой выборки не терялись. Требует блокировки a.mu.
+func (a *aggregator) round(name string, value float64, now time.Time) int64 {
+	if r, ok := a.remainders[name]; ok {
+		value += r.value
+	}
+	rounded := math.Round(value)
+	if rest := value - rounded; rest != 0 {
+		a.remainders[name] = &remainder{updated: now, value: rest}
+	} else {
+		delete(a.remainders, name)
+	}
+	return int64(rounded)
+}
+
+// sweep удаляет остатки счётчиков без значений в течение seriesIdleTimeout. Требует блокировки a.mu.
+func (a *aggregator) sweep(now time.Time) {
+	if now.Sub(a.lastSweep) <= seriesIdleTimeout {
+		return
+	}
+	a.lastSweep = now
+	for name, r := range a.remainders {
+		if now.Sub(r.updated) > seriesIdleTimeout {
+			delete(a.remainders, name)
+		}
+	}
+}
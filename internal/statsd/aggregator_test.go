@@ -0,0 +1,126 @@
+package statsd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SpaceSlow/execenv/internal/metrics"
+	"github.com/SpaceSlow/execenv/internal/storages"
+)
+
+func addLines(t *testing.T, a *aggregator, lines ...string) {
+	t.Helper()
+	for _, line := range lines {
+		s, err := parseLine(line)
+		require.NoError(t, err)
+		a.add(s)
+	}
+}
+
+func Test_aggregator(t *testing.T) {
+	ctx := context.Background()
+	storage := storages.NewMemStorage()
+	a := newAggregator()
+	addLines(t, a,
+		"requests:1|c",
+		"requests:2|c|@0.5",
+		"temperature:20|g",
+		"temperature:+1.5|g",
+		"latency:100|ms",
+		"latency:300|ms|@0.5",
+		"users:alice|s",
+		"users:bob|s",
+		"users:alice|s",
+	)
+
+	metricSlice := a.flush(ctx, storage)
+	assert.ElementsMatch(t, []metrics.Metric{
+		metrics.NewCounter("requests", 5),
+		metrics.NewGauge("temperature", 21.5),
+		metrics.NewCounter("latency.count", 3),
+		metrics.NewGauge("latency.min", 100),
+		metrics.NewGauge("latency.max", 300),
+		metrics.NewGauge("latency.mean", 200),
+		metrics.NewGauge("users", 2),
+	}, metricSlice)
+	require.NoError(t, storage.Batch(ctx, metricSlice))
+
+	assert.Empty(t, a.flush(ctx, storage), "values of previous interval must not be flushed again")
+
+	addLines(t, a, "temperature:-0.5|g")
+	assert.Equal(t, []metrics.Metric{metrics.NewGauge("temperature", 21)}, a.flush(ctx, storage), "relative gauge must be based on stored value")
+}
+
+func Test_aggregator_relativeGauge(t *testing.T) {
+	ctx := context.Background()
+	storage := storages.NewMemStorage()
+	require.NoError(t, storage.Batch(ctx, []metrics.Metric{metrics.NewGauge("stored", 10)}))
+	a := newAggregator()
+	addLines(t, a, "stored:+2|g", "unseen:-1|g", "reset:+5|g", "reset:3|g", "reset:+1|g")
+
+	assert.ElementsMatch(t, []metrics.Metric{
+		metrics.NewGauge("stored", 12),
+		metrics.NewGauge("unseen", -1),
+		metrics.NewGauge("reset", 4),
+	}, a.flush(ctx, storage))
+}
+
+func Test_aggregator_counterRemainder(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		flushes int
+		want    int64
+	}{
+		{
+			name:    "sampled counter",
+			line:    "requests:1|c|@0.3",
+			flushes: 3,
+			want:    10,
+		},
+		{
+			name:    "fractional counter",
+			line:    "requests:0.4|c",
+			flushes: 5,
+			want:    2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			storage := storages.NewMemStorage()
+			a := newAggregator()
+
+			var total int64
+			for i := 0; i < tt.flushes; i++ {
+				addLines(t, a, tt.line)
+				for _, metric := range a.flush(ctx, storage) {
+					total += metric.Delta
+				}
+			}
+			assert.Equal(t, tt.want, total, "fractional parts of counter must be carried over to next flushes")
+		})
+	}
+}
+
+func Test_aggregator_idleRemainder(t *testing.T) {
+	ctx := context.Background()
+	storage := storages.NewMemStorage()
+	a := newAggregator()
+	now := time.Unix(0, 0)
+	a.now = func() time.Time { return now }
+
+	addLines(t, a, "requests:0.4|c")
+	a.flush(ctx, storage)
+	require.Len(t, a.remainders, 1)
+
+	now = now.Add(2 * seriesIdleTimeout)
+	addLines(t, a, "errors:0.4|c")
+	a.flush(ctx, storage)
+	assert.Len(t, a.remainders, 1, "idle remainder must be removed")
+	assert.Contains(t, a.remainders, "errors")
+}
@@ -0,0 +1,240 @@
+// Package statsd реализует приём метрик по протоколу StatsD (UDP и, опционально, TCP).
+//
+// Поддерживаются типы c (counter), g (gauge, в том числе относительные изменения +N/-N),
+// ms/h (таймеры) и s (множества), а также частота выборки @rate.
+// Значения агрегируются в течение интервала сброса и записываются в хранилище одним Batch:
+//   - c — counter с суммой значений за интервал с учётом частоты выборки;
+//   - g — gauge с последним значением;
+//   - ms/h — counter <name>.count и gauge <name>.min, <name>.max, <name>.mean;
+//   - s — gauge с количеством уникальных значений за интервал.
+package statsd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/SpaceSlow/execenv/internal/logger"
+	"github.com/SpaceSlow/execenv/internal/storages"
+)
+
+// maxPacketSize максимальный размер UDP-пакета StatsD.
+const maxPacketSize = 65535
+
+// Listener принимает метрики StatsD и периодически сбрасывает их в хранилище.
+type Listener struct {
+	storage       storages.MetricStorage
+	aggregator    *aggregator
+	udpConn       net.PacketConn
+	tcpListener   net.Listener
+	tcpConns      map[net.Conn]struct{}
+	done          chan struct{}
+	stopped       chan struct{}
+	address       string
+	flushInterval time.Duration
+	mu            sync.Mutex
+	closeOnce     sync.Once
+	withTCP       bool
+}
+
+// NewListener создаёт Listener на адресе address. При withTCP метрики также принимаются по TCP на том же адресе.
+func NewListener(address string, withTCP bool, flushInterval time.Duration, storage storages.MetricStorage) *Listener {
+	return &Listener{
+		storage:       storage,
+		aggregator:    newAggregator(),
+		tcpConns:      make(map[net.Conn]struct{}),
+		done:          make(chan struct{}),
+		stopped:       make(chan struct{}),
+		address:       address,
+		flushInterval: flushInterval,
+		withTCP:       withTCP,
+	}
+}
+
+// Run начинает приём метрик и блокируется до вызова Shutdown.
+func (l *Listener) Run() error {
+	defer close(l.stopped)
+
+	if err := l.listen(); err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		l.serveUDP()
+	}()
+	if l.tcpListener != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.serveTCP(&wg)
+		}()
+	}
+
+	ticker := time.NewTicker(l.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			l.flush()
+		case <-l.done:
+			l.closeConns()
+			wg.Wait()
+			l.flush()
+			return nil
+		}
+	}
+}
+
+// Shutdown останавливает приём метрик и дожидается сброса накопленных значений в хранилище.
+func (l *Listener) Shutdown(ctx context.Context) error {
+	l.closeOnce.Do(func() {
+		close(l.done)
+	})
+
+	select {
+	case <-l.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Addr возвращает адрес, на котором принимаются UDP-пакеты, или nil, если Listener не запущен.
+func (l *Listener) Addr() net.Addr {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.udpConn == nil {
+		return nil
+	}
+	return l.udpConn.LocalAddr()
+}
+
+func (l *Listener) listen() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	udpConn, err := net.ListenPacket("udp", l.address)
+	if err != nil {
+		return err
+	}
+	l.udpConn = udpConn
+
+	if l.withTCP {
+		l.tcpListener, err = net.Listen("tcp", udpConn.LocalAddr().String())
+		if err != nil {
+			udpConn.Close()
+			return err
+		}
+	}
+
+	logger.Log.Info("started statsd listener", zap.String("address", udpConn.LocalAddr().String()), zap.Bool("tcp", l.withTCP))
+	return nil
+}
+
+func (l *Listener) closeConns() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.udpConn.Close()
+	if l.tcpListener != nil {
+		l.tcpListener.Close()
+	}
+	for conn := range l.tcpConns {
+		conn.Close()
+	}
+}
+
+func (l *Listener) serveUDP() {
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, _, err := l.udpConn.ReadFrom(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				logger.Log.Error("failed to read statsd packet", zap.Error(err))
+			}
+			return
+		}
+		for _, line := range strings.Split(string(buf[:n]), "\n") {
+			l.handleLine(line)
+		}
+	}
+}
+
+func (l *Listener) serveTCP(wg *sync.WaitGroup) {
+	for {
+		conn, err := l.tcpListener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				logger.Log.Error("failed to accept statsd connection", zap.Error(err))
+			}
+			return
+		}
+
+		l.mu.Lock()
+		select {
+		case <-l.done:
+			l.mu.Unlock()
+			conn.Close()
+			continue
+		default:
+		}
+		l.tcpConns[conn] = struct{}{}
+		l.mu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.serveConn(conn)
+		}()
+	}
+}
+
+func (l *Listener) serveConn(conn net.Conn) {
+	defer func() {
+		l.mu.Lock()
+		delete(l.tcpConns, conn)
+		l.mu.Unlock()
+		conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		l.handleLine(scanner.Text())
+	}
+}
+
+func (l *Listener) handleLine(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return
+	}
+	s, err := parseLine(line)
+	if err != nil {
+		logger.Log.Debug("skipped statsd line", zap.String("line", line), zap.Error(err))
+		return
+	}
+	l.aggregator.add(s)
+}
+
+func (l *Listener) flush() {
+	ctx, cancel := context.WithTimeout(context.Background(), l.flushInterval)
+	defer cancel()
+
+	metricSlice := l.aggregator.flush(ctx, l.storage)
+	if len(metricSlice) == 0 {
+		return
+	}
+	if err := l.storage.Batch(ctx, metricSlice); err != nil {
+		logger.Log.Error("failed to flush statsd metrics", zap.Error(err), zap.Int("count", len(metricSlice)))
+	}
+}
@@ -0,0 +1,74 @@
+package statsd
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SpaceSlow/execenv/internal/metrics"
+	"github.com/SpaceSlow/execenv/internal/storages"
+)
+
+func runListener(t *testing.T, withTCP bool) (*Listener, storages.MetricStorage) {
+	storage := storages.NewMemStorage()
+	l := NewListener("127.0.0.1:0", withTCP, time.Hour, storage)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- l.Run()
+	}()
+	require.Eventually(t, func() bool { return l.Addr() != nil }, time.Second, 10*time.Millisecond)
+	t.Cleanup(func() {
+		require.NoError(t, <-errCh)
+	})
+
+	return l, storage
+}
+
+func aggregated(l *Listener, counter string, value float64) bool {
+	l.aggregator.mu.Lock()
+	defer l.aggregator.mu.Unlock()
+	return l.aggregator.counters[counter] == value
+}
+
+func TestListener_UDP(t *testing.T) {
+	l, storage := runListener(t, false)
+
+	conn, err := net.Dial("udp", l.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("requests:3|c\ntemperature:21.5|g\nincorrect line"))
+	require.NoError(t, err)
+
+	// значения записываются в хранилище при остановке, даже если интервал сброса не истёк
+	require.Eventually(t, func() bool { return aggregated(l, "requests", 3) }, time.Second, 10*time.Millisecond)
+	require.NoError(t, l.Shutdown(context.Background()))
+
+	list, err := storage.List(context.Background())
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []metrics.Metric{
+		metrics.NewCounter("requests", 3),
+		metrics.NewGauge("temperature", 21.5),
+	}, list)
+}
+
+func TestListener_TCP(t *testing.T) {
+	l, storage := runListener(t, true)
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("requests:3|c\nrequests:4|c\n"))
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return aggregated(l, "requests", 7) }, time.Second, 10*time.Millisecond)
+	require.NoError(t, l.Shutdown(context.Background()))
+
+	metric, err := storage.Get(context.Background(), metrics.Counter, "requests")
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), metric.Delta)
+}
@@ -0,0 +1,102 @@
+package statsd
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/SpaceSlow/execenv/internal/metrics"
+	"github.com/SpaceSlow/execenv/internal/selfmetrics"
+)
+
+var ErrIncorrectLine = errors.New("incorrect statsd line")
+
+type sampleType int
+
+const (
+	counterSample sampleType = iota
+	gaugeSample
+	timerSample
+	setSample
+)
+
+// sample одно значение, полученное по протоколу StatsD: <name>:<value>|<type>[|@<sample rate>][|#<tags>].
+type sample struct {
+	name string
+	// set значение метрики типа s (уникальный идентификатор)
+	set   string
+	value float64
+	rate  float64
+	typ   sampleType
+	// relative признак относительного изменения gauge (значение со знаком + или -)
+	relative bool
+}
+
+// parseLine разбирает строку протокола StatsD. Теги (расширение DogStatsD) допускаются, но не учитываются.
+// Строки с именем метрики длиннее metrics.MaxNameLength или с префиксом внутренних метрик сервера отклоняются.
+func parseLine(line string) (sample, error) {
+	var s sample
+
+	name, rest, ok := strings.Cut(line, ":")
+	if !ok || name == "" {
+		return s, ErrIncorrectLine
+	}
+	s.name = name
+
+	parts := strings.Split(rest, "|")
+	if len(parts) < 2 || parts[0] == "" {
+		return s, ErrIncorrectLine
+	}
+
+	switch parts[1] {
+	case "c":
+		s.typ = counterSample
+	case "g":
+		s.typ = gaugeSample
+		s.relative = parts[0][0] == '+' || parts[0][0] == '-'
+	case "ms", "h":
+		s.typ = timerSample
+	case "s":
+		s.typ = setSample
+	default:
+		return s, ErrIncorrectLine
+	}
+	// к имени таймера при сбросе добавляется суффикс, самый длинный из них — timerCountSuffix
+	nameLength := len(name)
+	if s.typ == timerSample {
+		nameLength += len(timerCountSuffix)
+	}
+	if nameLength > metrics.MaxNameLength {
+		return s, metrics.ErrMetricNameTooLong
+	}
+	if selfmetrics.IsReserved(name) {
+		return s, metrics.ErrReservedMetricName
+	}
+
+	if s.typ == setSample {
+		s.set = parts[0]
+	} else {
+		value, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil {
+			return s, ErrIncorrectLine
+		}
+		s.value = value
+	}
+
+	s.rate = 1
+	for _, part := range parts[2:] {
+		switch {
+		case strings.HasPrefix(part, "@"):
+			rate, err := strconv.ParseFloat(part[1:], 64)
+			if err != nil || rate <= 0 || rate > 1 {
+				return s, ErrIncorrectLine
+			}
+			s.rate = rate
+		case strings.HasPrefix(part, "#"):
+		default:
+			return s, ErrIncorrectLine
+		}
+	}
+
+	return s, nil
+}
@@ -0,0 +1,75 @@
+package statsd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SpaceSlow/execenv/internal/metrics"
+)
+
+func Test_parseLine(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		wantSample sample
+		wantErr    error
+	}{
+		{
+			name:       "counter",
+			line:       "requests:5|c",
+			wantSample: sample{name: "requests", value: 5, rate: 1, typ: counterSample},
+		},
+		{
+			name:       "counter with sample rate",
+			line:       "requests:1|c|@0.1",
+			wantSample: sample{name: "requests", value: 1, rate: 0.1, typ: counterSample},
+		},
+		{
+			name:       "gauge",
+			line:       "temperature:21.5|g",
+			wantSample: sample{name: "temperature", value: 21.5, rate: 1, typ: gaugeSample},
+		},
+		{
+			name:       "relative gauge",
+			line:       "temperature:-1.5|g",
+			wantSample: sample{name: "temperature", value: -1.5, rate: 1, typ: gaugeSample, relative: true},
+		},
+		{
+			name:       "timer with tags",
+			line:       "latency:320|ms|@0.5|#env:prod",
+			wantSample: sample{name: "latency", value: 320, rate: 0.5, typ: timerSample},
+		},
+		{
+			name:       "histogram",
+			line:       "latency:320|h",
+			wantSample: sample{name: "latency", value: 320, rate: 1, typ: timerSample},
+		},
+		{
+			name:       "set",
+			line:       "users:user-42|s",
+			wantSample: sample{name: "users", set: "user-42", rate: 1, typ: setSample},
+		},
+		{name: "without name", line: ":5|c", wantErr: ErrIncorrectLine},
+		{name: "without type", line: "requests:5", wantErr: ErrIncorrectLine},
+		{name: "unknown type", line: "requests:5|x", wantErr: ErrIncorrectLine},
+		{name: "incorrect value", line: "requests:five|c", wantErr: ErrIncorrectLine},
+		{name: "incorrect sample rate", line: "requests:5|c|@2", wantErr: ErrIncorrectLine},
+		{name: "unknown section", line: "requests:5|c|x", wantErr: ErrIncorrectLine},
+		{name: "too long name", line: strings.Repeat("a", metrics.MaxNameLength+1) + ":5|c", wantErr: metrics.ErrMetricNameTooLong},
+		{name: "too long timer name", line: strings.Repeat("a", metrics.MaxNameLength) + ":5|ms", wantErr: metrics.ErrMetricNameTooLong},
+		{name: "reserved name", line: "_srv.http.req.2xx:5|c", wantErr: metrics.ErrReservedMetricName},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := parseLine(tt.line)
+			require.ErrorIs(t, err, tt.wantErr)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.wantSample, s)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package storages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/SpaceSlow/execenv/internal/metrics"
+)
+
+// ErrBatchRejected оборачивается ошибкой Batch, если пакет отклонён целиком (например, превышает допустимый размер)
+// и его метрики нельзя добавлять по одной.
+var ErrBatchRejected = errors.New("batch rejected")
+
+// ItemError ошибка добавления метрики с индексом Index в пакете.
+type ItemError struct {
+	Err   error
+	Index int
+}
+
+func (e ItemError) Error() string {
+	return fmt.Sprintf("metric #%d: %v", e.Index, e.Err)
+}
+
+func (e ItemError) Unwrap() error {
+	return e.Err
+}
+
+// validateBatch проверяет все метрики пакета до изменения хранилища.
+func validateBatch(metricSlice []metrics.Metric) error {
+	for i := range metricSlice {
+		if err := metricSlice[i].Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BatchEach добавляет метрики пакета независимо друг от друга и возвращает количество добавленных метрик
+// и ошибки отклонённых метрик в порядке возрастания индекса.
+// Корректные метрики записываются одним Batch; если хранилище отклоняет его, метрики добавляются по одной через Add.
+// Ошибка возвращается, только если обращение к хранилищу прервано контекстом или пакет отклонён целиком
+// (ErrBatchRejected).
+func BatchEach(ctx context.Context, storage MetricStorage, metricSlice []metrics.Metric) (int, []ItemError, error) {
+	var (
+		itemErrors []ItemError
+		valid      = make([]metrics.Metric, 0, len(metricSlice))
+		indexes    = make([]int, 0, len(metricSlice))
+	)
+	for i := range metricSlice {
+		if err := metricSlice[i].Validate(); err != nil {
+			itemErrors = append(itemErrors, ItemError{Index: i, Err: err})
+			continue
+		}
+		valid = append(valid, metricSlice[i])
+		indexes = append(indexes, i)
+	}
+	if len(valid) == 0 {
+		return 0, itemErrors, nil
+	}
+
+	err := storage.Batch(ctx, valid)
+	if err == nil {
+		return len(valid), itemErrors, nil
+	}
+	if isContextError(err) || errors.Is(err, ErrBatchRejected) {
+		return 0, nil, err
+	}
+
+	accepted := 0
+	for i := range valid {
+		if _, err = storage.Add(ctx, &valid[i]); err != nil {
+			if isContextError(err) {
+				return accepted, nil, err
+			}
+			itemErrors = append(itemErrors, ItemError{Index: indexes[i], Err: err})
+			continue
+		}
+		accepted++
+	}
+	slices.SortFunc(itemErrors, func(a, b ItemError) int {
+		return a.Index - b.Index
+	})
+	return accepted, itemErrors, nil
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
@@ -72,14 +72,26 @@ func (db *RetryDB) ExecContext(ctx context.Context, query string, args ...any) (
 	return <-resultCh, err
 }
 
+// ErrNoConnection возвращается CheckConnection при отсутствии соединения с хранилищем.
+var ErrNoConnection = errors.New("no connection to storage")
+
+// ICheckConnection реализуется хранилищами, поддерживающими проверку соединения.
 type ICheckConnection interface {
-	CheckConnection() bool
+	CheckConnection(ctx context.Context) bool
+}
+
+// CheckConnection проверяет соединение с хранилищем, если оно поддерживает проверку (ICheckConnection).
+func CheckConnection(ctx context.Context, storage MetricStorage) error {
+	s, ok := storage.(ICheckConnection)
+	if !ok || s.CheckConnection(ctx) {
+		return nil
+	}
+	return ErrNoConnection
 }
 
 // DBStorage хранит метрики в БД.
 type DBStorage struct {
-	ctx context.Context
-	db  RetryDB
+	db RetryDB
 }
 
 func NewDBStorage(ctx context.Context, dsn string, delays []time.Duration) (*DBStorage, error) {
@@ -99,49 +111,53 @@ func NewDBStorage(ctx context.Context, dsn string, delays []time.Duration) (*DBS
 		if err := createMetricsTable(ctx, rdb); err != nil {
 			return nil, err
 		}
+	} else if err := migrateMetricsTable(ctx, rdb); err != nil {
+		return nil, err
 	}
 
 	return &DBStorage{
-		ctx: ctx,
-		db:  rdb,
+		db: rdb,
 	}, nil
 }
 
-func (s DBStorage) Add(metric *metrics.Metric) (*metrics.Metric, error) {
+func (s DBStorage) Add(ctx context.Context, metric *metrics.Metric) (*metrics.Metric, error) {
+	if err := metric.Validate(); err != nil {
+		return nil, err
+	}
+
 	var (
 		updMetric *metrics.Metric
 		err       error
 	)
 	switch metric.Type {
 	case metrics.Gauge:
-		_, err = s.db.ExecContext(s.ctx, "INSERT INTO metrics (name, is_gauge, value) VALUES ($1, TRUE, $2) ON CONFLICT (name) DO UPDATE SET value=excluded.value;", metric.Name, metric.Value.(float64))
+		_, err = s.db.ExecContext(ctx, "INSERT INTO metrics (name, is_gauge, value) VALUES ($1, TRUE, $2) ON CONFLICT (name, is_gauge) DO UPDATE SET value=excluded.value;", metric.Name, metric.Value)
 		if err != nil {
 			return nil, err
 		}
 		updMetric = metric.Copy()
 	case metrics.Counter:
-		row := s.db.QueryRowContext(s.ctx, "SELECT delta FROM metrics WHERE (name=$1 AND is_gauge=FALSE) LIMIT 1;", metric.Name)
-		var prevValue int64
-		err = row.Scan(&prevValue)
-		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		// значение увеличивается одним запросом, иначе параллельные изменения счётчика терялись бы
+		row := s.db.QueryRowContext(ctx, "INSERT INTO metrics (name, is_gauge, delta) VALUES ($1, FALSE, $2) ON CONFLICT (name, is_gauge) DO UPDATE SET delta=metrics.delta+excluded.delta RETURNING delta;", metric.Name, metric.Delta)
+		var updValue int64
+		if err = row.Scan(&updValue); err != nil {
 			return nil, err
 		}
-		updValue := metric.Value.(int64) + prevValue
-		_, err = s.db.ExecContext(s.ctx, "INSERT INTO metrics (name, is_gauge, delta) VALUES ($1, FALSE, $2) ON CONFLICT (name) DO UPDATE SET delta=excluded.delta;", metric.Name, updValue)
-
 		updMetric = metric.Copy()
-		updMetric.Value = updValue
-		if err != nil {
-			return nil, err
-		}
+		updMetric.Delta = updValue
 	default:
-		err = metrics.ErrIncorrectMetricTypeOrValue
+		err = metrics.ErrUnknownMetricType
 	}
 	return updMetric, err
 }
 
-func (s DBStorage) Batch(metricSlice []metrics.Metric) error {
-	tx, err := s.db.Begin()
+// Batch добавляет метрики в одной транзакции: при ошибке любой из метрик хранилище не изменяется.
+func (s DBStorage) Batch(ctx context.Context, metricSlice []metrics.Metric) error {
+	if err := validateBatch(metricSlice); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
@@ -149,11 +165,11 @@ func (s DBStorage) Batch(metricSlice []metrics.Metric) error {
 	for i := range metricSlice {
 		switch metricSlice[i].Type {
 		case metrics.Gauge:
-			_, err = tx.ExecContext(s.ctx, "INSERT INTO metrics (name, is_gauge, value) VALUES ($1, TRUE, $2) ON CONFLICT (name) DO UPDATE SET value=excluded.value;", metricSlice[i].Name, metricSlice[i].Value.(float64))
+			_, err = tx.ExecContext(ctx, "INSERT INTO metrics (name, is_gauge, value) VALUES ($1, TRUE, $2) ON CONFLICT (name, is_gauge) DO UPDATE SET value=excluded.value;", metricSlice[i].Name, metricSlice[i].Value)
 		case metrics.Counter:
-			_, err = tx.ExecContext(s.ctx, "INSERT INTO metrics (name, is_gauge, delta) VALUES ($1, FALSE, $2) ON CONFLICT (name) DO UPDATE SET delta=(excluded.delta + (SELECT delta FROM metrics WHERE (name=$1 AND is_gauge=FALSE) LIMIT 1));", metricSlice[i].Name, metricSlice[i].Value.(int64))
+			_, err = tx.ExecContext(ctx, "INSERT INTO metrics (name, is_gauge, delta) VALUES ($1, FALSE, $2) ON CONFLICT (name, is_gauge) DO UPDATE SET delta=metrics.delta+excluded.delta;", metricSlice[i].Name, metricSlice[i].Delta)
 		default:
-			err = metrics.ErrIncorrectMetricTypeOrValue
+			err = metrics.ErrUnknownMetricType
 		}
 		if err != nil {
 			tx.Rollback()
@@ -166,41 +182,45 @@ func (s DBStorage) Batch(metricSlice []metrics.Metric) error {
 	return nil
 }
 
-func (s DBStorage) Get(metricType metrics.MetricType, name string) (*metrics.Metric, bool) {
+func (s DBStorage) Get(ctx context.Context, metricType metrics.MetricType, name string) (*metrics.Metric, error) {
 	switch metricType {
 	case metrics.Gauge:
 		var value float64
-		row := s.db.QueryRowContext(s.ctx, "SELECT value FROM metrics WHERE (name=$1 AND is_gauge=TRUE) LIMIT 1;", name)
-		if err := row.Scan(&value); err != nil {
-			return nil, false
+		row := s.db.QueryRowContext(ctx, "SELECT value FROM metrics WHERE (name=$1 AND is_gauge=TRUE) LIMIT 1;", name)
+		if err := row.Scan(&value); errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrMetricNotFound
+		} else if err != nil {
+			return nil, err
 		}
 
 		return &metrics.Metric{
 			Type:  metricType,
 			Name:  name,
 			Value: value,
-		}, true
+		}, nil
 	case metrics.Counter:
 		var delta int64
-		row := s.db.QueryRowContext(s.ctx, "SELECT delta FROM metrics WHERE (name=$1 AND is_gauge=FALSE) LIMIT 1;", name)
-		if err := row.Scan(&delta); err != nil {
-			return nil, false
+		row := s.db.QueryRowContext(ctx, "SELECT delta FROM metrics WHERE (name=$1 AND is_gauge=FALSE) LIMIT 1;", name)
+		if err := row.Scan(&delta); errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrMetricNotFound
+		} else if err != nil {
+			return nil, err
 		}
 
 		return &metrics.Metric{
 			Type:  metricType,
 			Name:  name,
-			Value: delta,
-		}, true
+			Delta: delta,
+		}, nil
 	default:
-		return nil, false
+		return nil, metrics.ErrUnknownMetricType
 	}
 }
 
-func (s DBStorage) List() []metrics.Metric {
-	rows, err := s.db.QueryContext(s.ctx, "SELECT name, is_gauge, delta, value FROM metrics;")
+func (s DBStorage) List(ctx context.Context) ([]metrics.Metric, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT name, is_gauge, delta, value FROM metrics;")
 	if err != nil {
-		return make([]metrics.Metric, 0)
+		return nil, err
 	}
 
 	defer rows.Close()
@@ -216,7 +236,7 @@ func (s DBStorage) List() []metrics.Metric {
 		m := metrics.Metric{}
 
 		if err := rows.Scan(&name, &isGauge, &delta, &value); err != nil {
-			return make([]metrics.Metric, 0)
+			return nil, err
 		}
 
 		if isGauge {
@@ -224,26 +244,35 @@ func (s DBStorage) List() []metrics.Metric {
 			m.Value = *value
 		} else {
 			m.Type = metrics.Counter
-			m.Value = *delta
+			m.Delta = *delta
 		}
 		m.Name = name
 
 		metricSlice = append(metricSlice, m)
 	}
 
-	if rows.Err() != nil {
-		return make([]metrics.Metric, 0)
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	return metricSlice
+	return metricSlice, nil
+}
+
+func (s DBStorage) ListFiltered(ctx context.Context, filter ListFilter) ([]metrics.Metric, error) {
+	query, args := filter.sqlQuery()
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return filter.scanFilteredMetrics(rows)
 }
 
-func (s DBStorage) Close() error {
+func (s DBStorage) Close(_ context.Context) error {
 	return s.db.Close()
 }
 
-func (s DBStorage) CheckConnection() bool {
-	return s.db.PingContext(s.ctx) == nil
+func (s DBStorage) CheckConnection(ctx context.Context) bool {
+	return s.db.PingContext(ctx) == nil
 }
 
 func checkExistMetricTable(ctx context.Context, db RetryDB) (bool, error) {
@@ -256,16 +285,31 @@ func checkExistMetricTable(ctx context.Context, db RetryDB) (bool, error) {
 	return tableExist, nil
 }
 
+// createMetricsTable создаёт таблицу метрик. Метрики разных типов могут иметь одинаковые имена;
+// имена сравниваются побайтово (COLLATE "C"), как в остальных хранилищах (ListFilter.Compare).
 func createMetricsTable(ctx context.Context, db RetryDB) error {
 	_, err := db.ExecContext(ctx, `
 		CREATE TABLE metrics (
 			id 			SERIAL PRIMARY KEY,
-			name 		VARCHAR(30) UNIQUE NOT NULL,
+			name 		TEXT COLLATE "C" NOT NULL,
 			is_gauge 	BOOLEAN NOT NULL,
 			delta 		BIGINT,
-			value		DOUBLE PRECISION
+			value		DOUBLE PRECISION,
+			UNIQUE (name, is_gauge)
 		);
 		`)
 
 	return err
 }
+
+// migrateMetricsTable обновляет таблицу метрик, созданную прежними версиями, в которой имя метрики
+// было ограничено 30 символами и уникальным независимо от типа.
+func migrateMetricsTable(ctx context.Context, db RetryDB) error {
+	_, err := db.ExecContext(ctx, `
+		ALTER TABLE metrics DROP CONSTRAINT IF EXISTS metrics_name_key;
+		ALTER TABLE metrics ALTER COLUMN name TYPE TEXT COLLATE "C";
+		CREATE UNIQUE INDEX IF NOT EXISTS metrics_name_is_gauge_key ON metrics (name, is_gauge);
+		`)
+
+	return err
+}
@@ -0,0 +1,179 @@
+package storages
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/SpaceSlow/execenv/internal/metrics"
+)
+
+// ListOrder порядок сортировки метрик в ListFiltered.
+type ListOrder int
+
+const (
+	// OrderByName сортировка по имени, затем по типу.
+	OrderByName ListOrder = iota
+	// OrderByNameDesc сортировка по имени, затем по типу в обратном порядке.
+	OrderByNameDesc
+	// OrderByType сортировка по типу (counter, gauge), затем по имени.
+	OrderByType
+	// OrderByTypeDesc сортировка по типу, затем по имени в обратном порядке.
+	OrderByTypeDesc
+)
+
+// MetricKey однозначно определяет метрику в хранилище, используется как курсор постраничного чтения.
+type MetricKey struct {
+	Name string
+	Type metrics.MetricType
+}
+
+// ListFilter условия выборки метрик для ListFiltered.
+type ListFilter struct {
+	// NameRegexp регулярное выражение для имени, nil — без ограничения
+	NameRegexp *regexp.Regexp
+	// After курсор: возвращаются метрики, следующие за After в порядке Order, nil — с начала
+	After *MetricKey
+	// NamePrefix префикс имени
+	NamePrefix string
+	// Type тип метрик, 0 — любой
+	Type metrics.MetricType
+	// Limit максимальное количество метрик, 0 — без ограничения
+	Limit int
+	Order ListOrder
+}
+
+// Match проверяет, удовлетворяет ли метрика условиям фильтра (без учёта Limit).
+func (f ListFilter) Match(metric *metrics.Metric) bool {
+	if f.Type != 0 && metric.Type != f.Type {
+		return false
+	}
+	if !strings.HasPrefix(metric.Name, f.NamePrefix) {
+		return false
+	}
+	if f.After != nil && f.Compare(MetricKey{Name: metric.Name, Type: metric.Type}, *f.After) <= 0 {
+		return false
+	}
+	if f.NameRegexp != nil && !f.NameRegexp.MatchString(metric.Name) {
+		return false
+	}
+	return true
+}
+
+// Compare сравнивает ключи метрик в порядке Order.
+func (f ListFilter) Compare(a, b MetricKey) int {
+	byName := strings.Compare(a.Name, b.Name)
+	byType := int(a.Type) - int(b.Type)
+
+	switch f.Order {
+	case OrderByNameDesc:
+		return -compareChain(byName, byType)
+	case OrderByType:
+		return compareChain(byType, byName)
+	case OrderByTypeDesc:
+		return -compareChain(byType, byName)
+	default:
+		return compareChain(byName, byType)
+	}
+}
+
+func compareChain(results ...int) int {
+	for _, result := range results {
+		if result != 0 {
+			return result
+		}
+	}
+	return 0
+}
+
+// sqlQuery возвращает запрос выборки метрик для таблицы metrics (PostgreSQL и SQLite).
+// Фильтр по регулярному выражению применяется при чтении строк (см. scanFilteredMetrics),
+// поэтому при заданном NameRegexp ограничение LIMIT в запрос не добавляется.
+func (f ListFilter) sqlQuery() (string, []any) {
+	var (
+		conditions []string
+		args       []any
+	)
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	switch f.Type {
+	case metrics.Counter:
+		conditions = append(conditions, "is_gauge=FALSE")
+	case metrics.Gauge:
+		conditions = append(conditions, "is_gauge=TRUE")
+	}
+	if f.NamePrefix != "" {
+		conditions = append(conditions, fmt.Sprintf("substr(name, 1, %s)=%s", arg(utf8.RuneCountInString(f.NamePrefix)), arg(f.NamePrefix)))
+	}
+	if f.After != nil {
+		name, isGauge := arg(f.After.Name), arg(f.After.Type == metrics.Gauge)
+		switch f.Order {
+		case OrderByNameDesc:
+			conditions = append(conditions, fmt.Sprintf("(name<%[1]s OR (name=%[1]s AND is_gauge<%[2]s))", name, isGauge))
+		case OrderByType:
+			conditions = append(conditions, fmt.Sprintf("(is_gauge>%[2]s OR (is_gauge=%[2]s AND name>%[1]s))", name, isGauge))
+		case OrderByTypeDesc:
+			conditions = append(conditions, fmt.Sprintf("(is_gauge<%[2]s OR (is_gauge=%[2]s AND name<%[1]s))", name, isGauge))
+		default:
+			conditions = append(conditions, fmt.Sprintf("(name>%[1]s OR (name=%[1]s AND is_gauge>%[2]s))", name, isGauge))
+		}
+	}
+
+	query := "SELECT name, is_gauge, delta, value FROM metrics"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	switch f.Order {
+	case OrderByNameDesc:
+		query += " ORDER BY name DESC, is_gauge DESC"
+	case OrderByType:
+		query += " ORDER BY is_gauge, name"
+	case OrderByTypeDesc:
+		query += " ORDER BY is_gauge DESC, name DESC"
+	default:
+		query += " ORDER BY name, is_gauge"
+	}
+
+	if f.Limit > 0 && f.NameRegexp == nil {
+		query += " LIMIT " + arg(f.Limit)
+	}
+	return query + ";", args
+}
+
+// scanFilteredMetrics читает метрики из результата запроса sqlQuery, применяя NameRegexp и Limit.
+func (f ListFilter) scanFilteredMetrics(rows *sql.Rows) ([]metrics.Metric, error) {
+	defer rows.Close()
+
+	metricSlice := make([]metrics.Metric, 0)
+	var (
+		name    string
+		isGauge bool
+		delta   sql.NullInt64
+		value   sql.NullFloat64
+	)
+	for (f.Limit == 0 || len(metricSlice) < f.Limit) && rows.Next() {
+		if err := rows.Scan(&name, &isGauge, &delta, &value); err != nil {
+			return nil, err
+		}
+		if f.NameRegexp != nil && !f.NameRegexp.MatchString(name) {
+			continue
+		}
+
+		if isGauge {
+			metricSlice = append(metricSlice, metrics.NewGauge(name, value.Float64))
+		} else {
+			metricSlice = append(metricSlice, metrics.NewCounter(name, delta.Int64))
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return metricSlice, nil
+}
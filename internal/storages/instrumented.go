@@ -0,0 +1,81 @@
+package storages
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/SpaceSlow/execenv/internal/metrics"
+	"github.com/SpaceSlow/execenv/internal/selfmetrics"
+)
+
+var (
+	_ MetricStorage    = (*InstrumentedStorage)(nil)
+	_ ICheckConnection = (*InstrumentedStorage)(nil)
+)
+
+// InstrumentedStorage измеряет задержки операций хранилища, количество ошибок и размеры пакетов.
+type InstrumentedStorage struct {
+	storage  MetricStorage
+	recorder *selfmetrics.Recorder
+}
+
+func NewInstrumentedStorage(storage MetricStorage, recorder *selfmetrics.Recorder) *InstrumentedStorage {
+	return &InstrumentedStorage{
+		storage:  storage,
+		recorder: recorder,
+	}
+}
+
+func (s InstrumentedStorage) Add(ctx context.Context, metric *metrics.Metric) (*metrics.Metric, error) {
+	defer s.recorder.Since("store.add", time.Now())
+	updMetric, err := s.storage.Add(ctx, metric)
+	s.countError(err)
+	return updMetric, err
+}
+
+func (s InstrumentedStorage) Batch(ctx context.Context, metricSlice []metrics.Metric) error {
+	defer s.recorder.Since("store.batch", time.Now())
+	s.recorder.Observe("batch.size", float64(len(metricSlice)))
+	err := s.storage.Batch(ctx, metricSlice)
+	s.countError(err)
+	return err
+}
+
+func (s InstrumentedStorage) Get(ctx context.Context, metricType metrics.MetricType, name string) (*metrics.Metric, error) {
+	defer s.recorder.Since("store.get", time.Now())
+	metric, err := s.storage.Get(ctx, metricType, name)
+	if !errors.Is(err, ErrMetricNotFound) {
+		s.countError(err)
+	}
+	return metric, err
+}
+
+func (s InstrumentedStorage) List(ctx context.Context) ([]metrics.Metric, error) {
+	defer s.recorder.Since("store.list", time.Now())
+	metricSlice, err := s.storage.List(ctx)
+	s.countError(err)
+	return metricSlice, err
+}
+
+func (s InstrumentedStorage) ListFiltered(ctx context.Context, filter ListFilter) ([]metrics.Metric, error) {
+	defer s.recorder.Since("store.listf", time.Now())
+	metricSlice, err := s.storage.ListFiltered(ctx, filter)
+	s.countError(err)
+	return metricSlice, err
+}
+
+func (s InstrumentedStorage) Close(ctx context.Context) error {
+	return s.storage.Close(ctx)
+}
+
+// CheckConnection проверяет соединение с исходным хранилищем (см. storages.CheckConnection).
+func (s InstrumentedStorage) CheckConnection(ctx context.Context) bool {
+	return CheckConnection(ctx, s.storage) == nil
+}
+
+func (s InstrumentedStorage) countError(err error) {
+	if err != nil {
+		s.recorder.Add("store.err", 1)
+	}
+}
@@ -0,0 +1,41 @@
+package storages
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SpaceSlow/execenv/internal/metrics"
+	"github.com/SpaceSlow/execenv/internal/selfmetrics"
+)
+
+func TestInstrumentedStorage(t *testing.T) {
+	recorder := selfmetrics.NewRecorder()
+	s := NewInstrumentedStorage(NewMemStorage(), recorder)
+	ctx := context.Background()
+
+	require.NoError(t, s.Batch(ctx, []metrics.Metric{metrics.NewCounter("PollCount", 1), metrics.NewGauge("RandomValue", 1.1)}))
+	_, err := s.Get(ctx, metrics.Counter, "PollCount")
+	require.NoError(t, err)
+	_, err = s.Get(ctx, metrics.Gauge, "Unknown")
+	require.ErrorIs(t, err, ErrMetricNotFound)
+	_, err = s.Add(ctx, &metrics.Metric{Name: "Bad"})
+	require.ErrorIs(t, err, metrics.ErrUnknownMetricType)
+	assert.True(t, s.CheckConnection(ctx))
+
+	counters := make(map[string]int64)
+	for _, metric := range recorder.Flush() {
+		if metric.Type == metrics.Counter {
+			counters[metric.Name] = metric.Delta
+		}
+	}
+	assert.Equal(t, map[string]int64{
+		"_srv.batch.size.count":  1,
+		"_srv.store.batch.count": 1,
+		"_srv.store.get.count":   2,
+		"_srv.store.add.count":   1,
+		"_srv.store.err":         1,
+	}, counters)
+}
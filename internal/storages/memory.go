@@ -1,134 +1,248 @@
 package storages
 
 import (
+	"context"
+	"math"
+	"slices"
 	"sync"
+	"sync/atomic"
 
 	"github.com/SpaceSlow/execenv/internal/metrics"
 )
 
 var _ MetricStorage = (*MemStorage)(nil)
 
-// MemStorage хранит метрики в памяти (на основе map).
+// shardCount количество сегментов MemStorage, должно быть степенью двойки.
+const shardCount = 32
+
+// MemStorage хранит метрики в памяти.
+//
+// Метрики распределены по сегментам (shards) по хэшу имени, каждый сегмент защищён собственным sync.RWMutex.
+// Значения хранятся в атомарных ячейках, поэтому обновление существующей метрики выполняется под блокировкой
+// на чтение и не мешает параллельным обновлениям других метрик того же сегмента.
+// Блокировка на запись берётся только при создании новой метрики и в Batch.
 type MemStorage struct {
-	counters counters
-	gauges   gauges
-	mu       sync.Mutex
+	shards [shardCount]memShard
 }
 
-func NewMemStorage() *MemStorage {
-	return &MemStorage{counters: make(map[string]int64), gauges: make(map[string]float64)}
+type memShard struct {
+	counters map[string]*atomic.Int64
+	gauges   map[string]*atomic.Uint64
+	mu       sync.RWMutex
 }
 
-func (storage *MemStorage) Add(metric *metrics.Metric) (*metrics.Metric, error) {
-	storage.mu.Lock()
-	defer storage.mu.Unlock()
+func NewMemStorage() *MemStorage {
+	storage := &MemStorage{}
+	for i := range storage.shards {
+		storage.shards[i].counters = make(map[string]*atomic.Int64)
+		storage.shards[i].gauges = make(map[string]*atomic.Uint64)
+	}
+	return storage
+}
 
-	var (
-		updMetric *metrics.Metric
-		err       error
+// shardIndex возвращает номер сегмента по имени метрики (FNV-1a без аллокаций).
+func shardIndex(name string) int {
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
 	)
-	switch metric.Type {
-	case metrics.Counter:
-		updMetric, err = storage.counters.Add(metric)
-	case metrics.Gauge:
-		updMetric, err = storage.gauges.Add(metric)
-	default:
-		return nil, metrics.ErrIncorrectMetricTypeOrValue
+	hash := uint32(offset32)
+	for i := 0; i < len(name); i++ {
+		hash ^= uint32(name[i])
+		hash *= prime32
 	}
-	if err != nil {
+	return int(hash & (shardCount - 1))
+}
+
+func (storage *MemStorage) shard(name string) *memShard {
+	return &storage.shards[shardIndex(name)]
+}
+
+func (storage *MemStorage) Add(_ context.Context, metric *metrics.Metric) (*metrics.Metric, error) {
+	if err := metric.Validate(); err != nil {
 		return nil, err
 	}
+
+	shard := storage.shard(metric.Name)
+	shard.mu.RLock()
+	delta, ok := shard.update(metric)
+	shard.mu.RUnlock()
+	if !ok {
+		shard.mu.Lock()
+		// метрика могла появиться между снятием блокировки на чтение и взятием блокировки на запись
+		if delta, ok = shard.update(metric); !ok {
+			shard.insert(metric)
+		}
+		shard.mu.Unlock()
+	}
+
+	updMetric := metric.Copy()
+	if ok && metric.Type == metrics.Counter {
+		updMetric.Delta = delta
+	}
 	return updMetric, nil
 }
 
-func (storage *MemStorage) Get(metricType metrics.MetricType, name string) (*metrics.Metric, bool) {
-	storage.mu.Lock()
-	defer storage.mu.Unlock()
+func (storage *MemStorage) Get(_ context.Context, metricType metrics.MetricType, name string) (*metrics.Metric, error) {
+	shard := storage.shard(name)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
 
-	var value interface{}
-	var ok bool
 	switch metricType {
 	case metrics.Counter:
-		value, ok = storage.counters[name]
+		delta, ok := shard.counters[name]
+		if !ok {
+			return nil, ErrMetricNotFound
+		}
+		return &metrics.Metric{Type: metrics.Counter, Name: name, Delta: delta.Load()}, nil
 	case metrics.Gauge:
-		value, ok = storage.gauges[name]
+		bits, ok := shard.gauges[name]
+		if !ok {
+			return nil, ErrMetricNotFound
+		}
+		return &metrics.Metric{Type: metrics.Gauge, Name: name, Value: math.Float64frombits(bits.Load())}, nil
 	default:
-		return nil, false
+		return nil, metrics.ErrUnknownMetricType
 	}
-	if !ok {
-		return nil, ok
+}
+
+// List возвращает согласованный снимок всех метрик: на время копирования блокируются на чтение все сегменты,
+// поэтому в результат не может попасть частично применённый Batch.
+func (storage *MemStorage) List(_ context.Context) ([]metrics.Metric, error) {
+	for i := range storage.shards {
+		storage.shards[i].mu.RLock()
 	}
+	defer func() {
+		for i := range storage.shards {
+			storage.shards[i].mu.RUnlock()
+		}
+	}()
 
-	return &metrics.Metric{
-		Type:  metricType,
-		Name:  name,
-		Value: value,
-	}, true
-}
+	size := 0
+	for i := range storage.shards {
+		size += len(storage.shards[i].counters) + len(storage.shards[i].gauges)
+	}
+	metricSlice := make([]metrics.Metric, 0, size)
 
-func (storage *MemStorage) List() []metrics.Metric {
-	storage.mu.Lock()
-	defer storage.mu.Unlock()
+	for i := range storage.shards {
+		for name, delta := range storage.shards[i].counters {
+			metricSlice = append(metricSlice, metrics.NewCounter(name, delta.Load()))
+		}
+	}
+	for i := range storage.shards {
+		for name, bits := range storage.shards[i].gauges {
+			metricSlice = append(metricSlice, metrics.NewGauge(name, math.Float64frombits(bits.Load())))
+		}
+	}
 
-	metricSlice := make([]metrics.Metric, 0, len(storage.counters)+len(storage.gauges))
+	return metricSlice, nil
+}
 
-	for name, value := range storage.counters {
-		metricSlice = append(metricSlice, metrics.Metric{
-			Type:  metrics.Counter,
-			Name:  name,
-			Value: value,
-		})
+// ListFiltered возвращает согласованный снимок метрик, удовлетворяющих фильтру (см. List).
+func (storage *MemStorage) ListFiltered(_ context.Context, filter ListFilter) ([]metrics.Metric, error) {
+	for i := range storage.shards {
+		storage.shards[i].mu.RLock()
+	}
+	metricSlice := make([]metrics.Metric, 0)
+	for i := range storage.shards {
+		if filter.Type == 0 || filter.Type == metrics.Counter {
+			for name, delta := range storage.shards[i].counters {
+				if metric := metrics.NewCounter(name, delta.Load()); filter.Match(&metric) {
+					metricSlice = append(metricSlice, metric)
+				}
+			}
+		}
+		if filter.Type == 0 || filter.Type == metrics.Gauge {
+			for name, bits := range storage.shards[i].gauges {
+				if metric := metrics.NewGauge(name, math.Float64frombits(bits.Load())); filter.Match(&metric) {
+					metricSlice = append(metricSlice, metric)
+				}
+			}
+		}
 	}
-	for name, value := range storage.gauges {
-		metricSlice = append(metricSlice, metrics.Metric{
-			Type:  metrics.Gauge,
-			Name:  name,
-			Value: value,
-		})
+	for i := range storage.shards {
+		storage.shards[i].mu.RUnlock()
 	}
 
-	return metricSlice
+	slices.SortFunc(metricSlice, func(a, b metrics.Metric) int {
+		return filter.Compare(MetricKey{Name: a.Name, Type: a.Type}, MetricKey{Name: b.Name, Type: b.Type})
+	})
+	if filter.Limit > 0 && len(metricSlice) > filter.Limit {
+		metricSlice = metricSlice[:filter.Limit]
+	}
+	return metricSlice, nil
 }
 
-func (storage *MemStorage) Close() error {
+func (storage *MemStorage) Close(_ context.Context) error {
 	return nil
 }
 
-func (storage *MemStorage) Batch(metricSlice []metrics.Metric) error {
-	for _, metric := range metricSlice {
-		_, err := storage.Add(&metric)
-		if err != nil {
+// Batch добавляет метрики атомарно: при наличии хотя бы одной некорректной метрики хранилище не изменяется.
+// На время применения блокируются на запись только затронутые сегменты (в порядке возрастания номера, чтобы исключить взаимоблокировки).
+func (storage *MemStorage) Batch(_ context.Context, metricSlice []metrics.Metric) error {
+	var locked [shardCount]bool
+	for i := range metricSlice {
+		if err := metricSlice[i].Validate(); err != nil {
 			return err
 		}
+		locked[shardIndex(metricSlice[i].Name)] = true
 	}
-	return nil
-}
-
-type counters map[string]int64
 
-func (c counters) Add(metric *metrics.Metric) (*metrics.Metric, error) {
-	prevValue := c[metric.Name]
-	value, ok := metric.Value.(int64)
-	if !ok {
-		return nil, metrics.ErrIncorrectMetricTypeOrValue
+	for i := range locked {
+		if locked[i] {
+			storage.shards[i].mu.Lock()
+		}
 	}
-	updValue := prevValue + value
-	updMetric := metric.Copy()
-	updMetric.Value = updValue
-	c[metric.Name] = updValue
+	defer func() {
+		for i := range locked {
+			if locked[i] {
+				storage.shards[i].mu.Unlock()
+			}
+		}
+	}()
 
-	return updMetric, nil
+	for i := range metricSlice {
+		shard := storage.shard(metricSlice[i].Name)
+		if _, ok := shard.update(&metricSlice[i]); !ok {
+			shard.insert(&metricSlice[i])
+		}
+	}
+	return nil
 }
 
-type gauges map[string]float64
-
-func (g gauges) Add(metric *metrics.Metric) (*metrics.Metric, error) {
-	value, ok := metric.Value.(float64)
-	if !ok {
-		return nil, metrics.ErrIncorrectMetricTypeOrValue
+// update обновляет значение существующей метрики и возвращает новое значение счётчика (для gauge — 0),
+// возвращает false, если метрики в сегменте нет.
+// Требует блокировки сегмента (достаточно на чтение), метрика должна быть проверена metrics.Metric.Validate.
+func (s *memShard) update(metric *metrics.Metric) (int64, bool) {
+	switch metric.Type {
+	case metrics.Counter:
+		delta, ok := s.counters[metric.Name]
+		if !ok {
+			return 0, false
+		}
+		return delta.Add(metric.Delta), true
+	case metrics.Gauge:
+		bits, ok := s.gauges[metric.Name]
+		if !ok {
+			return 0, false
+		}
+		bits.Store(math.Float64bits(metric.Value))
+		return 0, true
+	default:
+		return 0, false
 	}
-	updMetric := metric.Copy()
-	g[metric.Name] = value
+}
 
-	return updMetric, nil
+// insert добавляет в сегмент новую метрику. Требует блокировки сегмента на запись.
+func (s *memShard) insert(metric *metrics.Metric) {
+	switch metric.Type {
+	case metrics.Counter:
+		delta := &atomic.Int64{}
+		delta.Store(metric.Delta)
+		s.counters[metric.Name] = delta
+	case metrics.Gauge:
+		bits := &atomic.Uint64{}
+		bits.Store(math.Float64bits(metric.Value))
+		s.gauges[metric.Name] = bits
+	}
 }
@@ -5,10 +5,13 @@ import (
 	"encoding/json"
 	"errors"
 	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/SpaceSlow/execenv/internal/logger"
 	"github.com/SpaceSlow/execenv/internal/metrics"
+	"github.com/SpaceSlow/execenv/internal/selfmetrics"
 )
 
 var _ MetricStorage = (*MemFileStorage)(nil)
@@ -18,17 +21,37 @@ var ErrNoSpecifyFile = errors.New("no file specified")
 // MemFileStorage хранит метрики и в памяти и в файле, поддерживает синхронизацию памяти с файлом.
 type MemFileStorage struct {
 	*MemStorage
-	ctx         context.Context
-	f           *os.File
-	isSyncStore bool
+	ctx        context.Context
+	f          *os.File
+	recorder   *selfmetrics.Recorder
+	saveErr    error
+	intervalCh chan time.Duration
+	fileMu     sync.Mutex
+	// syncMu упорядочивает изменения памяти при синхронной записи и сохранение снимков в файл,
+	// чтобы последний записанный снимок содержал все применённые до него изменения
+	syncMu      sync.Mutex
+	isSyncStore atomic.Bool
 }
 
-func NewMemFileStorage(ctx context.Context, filename string, duration time.Duration, neededRestore bool) (*MemFileStorage, error) {
+// MemFileOption дополнительная настройка MemFileStorage.
+type MemFileOption func(s *MemFileStorage)
+
+// WithSnapshotRecorder задаёт Recorder, в котором учитываются длительность, размер и ошибки сохранения метрик в файл.
+func WithSnapshotRecorder(recorder *selfmetrics.Recorder) MemFileOption {
+	return func(s *MemFileStorage) {
+		s.recorder = recorder
+	}
+}
+
+func NewMemFileStorage(ctx context.Context, filename string, duration time.Duration, neededRestore bool, opts ...MemFileOption) (*MemFileStorage, error) {
 	storage := &MemFileStorage{
 		ctx:        ctx,
 		MemStorage: NewMemStorage(),
 		f:          nil,
 	}
+	for _, opt := range opts {
+		opt(storage)
+	}
 
 	if filename == "" {
 		return storage, nil
@@ -41,7 +64,7 @@ func NewMemFileStorage(ctx context.Context, filename string, duration time.Durat
 	storage.f = file
 
 	if neededRestore {
-		err = storage.LoadMetricsFromFile()
+		err = storage.LoadMetricsFromFile(ctx)
 	} else {
 		err = storage.f.Truncate(0)
 	}
@@ -49,39 +72,78 @@ func NewMemFileStorage(ctx context.Context, filename string, duration time.Durat
 		return nil, err
 	}
 
-	storage.isSyncStore = duration == 0
-	if !storage.isSyncStore {
-		go storage.startStoreMetricsPerSecondsTask(duration)
-	}
+	storage.isSyncStore.Store(duration == 0)
+	storage.intervalCh = make(chan time.Duration)
+	go storage.startStoreMetricsPerSecondsTask(duration)
 
 	return storage, nil
 }
 
-func (s *MemFileStorage) Add(metric *metrics.Metric) (*metrics.Metric, error) {
-	updMetric, err := s.MemStorage.Add(metric)
-	if err != nil {
+// Add добавляет метрику. При синхронной записи в файл, как и в Batch, сначала сохраняется состояние
+// с добавленной метрикой, и только затем изменяется память.
+func (s *MemFileStorage) Add(ctx context.Context, metric *metrics.Metric) (*metrics.Metric, error) {
+	if !s.isSyncStore.Load() {
+		return s.MemStorage.Add(ctx, metric)
+	}
+	if err := metric.Validate(); err != nil {
+		return nil, err
+	}
+
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+	if err := s.saveBatch(ctx, []metrics.Metric{*metric}); err != nil {
 		return nil, err
 	}
-	if !s.isSyncStore {
-		return updMetric, nil
+	return s.MemStorage.Add(ctx, metric)
+}
+
+// Batch добавляет метрики атомарно. При синхронной записи в файл сначала сохраняется состояние с применённым пакетом,
+// и только затем изменяется память, поэтому при ошибке записи хранилище не изменяется. Пакеты применяются
+// по одному, поэтому снимок в файле не теряет изменения параллельных пакетов.
+func (s *MemFileStorage) Batch(ctx context.Context, metricSlice []metrics.Metric) error {
+	if !s.isSyncStore.Load() {
+		return s.MemStorage.Batch(ctx, metricSlice)
+	}
+	if err := validateBatch(metricSlice); err != nil {
+		return err
+	}
+
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+	if err := s.saveBatch(ctx, metricSlice); err != nil {
+		return err
 	}
-	return updMetric, s.SaveMetricsToFile()
+	return s.MemStorage.Batch(ctx, metricSlice)
 }
 
-func (s *MemFileStorage) Batch(metricSlice []metrics.Metric) error {
-	err := s.MemStorage.Batch(metricSlice)
-	if err != nil || !s.isSyncStore {
+// saveBatch сохраняет в файл текущие метрики с применённым пакетом metricSlice. Требует блокировки s.syncMu.
+func (s *MemFileStorage) saveBatch(ctx context.Context, metricSlice []metrics.Metric) error {
+	current, err := s.List(ctx)
+	if err != nil {
 		return err
 	}
-	return s.SaveMetricsToFile()
+	return s.saveMetrics(applyBatch(current, metricSlice))
+}
+
+// SetStoreInterval изменяет интервал сохранения метрик в файл, при нулевом интервале метрики сохраняются
+// при каждом изменении.
+func (s *MemFileStorage) SetStoreInterval(duration time.Duration) {
+	if s.f == nil {
+		return
+	}
+	s.isSyncStore.Store(duration == 0)
+	select {
+	case s.intervalCh <- duration:
+	case <-s.ctx.Done():
+	}
 }
 
-func (s *MemFileStorage) Close() error {
+func (s *MemFileStorage) Close(ctx context.Context) error {
 	if s.f == nil {
 		return nil
 	}
 
-	err := s.SaveMetricsToFile()
+	err := s.SaveMetricsToFile(ctx)
 	if err != nil {
 		logger.Log.Error("not saved metrics")
 	}
@@ -89,31 +151,91 @@ func (s *MemFileStorage) Close() error {
 	return s.f.Close()
 }
 
-func (s *MemFileStorage) SaveMetricsToFile() error {
+func (s *MemFileStorage) SaveMetricsToFile(ctx context.Context) error {
 	if s.f == nil {
 		return ErrNoSpecifyFile
 	}
 	logger.Log.Info("saving metrics...")
 
-	data, err := json.MarshalIndent(s.List(), "", "    ")
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+	return s.saveSnapshot(ctx)
+}
+
+// saveSnapshot сохраняет в файл текущие метрики. Требует блокировки s.syncMu.
+func (s *MemFileStorage) saveSnapshot(ctx context.Context) error {
+	metricSlice, err := s.List(ctx)
+	if err != nil {
+		return err
+	}
+	return s.saveMetrics(metricSlice)
+}
+
+func (s *MemFileStorage) saveMetrics(metricSlice []metrics.Metric) error {
+	defer s.recorder.Since("snapshot.dur", time.Now())
+
+	data, err := json.MarshalIndent(metricSlice, "", "    ")
 	if err != nil {
+		s.recorder.Add("snapshot.err", 1)
 		return err
 	}
-	s.mu.Lock()
+	s.fileMu.Lock()
 	_, err = s.f.WriteAt(data, 0)
-	s.mu.Unlock()
+	if err == nil {
+		// предыдущий снимок мог быть длиннее, его остаток сделал бы файл некорректным JSON
+		err = s.f.Truncate(int64(len(data)))
+	}
+	s.saveErr = err
+	s.fileMu.Unlock()
+
+	if err != nil {
+		s.recorder.Add("snapshot.err", 1)
+		return err
+	}
+	s.recorder.Set("snapshot.bytes", float64(len(data)))
+	return nil
+}
+
+// CheckHealth возвращает ошибку последней записи метрик в файл.
+func (s *MemFileStorage) CheckHealth(_ context.Context) error {
+	s.fileMu.Lock()
+	defer s.fileMu.Unlock()
+	return s.saveErr
+}
+
+// applyBatch возвращает метрики metricSlice с применённым к ним пакетом batch.
+func applyBatch(metricSlice, batch []metrics.Metric) []metrics.Metric {
+	index := make(map[MetricKey]int, len(metricSlice))
+	for i := range metricSlice {
+		index[MetricKey{Name: metricSlice[i].Name, Type: metricSlice[i].Type}] = i
+	}
 
-	return err
+	for _, metric := range batch {
+		key := MetricKey{Name: metric.Name, Type: metric.Type}
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(metricSlice)
+			metricSlice = append(metricSlice, metric)
+			continue
+		}
+		switch metric.Type {
+		case metrics.Counter:
+			metricSlice[i].Delta += metric.Delta
+		case metrics.Gauge:
+			metricSlice[i].Value = metric.Value
+		}
+	}
+	return metricSlice
 }
 
-func (s *MemFileStorage) LoadMetricsFromFile() error {
+func (s *MemFileStorage) LoadMetricsFromFile(ctx context.Context) error {
 	if s.f == nil {
 		return ErrNoSpecifyFile
 	}
 
-	s.mu.Lock()
+	s.fileMu.Lock()
 	data, err := os.ReadFile(s.f.Name())
-	s.mu.Unlock()
+	s.fileMu.Unlock()
 	if err != nil || len(data) == 0 {
 		return err
 	}
@@ -124,7 +246,7 @@ func (s *MemFileStorage) LoadMetricsFromFile() error {
 	}
 
 	for _, metric := range metricSlice {
-		if _, err = s.MemStorage.Add(&metric); err != nil {
+		if _, err = s.MemStorage.Add(ctx, &metric); err != nil {
 			return err
 		}
 	}
@@ -132,17 +254,28 @@ func (s *MemFileStorage) LoadMetricsFromFile() error {
 	return nil
 }
 
+// startStoreMetricsPerSecondsTask периодически сохраняет метрики в файл, пока интервал duration не нулевой.
 func (s *MemFileStorage) startStoreMetricsPerSecondsTask(duration time.Duration) {
 	logger.Log.Info("start store metrics task")
-	storeTicker := time.NewTicker(duration)
+	storeTicker := time.NewTicker(time.Hour)
 	defer storeTicker.Stop()
+	resetTicker := func(duration time.Duration) {
+		if duration > 0 {
+			storeTicker.Reset(duration)
+		} else {
+			storeTicker.Stop()
+		}
+	}
+	resetTicker(duration)
 	for {
 		select {
 		case <-s.ctx.Done():
 			logger.Log.Info("store metrics task has been finished")
 			return
+		case duration = <-s.intervalCh:
+			resetTicker(duration)
 		case <-storeTicker.C:
-			err := s.SaveMetricsToFile()
+			err := s.SaveMetricsToFile(s.ctx)
 			if err != nil {
 				logger.Log.Error("not saved metrics")
 			}
@@ -6,6 +6,8 @@ import (
 	"io"
 	"os"
 	"path"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -13,6 +15,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/SpaceSlow/execenv/internal/metrics"
+	"github.com/SpaceSlow/execenv/internal/selfmetrics"
 )
 
 func TestMemFileStorage_Add(t *testing.T) {
@@ -45,7 +48,7 @@ func TestMemFileStorage_Add(t *testing.T) {
 			s, err := NewMemFileStorage(context.Background(), path.Join(os.TempDir(), randStringBytes(10)), 0, false)
 			require.NoError(t, err)
 			defer func() {
-				require.NoError(t, s.Close())
+				require.NoError(t, s.Close(context.Background()))
 				require.NoError(t, os.Remove(s.f.Name()))
 			}()
 
@@ -54,7 +57,7 @@ func TestMemFileStorage_Add(t *testing.T) {
 			require.ErrorIs(t, err, io.EOF)
 			assert.Zero(t, size)
 
-			_, err = s.Add(tt.metric)
+			_, err = s.Add(context.Background(), tt.metric)
 			require.ErrorIs(t, err, tt.wantErr)
 			if err != nil {
 				return
@@ -101,9 +104,9 @@ func TestMemFileStorage_Batch(t *testing.T) {
 				gauges:   make(gauges),
 			},
 			args: args{
-				metricSlice: []metrics.Metric{{Type: metrics.Counter, Name: "PollCount", Value: int64(50)}},
+				metricSlice: []metrics.Metric{{Type: metrics.Counter, Name: "PollCount", Delta: 50}},
 			},
-			wantMetrics: []metrics.Metric{{Type: metrics.Counter, Name: "PollCount", Value: int64(55)}},
+			wantMetrics: []metrics.Metric{{Type: metrics.Counter, Name: "PollCount", Delta: 55}},
 			wantErr:     nil,
 		},
 		{
@@ -114,13 +117,13 @@ func TestMemFileStorage_Batch(t *testing.T) {
 			},
 			args: args{
 				metricSlice: []metrics.Metric{
-					{Type: metrics.Counter, Name: "PollCount", Value: int64(50)},
+					{Type: metrics.Counter, Name: "PollCount", Delta: 50},
 					{Type: metrics.Gauge, Name: "RandomValue2", Value: float64(0.999)},
 				},
 			},
 			wantMetrics: []metrics.Metric{
-				{Type: metrics.Counter, Name: "PollCount", Value: int64(55)},
-				{Type: metrics.Counter, Name: "CounterMetric", Value: int64(9)},
+				{Type: metrics.Counter, Name: "PollCount", Delta: 55},
+				{Type: metrics.Counter, Name: "CounterMetric", Delta: 9},
 				{Type: metrics.Gauge, Name: "RandomValue", Value: float64(65)},
 				{Type: metrics.Gauge, Name: "RandomValue2", Value: float64(0.999)},
 			},
@@ -134,13 +137,13 @@ func TestMemFileStorage_Batch(t *testing.T) {
 			},
 			args: args{
 				metricSlice: []metrics.Metric{
-					{Type: metrics.MetricType(-1), Name: "PollCount", Value: int64(50)},
+					{Type: metrics.MetricType(-1), Name: "PollCount", Delta: 50},
 					{Type: metrics.Gauge, Name: "RandomValue2", Value: float64(0.999)},
 				},
 			},
 			wantMetrics: []metrics.Metric{
-				{Type: metrics.Counter, Name: "PollCount", Value: int64(5)},
-				{Type: metrics.Counter, Name: "CounterMetric", Value: int64(9)},
+				{Type: metrics.Counter, Name: "PollCount", Delta: 5},
+				{Type: metrics.Counter, Name: "CounterMetric", Delta: 9},
 				{Type: metrics.Gauge, Name: "RandomValue", Value: float64(65)},
 				{Type: metrics.Gauge, Name: "RandomValue2", Value: float64(10.001)},
 			},
@@ -152,16 +155,15 @@ func TestMemFileStorage_Batch(t *testing.T) {
 			s, err := NewMemFileStorage(context.Background(), path.Join(os.TempDir(), randStringBytes(10)), 0, false)
 			require.NoError(t, err)
 			defer func() {
-				require.NoError(t, s.Close())
+				require.NoError(t, s.Close(context.Background()))
 				require.NoError(t, os.Remove(s.f.Name()))
 			}()
 
-			s.counters = tt.fields.counters
-			s.gauges = tt.fields.gauges
-			require.NoError(t, s.SaveMetricsToFile())
+			fillMemStorage(s.MemStorage, tt.fields.counters, tt.fields.gauges)
+			require.NoError(t, s.SaveMetricsToFile(context.Background()))
 
-			err = s.Batch(tt.args.metricSlice)
-			require.Equal(t, tt.wantErr, err)
+			err = s.Batch(context.Background(), tt.args.metricSlice)
+			require.ErrorIs(t, err, tt.wantErr)
 
 			data, err := os.ReadFile(s.f.Name())
 			require.NoError(t, err)
@@ -173,16 +175,6 @@ func TestMemFileStorage_Batch(t *testing.T) {
 	}
 }
 
-func TestMemFileStorage_Close(t *testing.T) {
-	s, err := NewMemFileStorage(context.Background(), path.Join(os.TempDir(), randStringBytes(10)), 0, false)
-	require.NoError(t, err)
-	defer func() {
-		require.NoError(t, os.Remove(s.f.Name()))
-	}()
-
-	assert.NoError(t, s.Close())
-}
-
 func TestMemFileStorage_LoadMetricsFromFile(t *testing.T) {
 	type wantMetrics struct {
 		counters counters
@@ -222,21 +214,22 @@ func TestMemFileStorage_LoadMetricsFromFile(t *testing.T) {
 			s, err := NewMemFileStorage(context.Background(), path.Join(os.TempDir(), randStringBytes(10)), 0, false)
 			require.NoError(t, err)
 			defer func() {
-				require.NoError(t, s.Close())
+				require.NoError(t, s.Close(context.Background()))
 				require.NoError(t, os.Remove(s.f.Name()))
 			}()
 
 			_, err = s.f.Write(tt.data)
 			require.NoError(t, err)
 
-			err = s.LoadMetricsFromFile()
-			assert.ErrorIs(t, tt.wantErr, err)
+			err = s.LoadMetricsFromFile(context.Background())
+			assert.ErrorIs(t, err, tt.wantErr)
 			if err != nil {
 				return
 			}
 
-			assert.Equal(t, tt.wantMetrics.counters, s.counters)
-			assert.Equal(t, tt.wantMetrics.gauges, s.gauges)
+			storedCounters, storedGauges := snapshotMemStorage(t, s.MemStorage)
+			assert.Equal(t, tt.wantMetrics.counters, storedCounters)
+			assert.Equal(t, tt.wantMetrics.gauges, storedGauges)
 		})
 	}
 }
@@ -271,14 +264,13 @@ func TestMemFileStorage_SaveMetricsToFile(t *testing.T) {
 			s, err := NewMemFileStorage(context.Background(), path.Join(os.TempDir(), randStringBytes(10)), 1000*time.Second, false)
 			require.NoError(t, err)
 			defer func() {
-				require.NoError(t, s.Close())
+				require.NoError(t, s.Close(context.Background()))
 				require.NoError(t, os.Remove(s.f.Name()))
 			}()
 
-			s.counters = tt.fields.counters
-			s.gauges = tt.fields.gauges
+			fillMemStorage(s.MemStorage, tt.fields.counters, tt.fields.gauges)
 
-			err = s.SaveMetricsToFile()
+			err = s.SaveMetricsToFile(context.Background())
 			require.NoError(t, err)
 
 			data, err := os.ReadFile(s.f.Name())
@@ -296,3 +288,110 @@ func TestMemFileStorage_SaveMetricsToFile(t *testing.T) {
 		})
 	}
 }
+
+func TestMemFileStorage_BatchSaveError(t *testing.T) {
+	recorder := selfmetrics.NewRecorder()
+	s, err := NewMemFileStorage(context.Background(), path.Join(t.TempDir(), randStringBytes(10)), 0, false, WithSnapshotRecorder(recorder))
+	require.NoError(t, err)
+	initial := []metrics.Metric{metrics.NewCounter("PollCount", 5), metrics.NewGauge("RandomValue", 1.1)}
+	require.NoError(t, s.Batch(context.Background(), initial))
+	require.NoError(t, s.CheckHealth(context.Background()))
+	require.NoError(t, s.f.Close())
+
+	err = s.Batch(context.Background(), []metrics.Metric{metrics.NewCounter("PollCount", 1), metrics.NewGauge("NewGauge", 2.2)})
+	require.ErrorIs(t, err, os.ErrClosed)
+	assert.ElementsMatch(t, initial, listMetrics(t, s))
+	assert.ErrorIs(t, s.CheckHealth(context.Background()), os.ErrClosed)
+	assert.Contains(t, recorder.Flush(), metrics.NewCounter("_srv.snapshot.err", 1))
+}
+
+func TestMemFileStorage_AddSaveError(t *testing.T) {
+	s, err := NewMemFileStorage(context.Background(), path.Join(t.TempDir(), randStringBytes(10)), 0, false)
+	require.NoError(t, err)
+	initial := []metrics.Metric{metrics.NewCounter("PollCount", 5)}
+	require.NoError(t, s.Batch(context.Background(), initial))
+	require.NoError(t, s.f.Close())
+
+	_, err = s.Add(context.Background(), &metrics.Metric{Type: metrics.Counter, Name: "PollCount", Delta: 1})
+	require.ErrorIs(t, err, os.ErrClosed)
+	assert.ElementsMatch(t, initial, listMetrics(t, s))
+}
+
+func TestMemFileStorage_ConcurrentBatch(t *testing.T) {
+	ctx := context.Background()
+	filename := path.Join(t.TempDir(), "metrics.json")
+	s, err := NewMemFileStorage(ctx, filename, 0, false)
+	require.NoError(t, err)
+
+	const batches = 50
+	var wg sync.WaitGroup
+	for i := 0; i < batches; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, s.Batch(ctx, []metrics.Metric{
+				metrics.NewCounter("PollCount", 1),
+				metrics.NewGauge("Gauge"+strconv.Itoa(i), float64(i)),
+			}))
+		}(i)
+	}
+	wg.Wait()
+	require.NoError(t, s.f.Close())
+
+	restored, err := NewMemFileStorage(ctx, filename, time.Hour, true)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, restored.Close(ctx))
+	}()
+	assert.ElementsMatch(t, listMetrics(t, s), listMetrics(t, restored))
+	pollCount, err := restored.Get(ctx, metrics.Counter, "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, int64(batches), pollCount.Delta)
+}
+
+func TestMemFileStorage_SaveShorterSnapshot(t *testing.T) {
+	ctx := context.Background()
+	filename := path.Join(t.TempDir(), "metrics.json")
+	s, err := NewMemFileStorage(ctx, filename, 0, false)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Batch(ctx, []metrics.Metric{metrics.NewGauge("RandomValue", 1.2345678901234)}))
+	require.NoError(t, s.Batch(ctx, []metrics.Metric{metrics.NewGauge("RandomValue", 1)}))
+	require.NoError(t, s.f.Close())
+
+	restored, err := NewMemFileStorage(ctx, filename, time.Hour, true)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, restored.Close(ctx))
+	}()
+	assert.ElementsMatch(t, []metrics.Metric{metrics.NewGauge("RandomValue", 1)}, listMetrics(t, restored))
+}
+
+func TestMemFileStorage_SetStoreInterval(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s, err := NewMemFileStorage(ctx, path.Join(t.TempDir(), "metrics.json"), time.Hour, false)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, s.Close(context.Background()))
+	}()
+
+	fileSize := func() int64 {
+		info, err := os.Stat(s.f.Name())
+		require.NoError(t, err)
+		return info.Size()
+	}
+
+	_, err = s.Add(ctx, &metrics.Metric{Type: metrics.Counter, Name: "PollCount", Delta: 1})
+	require.NoError(t, err)
+	assert.Zero(t, fileSize(), "metrics saved before store interval")
+
+	s.SetStoreInterval(10 * time.Millisecond)
+	assert.Eventually(t, func() bool { return fileSize() > 0 }, time.Second, 10*time.Millisecond)
+
+	s.SetStoreInterval(0)
+	require.NoError(t, s.f.Truncate(0))
+	_, err = s.Add(ctx, &metrics.Metric{Type: metrics.Counter, Name: "PollCount", Delta: 1})
+	require.NoError(t, err)
+	assert.Positive(t, fileSize(), "metrics not saved synchronously")
+}
@@ -1,14 +1,23 @@
 package storages
 
 import (
+	"context"
+	"errors"
+
 	"github.com/SpaceSlow/execenv/internal/metrics"
 )
 
+// ErrMetricNotFound возвращается Get, если метрика с указанным типом и именем отсутствует в хранилище.
+var ErrMetricNotFound = errors.New("metric not found")
+
 // MetricStorage является интерфейсом для хранения метрик.
+// Все методы принимают контекст запроса, отмена или истечение которого прерывает обращение к хранилищу.
 type MetricStorage interface {
-	Add(metric *metrics.Metric) (*metrics.Metric, error)
-	Batch(metrics []metrics.Metric) error
-	Get(metricType metrics.MetricType, name string) (*metrics.Metric, bool)
-	List() []metrics.Metric
-	Close() error
+	Add(ctx context.Context, metric *metrics.Metric) (*metrics.Metric, error)
+	Batch(ctx context.Context, metrics []metrics.Metric) error
+	Get(ctx context.Context, metricType metrics.MetricType, name string) (*metrics.Metric, error)
+	List(ctx context.Context) ([]metrics.Metric, error)
+	// ListFiltered возвращает метрики, удовлетворяющие фильтру, в порядке filter.Order.
+	ListFiltered(ctx context.Context, filter ListFilter) ([]metrics.Metric, error)
+	Close(ctx context.Context) error
 }
@@ -0,0 +1,69 @@
+package storages
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/SpaceSlow/execenv/internal/metrics"
+	"github.com/SpaceSlow/execenv/internal/selfmetrics"
+)
+
+var (
+	_ MetricStorage    = (*ReservedNameStorage)(nil)
+	_ ICheckConnection = (*ReservedNameStorage)(nil)
+)
+
+// ReservedNameStorage отклоняет метрики с префиксом внутренних метрик сервера (selfmetrics.Namespace)
+// ошибкой metrics.ErrReservedMetricName, чтобы метрики агентов не смешивались с внутренними.
+// Внутренние метрики записываются в исходное хранилище в обход ReservedNameStorage.
+type ReservedNameStorage struct {
+	storage MetricStorage
+}
+
+func NewReservedNameStorage(storage MetricStorage) *ReservedNameStorage {
+	return &ReservedNameStorage{storage: storage}
+}
+
+func (s ReservedNameStorage) Add(ctx context.Context, metric *metrics.Metric) (*metrics.Metric, error) {
+	if err := checkReservedName(metric.Name); err != nil {
+		return nil, err
+	}
+	return s.storage.Add(ctx, metric)
+}
+
+func (s ReservedNameStorage) Batch(ctx context.Context, metricSlice []metrics.Metric) error {
+	for i := range metricSlice {
+		if err := checkReservedName(metricSlice[i].Name); err != nil {
+			return err
+		}
+	}
+	return s.storage.Batch(ctx, metricSlice)
+}
+
+func (s ReservedNameStorage) Get(ctx context.Context, metricType metrics.MetricType, name string) (*metrics.Metric, error) {
+	return s.storage.Get(ctx, metricType, name)
+}
+
+func (s ReservedNameStorage) List(ctx context.Context) ([]metrics.Metric, error) {
+	return s.storage.List(ctx)
+}
+
+func (s ReservedNameStorage) ListFiltered(ctx context.Context, filter ListFilter) ([]metrics.Metric, error) {
+	return s.storage.ListFiltered(ctx, filter)
+}
+
+func (s ReservedNameStorage) Close(ctx context.Context) error {
+	return s.storage.Close(ctx)
+}
+
+// CheckConnection проверяет соединение с исходным хранилищем (см. storages.CheckConnection).
+func (s ReservedNameStorage) CheckConnection(ctx context.Context) bool {
+	return CheckConnection(ctx, s.storage) == nil
+}
+
+func checkReservedName(name string) error {
+	if selfmetrics.IsReserved(name) {
+		return fmt.Errorf("%w: %s", metrics.ErrReservedMetricName, name)
+	}
+	return nil
+}
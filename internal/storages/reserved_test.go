@@ -0,0 +1,34 @@
+package storages
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SpaceSlow/execenv/internal/metrics"
+)
+
+func TestReservedNameStorage(t *testing.T) {
+	memStorage := NewMemStorage()
+	s := NewReservedNameStorage(memStorage)
+	ctx := context.Background()
+
+	_, err := s.Add(ctx, &metrics.Metric{Name: "_srv.http.req.2xx", Type: metrics.Counter, Delta: 1})
+	assert.ErrorIs(t, err, metrics.ErrReservedMetricName)
+	err = s.Batch(ctx, []metrics.Metric{metrics.NewCounter("PollCount", 1), metrics.NewGauge("_srv.snapshot.bytes", 1)})
+	assert.ErrorIs(t, err, metrics.ErrReservedMetricName)
+
+	accepted, itemErrors, err := BatchEach(ctx, s, []metrics.Metric{metrics.NewCounter("PollCount", 1), metrics.NewGauge("_srv.snapshot.bytes", 1)})
+	require.NoError(t, err)
+	assert.Equal(t, 1, accepted)
+	require.Len(t, itemErrors, 1)
+	assert.Equal(t, 1, itemErrors[0].Index)
+	assert.ErrorIs(t, itemErrors[0].Err, metrics.ErrReservedMetricName)
+
+	metricSlice, err := memStorage.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []metrics.Metric{metrics.NewCounter("PollCount", 1)}, metricSlice)
+	assert.True(t, s.CheckConnection(ctx))
+}
@@ -0,0 +1,231 @@
+package storages
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/SpaceSlow/execenv/internal/metrics"
+)
+
+var (
+	_ MetricStorage    = (*SQLiteStorage)(nil)
+	_ ICheckConnection = (*SQLiteStorage)(nil)
+)
+
+// SQLiteDSNPrefix префикс DSN, по которому выбирается хранилище SQLite (пример: sqlite:///var/lib/metrics.db).
+const SQLiteDSNPrefix = "sqlite://"
+
+// sqlitePragmas параметры соединения: ожидание блокировки БД и журнал WAL.
+const sqlitePragmas = "_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+
+// IsSQLiteDSN проверяет, указывает ли DSN на файл БД SQLite.
+func IsSQLiteDSN(dsn string) bool {
+	return strings.HasPrefix(dsn, SQLiteDSNPrefix)
+}
+
+// SQLiteStorage хранит метрики в однофайловой БД SQLite.
+type SQLiteStorage struct {
+	db *sql.DB
+}
+
+func NewSQLiteStorage(ctx context.Context, dsn string) (*SQLiteStorage, error) {
+	path := strings.TrimPrefix(dsn, SQLiteDSNPrefix)
+	if path == "" {
+		return nil, ErrNoSpecifyFile
+	}
+
+	db, err := sql.Open("sqlite", sqliteDSN(path))
+	if err != nil {
+		return nil, err
+	}
+	// SQLite допускает только одного писателя, поэтому все запросы выполняются через одно соединение.
+	db.SetMaxOpenConns(1)
+
+	if err = createSQLiteMetricsTable(ctx, db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteStorage{
+		db: db,
+	}, nil
+}
+
+// sqliteDSN добавляет к пути БД параметры соединения sqlitePragmas, сохраняя параметры из DSN
+// (например, sqlite:///var/lib/metrics.db?_txlock=immediate).
+func sqliteDSN(path string) string {
+	if strings.Contains(path, "?") {
+		return path + "&" + sqlitePragmas
+	}
+	return path + "?" + sqlitePragmas
+}
+
+func (s SQLiteStorage) Add(ctx context.Context, metric *metrics.Metric) (*metrics.Metric, error) {
+	if err := metric.Validate(); err != nil {
+		return nil, err
+	}
+
+	var (
+		updMetric *metrics.Metric
+		err       error
+	)
+	switch metric.Type {
+	case metrics.Gauge:
+		_, err = s.db.ExecContext(ctx, "INSERT INTO metrics (name, is_gauge, value) VALUES ($1, TRUE, $2) ON CONFLICT (name, is_gauge) DO UPDATE SET value=excluded.value;", metric.Name, metric.Value)
+		if err != nil {
+			return nil, err
+		}
+		updMetric = metric.Copy()
+	case metrics.Counter:
+		var updValue int64
+		row := s.db.QueryRowContext(ctx, "INSERT INTO metrics (name, is_gauge, delta) VALUES ($1, FALSE, $2) ON CONFLICT (name, is_gauge) DO UPDATE SET delta=delta+excluded.delta RETURNING delta;", metric.Name, metric.Delta)
+		if err = row.Scan(&updValue); err != nil {
+			return nil, err
+		}
+		updMetric = metric.Copy()
+		updMetric.Delta = updValue
+	default:
+		err = metrics.ErrUnknownMetricType
+	}
+	return updMetric, err
+}
+
+// Batch добавляет метрики в одной транзакции: при ошибке любой из метрик хранилище не изменяется.
+func (s SQLiteStorage) Batch(ctx context.Context, metricSlice []metrics.Metric) error {
+	if err := validateBatch(metricSlice); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for i := range metricSlice {
+		switch metricSlice[i].Type {
+		case metrics.Gauge:
+			_, err = tx.ExecContext(ctx, "INSERT INTO metrics (name, is_gauge, value) VALUES ($1, TRUE, $2) ON CONFLICT (name, is_gauge) DO UPDATE SET value=excluded.value;", metricSlice[i].Name, metricSlice[i].Value)
+		case metrics.Counter:
+			_, err = tx.ExecContext(ctx, "INSERT INTO metrics (name, is_gauge, delta) VALUES ($1, FALSE, $2) ON CONFLICT (name, is_gauge) DO UPDATE SET delta=delta+excluded.delta;", metricSlice[i].Name, metricSlice[i].Delta)
+		default:
+			err = metrics.ErrUnknownMetricType
+		}
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s SQLiteStorage) Get(ctx context.Context, metricType metrics.MetricType, name string) (*metrics.Metric, error) {
+	switch metricType {
+	case metrics.Gauge:
+		var value float64
+		row := s.db.QueryRowContext(ctx, "SELECT value FROM metrics WHERE (name=$1 AND is_gauge=TRUE) LIMIT 1;", name)
+		if err := row.Scan(&value); errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrMetricNotFound
+		} else if err != nil {
+			return nil, err
+		}
+
+		return &metrics.Metric{
+			Type:  metricType,
+			Name:  name,
+			Value: value,
+		}, nil
+	case metrics.Counter:
+		var delta int64
+		row := s.db.QueryRowContext(ctx, "SELECT delta FROM metrics WHERE (name=$1 AND is_gauge=FALSE) LIMIT 1;", name)
+		if err := row.Scan(&delta); errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrMetricNotFound
+		} else if err != nil {
+			return nil, err
+		}
+
+		return &metrics.Metric{
+			Type:  metricType,
+			Name:  name,
+			Delta: delta,
+		}, nil
+	default:
+		return nil, metrics.ErrUnknownMetricType
+	}
+}
+
+func (s SQLiteStorage) List(ctx context.Context) ([]metrics.Metric, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT name, is_gauge, delta, value FROM metrics;")
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	metricSlice := make([]metrics.Metric, 0)
+	var (
+		name    string
+		isGauge bool
+		delta   sql.NullInt64
+		value   sql.NullFloat64
+	)
+	for rows.Next() {
+		m := metrics.Metric{}
+
+		if err := rows.Scan(&name, &isGauge, &delta, &value); err != nil {
+			return nil, err
+		}
+
+		if isGauge {
+			m.Type = metrics.Gauge
+			m.Value = value.Float64
+		} else {
+			m.Type = metrics.Counter
+			m.Delta = delta.Int64
+		}
+		m.Name = name
+
+		metricSlice = append(metricSlice, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return metricSlice, nil
+}
+
+func (s SQLiteStorage) ListFiltered(ctx context.Context, filter ListFilter) ([]metrics.Metric, error) {
+	query, args := filter.sqlQuery()
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return filter.scanFilteredMetrics(rows)
+}
+
+func (s SQLiteStorage) Close(_ context.Context) error {
+	return s.db.Close()
+}
+
+func (s SQLiteStorage) CheckConnection(ctx context.Context) bool {
+	return s.db.PingContext(ctx) == nil
+}
+
+func createSQLiteMetricsTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS metrics (
+			id 			INTEGER PRIMARY KEY AUTOINCREMENT,
+			name 		TEXT NOT NULL,
+			is_gauge 	BOOLEAN NOT NULL,
+			delta 		INTEGER,
+			value		REAL,
+			UNIQUE (name, is_gauge)
+		);
+		`)
+
+	return err
+}
@@ -0,0 +1,95 @@
+package storagetest_test
+
+import (
+	"context"
+	"database/sql"
+	"math/rand"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SpaceSlow/execenv/internal/metrics"
+	"github.com/SpaceSlow/execenv/internal/storages"
+)
+
+func TestDBStorage_CheckConnection(t *testing.T) {
+	s := newDBStorage(t).(*storages.DBStorage)
+	assert.True(t, s.CheckConnection(context.Background()))
+
+	require.NoError(t, s.Close(context.Background()))
+	assert.False(t, s.CheckConnection(context.Background()))
+}
+
+func TestDBStorage_MigrateUniqueName(t *testing.T) {
+	if postgres == nil {
+		t.Skip("Docker is not available")
+	}
+	ctx := context.Background()
+	dsn := postgres.NewDatabase(t)
+
+	// таблица прежних версий: имя метрики ограничено 30 символами и уникально независимо от типа
+	db, err := sql.Open("pgx", dsn)
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, `
+		CREATE TABLE metrics (
+			id 			SERIAL PRIMARY KEY,
+			name 		VARCHAR(30) UNIQUE NOT NULL,
+			is_gauge 	BOOLEAN NOT NULL,
+			delta 		BIGINT,
+			value		DOUBLE PRECISION
+		);
+		INSERT INTO metrics (name, is_gauge, delta) VALUES ('Metric', FALSE, 42);
+		`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	s, err := storages.NewDBStorage(ctx, dsn, []time.Duration{time.Second})
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, s.Close(ctx))
+	}()
+
+	g := metrics.NewGauge("Metric", 4.2)
+	_, err = s.Add(ctx, &g)
+	require.NoError(t, err)
+	c := metrics.NewCounter("Metric", 1)
+	_, err = s.Add(ctx, &c)
+	require.NoError(t, err)
+	long := metrics.NewGauge("http_requests_total{code=200,method=GET}", 1)
+	_, err = s.Add(ctx, &long)
+	require.NoError(t, err)
+
+	metricSlice, err := s.List(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []metrics.Metric{metrics.NewCounter("Metric", 43), g, long}, metricSlice)
+}
+
+func BenchmarkDBStorage_Batch(b *testing.B) {
+	if postgres == nil {
+		b.Skip("Docker is not available")
+	}
+	ctx := context.Background()
+	s, err := storages.NewDBStorage(ctx, postgres.NewDatabase(b), []time.Duration{time.Second})
+	require.NoError(b, err)
+	defer s.Close(ctx)
+
+	metricSlice := make([]metrics.Metric, 50)
+	for i := range metricSlice {
+		name := "Metric" + strconv.Itoa(rand.Intn(1000))
+		if rand.Intn(2) == 0 {
+			metricSlice[i] = metrics.NewCounter(name, rand.Int63n(1000))
+		} else {
+			metricSlice[i] = metrics.NewGauge(name, rand.Float64())
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err = s.Batch(ctx, metricSlice); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
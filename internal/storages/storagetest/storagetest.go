@@ -0,0 +1,434 @@
+// Package storagetest содержит общий набор тестов, которому должна удовлетворять любая реализация storages.MetricStorage.
+//
+// Пример подключения нового хранилища:
+//
+//	func TestMyStorage(t *testing.T) {
+//		storagetest.Run(t, func(t *testing.T) storages.MetricStorage {
+//			return NewMyStorage()
+//		})
+//	}
+package storagetest
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SpaceSlow/execenv/internal/metrics"
+	"github.com/SpaceSlow/execenv/internal/storages"
+)
+
+// Factory создаёт новое пустое хранилище для каждого теста набора.
+// Закрытие хранилища выполняется набором тестов.
+type Factory func(t *testing.T) storages.MetricStorage
+
+// Run запускает весь набор тестов для хранилища, создаваемого newStorage.
+func Run(t *testing.T, newStorage Factory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, storage storages.MetricStorage)
+	}{
+		{name: "CounterAccumulation", test: testCounterAccumulation},
+		{name: "GaugeOverwrite", test: testGaugeOverwrite},
+		{name: "SameNameDifferentTypes", test: testSameNameDifferentTypes},
+		{name: "LongName", test: testLongName},
+		{name: "IncorrectMetric", test: testIncorrectMetric},
+		{name: "GetNotExisting", test: testGetNotExisting},
+		{name: "Batch", test: testBatch},
+		{name: "BatchAtomicity", test: testBatchAtomicity},
+		{name: "BatchEach", test: testBatchEach},
+		{name: "ConcurrentWriters", test: testConcurrentWriters},
+		{name: "ListConsistency", test: testListConsistency},
+		{name: "ListFiltered", test: testListFiltered},
+		{name: "ListFilteredPagination", test: testListFilteredPagination},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := newStorage(t)
+			defer func() {
+				assert.NoError(t, storage.Close(context.Background()))
+			}()
+			tt.test(t, storage)
+		})
+	}
+}
+
+var (
+	counter = metrics.NewCounter
+	gauge   = metrics.NewGauge
+)
+
+func requireMetric(t *testing.T, storage storages.MetricStorage, want metrics.Metric) {
+	t.Helper()
+
+	got, err := storage.Get(context.Background(), want.Type, want.Name)
+	require.NoError(t, err, "metric %s not found", want.String())
+	assert.Equal(t, want, *got)
+}
+
+func requireList(t *testing.T, storage storages.MetricStorage) []metrics.Metric {
+	t.Helper()
+
+	metricSlice, err := storage.List(context.Background())
+	require.NoError(t, err)
+	return metricSlice
+}
+
+func testCounterAccumulation(t *testing.T, storage storages.MetricStorage) {
+	ctx := context.Background()
+	deltas := []int64{5, 10, -3, 0, 100}
+
+	var sum int64
+	for _, delta := range deltas {
+		metric := counter("PollCount", delta)
+		sum += delta
+
+		updMetric, err := storage.Add(ctx, &metric)
+		require.NoError(t, err)
+		assert.Equal(t, counter("PollCount", sum), *updMetric)
+	}
+
+	requireMetric(t, storage, counter("PollCount", sum))
+}
+
+func testGaugeOverwrite(t *testing.T, storage storages.MetricStorage) {
+	ctx := context.Background()
+	values := []float64{1.21, 0, -7.5, 1e10, 6.54}
+
+	for _, value := range values {
+		metric := gauge("RandomValue", value)
+
+		updMetric, err := storage.Add(ctx, &metric)
+		require.NoError(t, err)
+		assert.Equal(t, gauge("RandomValue", value), *updMetric)
+		requireMetric(t, storage, gauge("RandomValue", value))
+	}
+}
+
+func testSameNameDifferentTypes(t *testing.T, storage storages.MetricStorage) {
+	ctx := context.Background()
+	c := counter("Metric", 42)
+	g := gauge("Metric", 4.2)
+
+	_, err := storage.Add(ctx, &c)
+	require.NoError(t, err)
+	_, err = storage.Add(ctx, &g)
+	require.NoError(t, err)
+
+	requireMetric(t, storage, c)
+	requireMetric(t, storage, g)
+}
+
+// testLongName проверяет имена с метками, которые формируют приёмники OTLP, remote write, InfluxDB и Graphite.
+func testLongName(t *testing.T, storage storages.MetricStorage) {
+	ctx := context.Background()
+	name := "http_server_request_duration_seconds_count{code=200,handler=/api/v1/query_range,instance=web01.example.com:9090,method=GET}"
+	c := counter(name, 3)
+	g := gauge(name+".max", 0.25)
+
+	_, err := storage.Add(ctx, &c)
+	require.NoError(t, err)
+	require.NoError(t, storage.Batch(ctx, []metrics.Metric{c, g}))
+
+	requireMetric(t, storage, counter(name, 6))
+	requireMetric(t, storage, g)
+}
+
+func testIncorrectMetric(t *testing.T, storage storages.MetricStorage) {
+	ctx := context.Background()
+	incorrectMetrics := []metrics.Metric{
+		{Type: metrics.MetricType(-1), Name: "PollCount", Delta: 5},
+		{Type: metrics.MetricType(0), Name: "RandomValue", Value: 5.5},
+	}
+
+	for _, metric := range incorrectMetrics {
+		updMetric, err := storage.Add(ctx, &metric)
+		assert.ErrorIs(t, err, metrics.ErrIncorrectMetricTypeOrValue, metric.String())
+		assert.Nil(t, updMetric)
+	}
+
+	long := counter(strings.Repeat("a", metrics.MaxNameLength+1), 1)
+	_, err := storage.Add(ctx, &long)
+	assert.ErrorIs(t, err, metrics.ErrMetricNameTooLong)
+	assert.ErrorIs(t, storage.Batch(ctx, []metrics.Metric{long}), metrics.ErrMetricNameTooLong)
+
+	assert.Empty(t, requireList(t, storage))
+}
+
+func testGetNotExisting(t *testing.T, storage storages.MetricStorage) {
+	ctx := context.Background()
+	metric := counter("PollCount", 1)
+	_, err := storage.Add(ctx, &metric)
+	require.NoError(t, err)
+
+	_, err = storage.Get(ctx, metrics.Counter, "NotExistMetric")
+	assert.ErrorIs(t, err, storages.ErrMetricNotFound)
+	_, err = storage.Get(ctx, metrics.Gauge, "PollCount")
+	assert.ErrorIs(t, err, storages.ErrMetricNotFound)
+	_, err = storage.Get(ctx, metrics.MetricType(-1), "PollCount")
+	assert.ErrorIs(t, err, metrics.ErrIncorrectMetricTypeOrValue)
+}
+
+func testBatch(t *testing.T, storage storages.MetricStorage) {
+	ctx := context.Background()
+	initial := []metrics.Metric{counter("PollCount", 5), gauge("RandomValue", 1.1)}
+	require.NoError(t, storage.Batch(ctx, initial))
+
+	err := storage.Batch(ctx, []metrics.Metric{
+		counter("PollCount", 10),
+		counter("PollCount", 20),
+		gauge("RandomValue", 2.2),
+		gauge("RandomValue", 3.3),
+		counter("NewCounter", 1),
+	})
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []metrics.Metric{
+		counter("PollCount", 35),
+		gauge("RandomValue", 3.3),
+		counter("NewCounter", 1),
+	}, requireList(t, storage))
+}
+
+func testBatchAtomicity(t *testing.T, storage storages.MetricStorage) {
+	ctx := context.Background()
+	initial := []metrics.Metric{counter("PollCount", 5), gauge("RandomValue", 1.1)}
+	require.NoError(t, storage.Batch(ctx, initial))
+
+	batches := [][]metrics.Metric{
+		{
+			{Type: metrics.MetricType(-1), Name: "PollCount", Delta: 50},
+			counter("PollCount", 1),
+		},
+		{
+			counter("PollCount", 1),
+			gauge("RandomValue", 2.2),
+			gauge("NewGauge", 3.3),
+			{Type: metrics.MetricType(-1), Name: "PollCount", Delta: 50},
+		},
+		{
+			counter("PollCount", 1),
+			{Type: metrics.MetricType(0), Name: "PollCount", Delta: 1},
+		},
+	}
+
+	for i, batch := range batches {
+		err := storage.Batch(ctx, batch)
+		assert.ErrorIs(t, err, metrics.ErrIncorrectMetricTypeOrValue, "batch #%d", i)
+		assert.ElementsMatch(t, initial, requireList(t, storage), "batch #%d", i)
+	}
+}
+
+func testBatchEach(t *testing.T, storage storages.MetricStorage) {
+	ctx := context.Background()
+	initial := []metrics.Metric{counter("PollCount", 5), gauge("RandomValue", 1.1)}
+	require.NoError(t, storage.Batch(ctx, initial))
+
+	accepted, itemErrors, err := storages.BatchEach(ctx, storage, []metrics.Metric{
+		{Type: metrics.MetricType(-1), Name: "PollCount", Delta: 50},
+		counter("PollCount", 1),
+		gauge("RandomValue", 2.2),
+		{Type: metrics.MetricType(0), Name: "NewGauge", Value: 1},
+		gauge("NewGauge", 3.3),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, accepted)
+	require.Len(t, itemErrors, 2)
+	assert.Equal(t, 0, itemErrors[0].Index)
+	assert.Equal(t, 3, itemErrors[1].Index)
+	for _, itemErr := range itemErrors {
+		assert.ErrorIs(t, itemErr, metrics.ErrIncorrectMetricTypeOrValue)
+	}
+
+	assert.ElementsMatch(t, []metrics.Metric{
+		counter("PollCount", 6),
+		gauge("RandomValue", 2.2),
+		gauge("NewGauge", 3.3),
+	}, requireList(t, storage))
+}
+
+func testConcurrentWriters(t *testing.T, storage storages.MetricStorage) {
+	ctx := context.Background()
+	const (
+		writers = 8
+		writes  = 50
+	)
+
+	var wg sync.WaitGroup
+	wg.Add(writers)
+	for w := 0; w < writers; w++ {
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < writes; i++ {
+				c := counter("SharedCounter", 1)
+				if _, err := storage.Add(ctx, &c); err != nil {
+					t.Error(err)
+					return
+				}
+				g := gauge(fmt.Sprintf("Gauge-%d", w), float64(i))
+				if _, err := storage.Add(ctx, &g); err != nil {
+					t.Error(err)
+					return
+				}
+				if err := storage.Batch(ctx, []metrics.Metric{counter("BatchCounter", 2)}); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	requireMetric(t, storage, counter("SharedCounter", writers*writes))
+	requireMetric(t, storage, counter("BatchCounter", 2*writers*writes))
+	for w := 0; w < writers; w++ {
+		requireMetric(t, storage, gauge(fmt.Sprintf("Gauge-%d", w), writes-1))
+	}
+}
+
+func testListConsistency(t *testing.T, storage storages.MetricStorage) {
+	ctx := context.Background()
+	const writes = 100
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 1; i <= writes; i++ {
+			err := storage.Batch(ctx, []metrics.Metric{counter("First", 1), counter("Second", 1)})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+
+		values := make(map[string]int64)
+		for _, metric := range requireList(t, storage) {
+			require.Equal(t, metrics.MetricType(metrics.Counter), metric.Type)
+			_, duplicated := values[metric.Name]
+			require.False(t, duplicated, "duplicated metric %s", metric.Name)
+			values[metric.Name] = metric.Delta
+		}
+		require.Equal(t, values["First"], values["Second"], "List returned partially applied batch")
+	}
+
+	assert.ElementsMatch(t, []metrics.Metric{counter("First", writes), counter("Second", writes)}, requireList(t, storage))
+}
+
+func testListFiltered(t *testing.T, storage storages.MetricStorage) {
+	ctx := context.Background()
+	require.NoError(t, storage.Batch(ctx, []metrics.Metric{
+		counter("PollCount", 5),
+		gauge("RandomValue", 1.1),
+		gauge("HeapAlloc", 2.2),
+		counter("HeapObjects", 3),
+		gauge("HeapObjects", 4.4),
+	}))
+
+	tests := []struct {
+		name   string
+		filter storages.ListFilter
+		want   []metrics.Metric
+	}{
+		{
+			name: "without conditions",
+			want: []metrics.Metric{
+				gauge("HeapAlloc", 2.2), counter("HeapObjects", 3), gauge("HeapObjects", 4.4),
+				counter("PollCount", 5), gauge("RandomValue", 1.1),
+			},
+		},
+		{
+			name:   "only gauges",
+			filter: storages.ListFilter{Type: metrics.Gauge},
+			want:   []metrics.Metric{gauge("HeapAlloc", 2.2), gauge("HeapObjects", 4.4), gauge("RandomValue", 1.1)},
+		},
+		{
+			name:   "by name prefix",
+			filter: storages.ListFilter{NamePrefix: "Heap"},
+			want:   []metrics.Metric{gauge("HeapAlloc", 2.2), counter("HeapObjects", 3), gauge("HeapObjects", 4.4)},
+		},
+		{
+			name:   "by name regexp with limit",
+			filter: storages.ListFilter{NameRegexp: regexp.MustCompile("(Objects|Count)$"), Limit: 2},
+			want:   []metrics.Metric{counter("HeapObjects", 3), gauge("HeapObjects", 4.4)},
+		},
+		{
+			name:   "ordered by name descending",
+			filter: storages.ListFilter{Order: storages.OrderByNameDesc, Limit: 3},
+			want:   []metrics.Metric{gauge("RandomValue", 1.1), counter("PollCount", 5), gauge("HeapObjects", 4.4)},
+		},
+		{
+			name:   "ordered by type",
+			filter: storages.ListFilter{Order: storages.OrderByType, NamePrefix: "Heap"},
+			want:   []metrics.Metric{counter("HeapObjects", 3), gauge("HeapAlloc", 2.2), gauge("HeapObjects", 4.4)},
+		},
+		{
+			name:   "ordered by type descending",
+			filter: storages.ListFilter{Order: storages.OrderByTypeDesc, NamePrefix: "Heap"},
+			want:   []metrics.Metric{gauge("HeapObjects", 4.4), gauge("HeapAlloc", 2.2), counter("HeapObjects", 3)},
+		},
+		{
+			name:   "after key",
+			filter: storages.ListFilter{After: &storages.MetricKey{Name: "HeapObjects", Type: metrics.Counter}},
+			want:   []metrics.Metric{gauge("HeapObjects", 4.4), counter("PollCount", 5), gauge("RandomValue", 1.1)},
+		},
+		{
+			name:   "nothing found",
+			filter: storages.ListFilter{NamePrefix: "Unknown"},
+			want:   []metrics.Metric{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := storage.ListFiltered(ctx, tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func testListFilteredPagination(t *testing.T, storage storages.MetricStorage) {
+	ctx := context.Background()
+	const count = 25
+
+	want := make([]metrics.Metric, 0, 2*count)
+	for i := 0; i < count; i++ {
+		want = append(want, counter(fmt.Sprintf("Metric%02d", i), int64(i)), gauge(fmt.Sprintf("Metric%02d", i), float64(i)))
+	}
+	require.NoError(t, storage.Batch(ctx, want))
+
+	for _, order := range []storages.ListOrder{storages.OrderByName, storages.OrderByNameDesc, storages.OrderByType, storages.OrderByTypeDesc} {
+		filter := storages.ListFilter{Order: order, Limit: 7}
+		got := make([]metrics.Metric, 0, len(want))
+		for {
+			page, err := storage.ListFiltered(ctx, filter)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(page), filter.Limit)
+			got = append(got, page...)
+			if len(page) < filter.Limit {
+				break
+			}
+			last := page[len(page)-1]
+			filter.After = &storages.MetricKey{Name: last.Name, Type: last.Type}
+		}
+
+		assert.ElementsMatch(t, want, got, "order %d", order)
+		assert.True(t, slices.IsSortedFunc(got, func(a, b metrics.Metric) int {
+			return filter.Compare(storages.MetricKey{Name: a.Name, Type: a.Type}, storages.MetricKey{Name: b.Name, Type: b.Type})
+		}), "order %d", order)
+	}
+}
@@ -0,0 +1,79 @@
+package storagetest_test
+
+import (
+	"context"
+	"log"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/SpaceSlow/execenv/internal/storages"
+	"github.com/SpaceSlow/execenv/internal/storages/storagetest"
+)
+
+// postgres контейнер PostgreSQL для тестов DBStorage, nil, если Docker недоступен.
+var postgres *postgresContainer
+
+func TestMain(m *testing.M) {
+	var err error
+	postgres, err = startPostgres()
+	if err != nil {
+		log.Printf("DBStorage tests are skipped: %s", err)
+	}
+
+	code := m.Run()
+	if postgres != nil {
+		if err = postgres.Stop(); err != nil {
+			log.Printf("Could not stop postgres container: %s", err)
+		}
+	}
+	os.Exit(code)
+}
+
+func TestMemStorage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storages.MetricStorage {
+		return storages.NewMemStorage()
+	})
+}
+
+func TestMemFileStorage_SyncStore(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storages.MetricStorage {
+		s, err := storages.NewMemFileStorage(context.Background(), path.Join(t.TempDir(), "metrics.json"), 0, false)
+		require.NoError(t, err)
+		return s
+	})
+}
+
+func TestMemFileStorage_IntervalStore(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storages.MetricStorage {
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		s, err := storages.NewMemFileStorage(ctx, path.Join(t.TempDir(), "metrics.json"), time.Millisecond, false)
+		require.NoError(t, err)
+		return s
+	})
+}
+
+func TestSQLiteStorage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storages.MetricStorage {
+		s, err := storages.NewSQLiteStorage(context.Background(), storages.SQLiteDSNPrefix+path.Join(t.TempDir(), "metrics.db"))
+		require.NoError(t, err)
+		return s
+	})
+}
+
+func TestDBStorage(t *testing.T) {
+	storagetest.Run(t, newDBStorage)
+}
+
+func newDBStorage(t *testing.T) storages.MetricStorage {
+	if postgres == nil {
+		t.Skip("Docker is not available")
+	}
+	s, err := storages.NewDBStorage(context.Background(), postgres.NewDatabase(t), []time.Duration{time.Second})
+	require.NoError(t, err)
+	return s
+}
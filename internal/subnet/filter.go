@@ -0,0 +1,104 @@
+// Package subnet ограничивает доступ к серверу по IP-адресу клиента: списки разрешённых и запрещённых подсетей.
+//
+// Адрес клиента определяется по адресу соединения. Заголовки X-Forwarded-For и X-Real-IP учитываются,
+// только если соединение установлено с одного из доверенных прокси-серверов, иначе клиент мог бы указать
+// в них произвольный адрес.
+package subnet
+
+import (
+	"errors"
+	"net"
+	"strings"
+)
+
+var (
+	// ErrDenied адрес клиента входит в запрещённую подсеть.
+	ErrDenied = errors.New("client address is in denied subnet")
+	// ErrNotAllowed адрес клиента не входит ни в одну из разрешённых подсетей.
+	ErrNotAllowed = errors.New("client address is not in trusted subnet")
+)
+
+// Filter проверяет адреса клиентов. Нулевое значение разрешает любые адреса.
+type Filter struct {
+	// Allowed разрешённые подсети; пустой список разрешает все адреса, кроме запрещённых
+	Allowed []*net.IPNet
+	// Denied запрещённые подсети, имеют приоритет над Allowed
+	Denied []*net.IPNet
+	// TrustedProxies подсети прокси-серверов, которым разрешено передавать адрес клиента в заголовках
+	TrustedProxies []*net.IPNet
+}
+
+// Enabled сообщает, ограничен ли доступ по адресу клиента.
+func (f Filter) Enabled() bool {
+	return len(f.Allowed) > 0 || len(f.Denied) > 0
+}
+
+// Check проверяет, разрешён ли доступ клиенту с адресом ip.
+func (f Filter) Check(ip net.IP) error {
+	if ip == nil {
+		if len(f.Allowed) > 0 {
+			return ErrNotAllowed
+		}
+		if len(f.Denied) > 0 {
+			return ErrDenied
+		}
+		return nil
+	}
+	if contains(f.Denied, ip) {
+		return ErrDenied
+	}
+	if len(f.Allowed) > 0 && !contains(f.Allowed, ip) {
+		return ErrNotAllowed
+	}
+	return nil
+}
+
+// ClientIP возвращает адрес клиента соединения с адреса remoteAddr (host:port или IP).
+// Если remoteAddr — доверенный прокси, адрес клиента берётся из значений заголовка X-Forwarded-For
+// (последний адрес, не принадлежащий доверенным прокси) или, при его отсутствии, из заголовка X-Real-IP.
+func (f Filter) ClientIP(remoteAddr string, forwardedFor []string, realIP string) net.IP {
+	ip := parseIP(remoteAddr)
+	if ip == nil || !contains(f.TrustedProxies, ip) {
+		return ip
+	}
+
+	hops := make([]string, 0)
+	for _, value := range forwardedFor {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+	if len(hops) > 0 {
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := net.ParseIP(strings.TrimSpace(hops[i]))
+			if hop == nil {
+				// адрес до некорректного значения нельзя считать достоверным
+				return ip
+			}
+			ip = hop
+			if !contains(f.TrustedProxies, hop) {
+				return hop
+			}
+		}
+		return ip
+	}
+
+	if forwarded := net.ParseIP(strings.TrimSpace(realIP)); forwarded != nil {
+		return forwarded
+	}
+	return ip
+}
+
+func parseIP(addr string) net.IP {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	return net.ParseIP(addr)
+}
+
+func contains(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
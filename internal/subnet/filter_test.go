@@ -0,0 +1,132 @@
+package subnet
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mustParseCIDRs(t *testing.T, cidrs ...string) []*net.IPNet {
+	t.Helper()
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
+
+func TestFilter_Check(t *testing.T) {
+	filter := Filter{
+		Allowed: mustParseCIDRs(t, "192.168.0.0/16", "fd00::/8"),
+		Denied:  mustParseCIDRs(t, "192.168.10.0/24"),
+	}
+	tests := []struct {
+		name    string
+		filter  Filter
+		ip      string
+		wantErr error
+	}{
+		{
+			name:   "allowed ipv4",
+			filter: filter,
+			ip:     "192.168.1.1",
+		},
+		{
+			name:   "allowed ipv6",
+			filter: filter,
+			ip:     "fd00::1",
+		},
+		{
+			name:    "denied takes precedence",
+			filter:  filter,
+			ip:      "192.168.10.5",
+			wantErr: ErrDenied,
+		},
+		{
+			name:    "not allowed",
+			filter:  filter,
+			ip:      "10.0.0.1",
+			wantErr: ErrNotAllowed,
+		},
+		{
+			name:    "unknown address",
+			filter:  filter,
+			wantErr: ErrNotAllowed,
+		},
+		{
+			name:   "only deny list",
+			filter: Filter{Denied: filter.Denied},
+			ip:     "10.0.0.1",
+		},
+		{
+			name: "disabled",
+			ip:   "10.0.0.1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, tt.filter.Check(net.ParseIP(tt.ip)), tt.wantErr)
+		})
+	}
+}
+
+func TestFilter_ClientIP(t *testing.T) {
+	filter := Filter{TrustedProxies: mustParseCIDRs(t, "10.0.0.0/8", "::1/128")}
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor []string
+		realIP       string
+		want         string
+	}{
+		{
+			name:       "direct connection ignores headers",
+			remoteAddr: "192.168.0.5:4321",
+			forwardedFor: []string{
+				"172.16.0.1",
+			},
+			realIP: "172.16.0.2",
+			want:   "192.168.0.5",
+		},
+		{
+			name:       "x-real-ip from trusted proxy",
+			remoteAddr: "10.0.0.1:4321",
+			realIP:     "172.16.0.2",
+			want:       "172.16.0.2",
+		},
+		{
+			name:         "last untrusted x-forwarded-for hop",
+			remoteAddr:   "[::1]:4321",
+			forwardedFor: []string{"1.1.1.1, 172.16.0.1", "10.0.0.2"},
+			realIP:       "172.16.0.2",
+			want:         "172.16.0.1",
+		},
+		{
+			name:         "invalid x-forwarded-for hop",
+			remoteAddr:   "10.0.0.1:4321",
+			forwardedFor: []string{"172.16.0.1, unknown, 10.0.0.3"},
+			want:         "10.0.0.3",
+		},
+		{
+			name:       "proxy without headers",
+			remoteAddr: "10.0.0.1:4321",
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "ip without port",
+			remoteAddr: "fd00::1",
+			want:       "fd00::1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filter.ClientIP(tt.remoteAddr, tt.forwardedFor, tt.realIP)
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
@@ -20,12 +20,9 @@ type MetricWorkers struct {
 	pollCount atomic.Int64
 }
 
-func NewMetricWorkers() (*MetricWorkers, error) {
-	cfg, err := config.GetAgentConfig()
-	if err != nil {
-		return nil, err
-	}
-	client, err := client.NewClient()
+// NewMetricWorkers создаёт обработчики метрик агента с конфигурацией cfg.
+func NewMetricWorkers(cfg *config.AgentConfig) (*MetricWorkers, error) {
+	client, err := client.NewClient(cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -56,17 +53,9 @@ func (mw *MetricWorkers) Poll(pollCh chan []metrics.Metric) {
 	}
 	metricSlice = append(
 		metricSlice,
-		metrics.Metric{
-			Type:  metrics.Gauge,
-			Name:  "RandomValue",
-			Value: rand.Float64(),
-		},
+		metrics.NewGauge("RandomValue", rand.Float64()),
 	)
-	metricSlice = append(metricSlice, metrics.Metric{
-		Type:  metrics.Counter,
-		Name:  "PollCount",
-		Value: mw.pollCount.Add(1),
-	})
+	metricSlice = append(metricSlice, metrics.NewCounter("PollCount", mw.pollCount.Add(1)))
 
 	if len(pollCh) > 0 {
 		<-pollCh
@@ -91,21 +80,9 @@ func (mw *MetricWorkers) getGopsutilMetrics() chan []metrics.Metric {
 		cpu, _ := cpu.Percent(0, false)
 
 		metrics := []metrics.Metric{
-			{
-				Type:  metrics.Gauge,
-				Name:  "TotalMemory",
-				Value: float64(v.Total),
-			},
-			{
-				Type:  metrics.Gauge,
-				Name:  "FreeMemory",
-				Value: float64(v.Free),
-			},
-			{
-				Type:  metrics.Gauge,
-				Name:  "CPUtilization1",
-				Value: cpu[0],
-			},
+			metrics.NewGauge("TotalMemory", float64(v.Total)),
+			metrics.NewGauge("FreeMemory", float64(v.Free)),
+			metrics.NewGauge("CPUtilization1", cpu[0]),
 		}
 		metricsCh <- metrics
 	}()
@@ -122,146 +99,34 @@ func (mw *MetricWorkers) getRuntimeMetrics() chan []metrics.Metric {
 		runtime.ReadMemStats(&rtm)
 
 		metrics := []metrics.Metric{
-			{
-				Type:  metrics.Gauge,
-				Name:  "Alloc",
-				Value: float64(rtm.Alloc),
-			},
-			{
-				Type:  metrics.Gauge,
-				Name:  "BuckHashSys",
-				Value: float64(rtm.BuckHashSys),
-			},
-			{
-				Type:  metrics.Gauge,
-				Name:  "Frees",
-				Value: float64(rtm.Frees),
-			},
-			{
-				Type:  metrics.Gauge,
-				Name:  "GCCPUFraction",
-				Value: float64(rtm.GCCPUFraction),
-			},
-			{
-				Type:  metrics.Gauge,
-				Name:  "GCSys",
-				Value: float64(rtm.GCSys),
-			},
-			{
-				Type:  metrics.Gauge,
-				Name:  "HeapAlloc",
-				Value: float64(rtm.HeapAlloc),
-			},
-			{
-				Type:  metrics.Gauge,
-				Name:  "HeapIdle",
-				Value: float64(rtm.HeapIdle),
-			},
-			{
-				Type:  metrics.Gauge,
-				Name:  "HeapInuse",
-				Value: float64(rtm.HeapInuse),
-			},
-			{
-				Type:  metrics.Gauge,
-				Name:  "HeapObjects",
-				Value: float64(rtm.HeapObjects),
-			},
-			{
-				Type:  metrics.Gauge,
-				Name:  "HeapReleased",
-				Value: float64(rtm.HeapReleased),
-			},
-			{
-				Type:  metrics.Gauge,
-				Name:  "HeapSys",
-				Value: float64(rtm.HeapSys),
-			},
-			{
-				Type:  metrics.Gauge,
-				Name:  "LastGC",
-				Value: float64(rtm.LastGC),
-			},
-			{
-				Type:  metrics.Gauge,
-				Name:  "Lookups",
-				Value: float64(rtm.Lookups),
-			},
-			{
-				Type:  metrics.Gauge,
-				Name:  "MCacheInuse",
-				Value: float64(rtm.MCacheInuse),
-			},
-			{
-				Type:  metrics.Gauge,
-				Name:  "MCacheSys",
-				Value: float64(rtm.MCacheSys),
-			},
-			{
-				Type:  metrics.Gauge,
-				Name:  "MSpanInuse",
-				Value: float64(rtm.MSpanInuse),
-			},
-			{
-				Type:  metrics.Gauge,
-				Name:  "MSpanSys",
-				Value: float64(rtm.MSpanSys),
-			},
-			{
-				Type:  metrics.Gauge,
-				Name:  "Mallocs",
-				Value: float64(rtm.Mallocs),
-			},
-			{
-				Type:  metrics.Gauge,
-				Name:  "NextGC",
-				Value: float64(rtm.NextGC),
-			},
-			{
-				Type:  metrics.Gauge,
-				Name:  "NumForcedGC",
-				Value: float64(rtm.NumForcedGC),
-			},
-			{
-				Type:  metrics.Gauge,
-				Name:  "NumGC",
-				Value: float64(rtm.NumGC),
-			},
-			{
-				Type:  metrics.Gauge,
-				Name:  "OtherSys",
-				Value: float64(rtm.OtherSys),
-			},
-			{
-				Type:  metrics.Gauge,
-				Name:  "PauseTotalNs",
-				Value: float64(rtm.PauseTotalNs),
-			},
-			{
-				Type:  metrics.Gauge,
-				Name:  "StackInuse",
-				Value: float64(rtm.StackInuse),
-			},
-			{
-				Type:  metrics.Gauge,
-				Name:  "PauseTotalNs",
-				Value: float64(rtm.PauseTotalNs),
-			},
-			{
-				Type:  metrics.Gauge,
-				Name:  "StackSys",
-				Value: float64(rtm.StackSys),
-			},
-			{
-				Type:  metrics.Gauge,
-				Name:  "Sys",
-				Value: float64(rtm.Sys),
-			},
-			{
-				Type:  metrics.Gauge,
-				Name:  "TotalAlloc",
-				Value: float64(rtm.TotalAlloc),
-			},
+			metrics.NewGauge("Alloc", float64(rtm.Alloc)),
+			metrics.NewGauge("BuckHashSys", float64(rtm.BuckHashSys)),
+			metrics.NewGauge("Frees", float64(rtm.Frees)),
+			metrics.NewGauge("GCCPUFraction", float64(rtm.GCCPUFraction)),
+			metrics.NewGauge("GCSys", float64(rtm.GCSys)),
+			metrics.NewGauge("HeapAlloc", float64(rtm.HeapAlloc)),
+			metrics.NewGauge("HeapIdle", float64(rtm.HeapIdle)),
+			metrics.NewGauge("HeapInuse", float64(rtm.HeapInuse)),
+			metrics.NewGauge("HeapObjects", float64(rtm.HeapObjects)),
+			metrics.NewGauge("HeapReleased", float64(rtm.HeapReleased)),
+			metrics.NewGauge("HeapSys", float64(rtm.HeapSys)),
+			metrics.NewGauge("LastGC", float64(rtm.LastGC)),
+			metrics.NewGauge("Lookups", float64(rtm.Lookups)),
+			metrics.NewGauge("MCacheInuse", float64(rtm.MCacheInuse)),
+			metrics.NewGauge("MCacheSys", float64(rtm.MCacheSys)),
+			metrics.NewGauge("MSpanInuse", float64(rtm.MSpanInuse)),
+			metrics.NewGauge("MSpanSys", float64(rtm.MSpanSys)),
+			metrics.NewGauge("Mallocs", float64(rtm.Mallocs)),
+			metrics.NewGauge("NextGC", float64(rtm.NextGC)),
+			metrics.NewGauge("NumForcedGC", float64(rtm.NumForcedGC)),
+			metrics.NewGauge("NumGC", float64(rtm.NumGC)),
+			metrics.NewGauge("OtherSys", float64(rtm.OtherSys)),
+			metrics.NewGauge("PauseTotalNs", float64(rtm.PauseTotalNs)),
+			metrics.NewGauge("StackInuse", float64(rtm.StackInuse)),
+			metrics.NewGauge("PauseTotalNs", float64(rtm.PauseTotalNs)),
+			metrics.NewGauge("StackSys", float64(rtm.StackSys)),
+			metrics.NewGauge("Sys", float64(rtm.Sys)),
+			metrics.NewGauge("TotalAlloc", float64(rtm.TotalAlloc)),
 		}
 		metricsCh <- metrics
 	}()
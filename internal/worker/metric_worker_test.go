@@ -2,16 +2,16 @@ package worker
 
 import (
 	"errors"
-	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/SpaceSlow/execenv/internal/config"
 )
 
 func TestMetricWorkers_Err(t *testing.T) {
-	cleanArgs()
-	mw, err := NewMetricWorkers()
+	mw, err := NewMetricWorkers(newTestConfig(t))
 	require.NoError(t, err)
 	mw.errorsCh <- errors.New("some error")
 	mw.Close()
@@ -20,8 +20,7 @@ func TestMetricWorkers_Err(t *testing.T) {
 }
 
 func TestMetricWorkers_getGopsutilMetrics(t *testing.T) {
-	cleanArgs()
-	mw, err := NewMetricWorkers()
+	mw, err := NewMetricWorkers(newTestConfig(t))
 	require.NoError(t, err)
 	metrics := <-mw.getGopsutilMetrics()
 	assert.Greater(t, len(metrics), 0)
@@ -32,8 +31,7 @@ func TestMetricWorkers_getGopsutilMetrics(t *testing.T) {
 }
 
 func TestMetricWorkers_getRuntimeMetrics(t *testing.T) {
-	cleanArgs()
-	mw, err := NewMetricWorkers()
+	mw, err := NewMetricWorkers(newTestConfig(t))
 	require.NoError(t, err)
 	metrics := <-mw.getRuntimeMetrics()
 	assert.Greater(t, len(metrics), 0)
@@ -43,6 +41,9 @@ func TestMetricWorkers_getRuntimeMetrics(t *testing.T) {
 	}
 }
 
-func cleanArgs() {
-	os.Args = []string{"program"}
+func newTestConfig(t *testing.T) *config.AgentConfig {
+	t.Helper()
+	cfg, err := config.NewAgentConfig("program", nil)
+	require.NoError(t, err)
+	return cfg
 }